@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
+)
+
+type AuditController struct {
+	s interfaces.AuditServiceInterface
+}
+
+func NewAuditController(s interfaces.AuditServiceInterface) *AuditController {
+	return &AuditController{s: s}
+}
+
+// PaymentsAudit returns the recent audit reports. When from and to are given,
+// it audits that window right away and returns its report.
+func (a *AuditController) PaymentsAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if a.s == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "payment summary audit is disabled")
+		return
+	}
+
+	from, to, errMsg := parseDateRange(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	if from == nil {
+		writeJSONResponse(w, http.StatusOK, a.s.Reports())
+		return
+	}
+
+	if from.After(*to) {
+		writeErrorResponse(w, http.StatusBadRequest, "from date cannot be after to date")
+		return
+	}
+
+	report, err := a.s.Audit(r.Context(), *from, *to)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "failed to audit payment summary", err.Error())
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, report)
+}
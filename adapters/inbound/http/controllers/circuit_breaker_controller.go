@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
+)
+
+type CircuitBreakerController struct {
+	s interfaces.CircuitBreakerServiceInterface
+}
+
+func NewCircuitBreakerController(s interfaces.CircuitBreakerServiceInterface) *CircuitBreakerController {
+	return &CircuitBreakerController{s: s}
+}
+
+// defaultAdminReason is recorded when a breaker change gives no reason
+const defaultAdminReason = "admin request"
+
+// CircuitBreakers returns the state, failure count and last failure of each
+// processor's circuit breaker
+func (c *CircuitBreakerController) CircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, c.s.CircuitBreakers())
+}
+
+// Control forces a processor's circuit breaker open or closed, releases a
+// forced state or resets the breaker. An optional JSON body gives the reason.
+func (c *CircuitBreakerController) Control(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format", err.Error())
+			return
+		}
+	}
+
+	if body.Reason == "" {
+		body.Reason = defaultAdminReason
+	}
+
+	var control func(processorName, reason string) (*domain.CircuitBreakerStatus, error)
+
+	switch r.PathValue("action") {
+	case "open":
+		control = c.s.ForceCircuitBreakerOpen
+	case "close":
+		control = c.s.ForceCircuitBreakerClosed
+	case "release":
+		control = c.s.ReleaseCircuitBreaker
+	case "reset":
+		control = c.s.ResetCircuitBreaker
+	default:
+		writeErrorResponse(w, http.StatusNotFound, "unknown action, use open, close, release or reset")
+		return
+	}
+
+	status, err := control(r.PathValue("processor"), body.Reason)
+	if errors.Is(err, core.ErrProcessorNotFound) {
+		writeErrorResponse(w, http.StatusNotFound, "processor not found", err.Error())
+		return
+	}
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "failed to update circuit breaker", err.Error())
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, status)
+}
+
+// Events returns the recent state changes of each processor's circuit
+// breaker, newest first, with an empty list for a breaker that never changed.
+// The processor query parameter limits the result to one processor.
+func (c *CircuitBreakerController) Events(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	events := c.s.Events()
+	for _, status := range c.s.CircuitBreakers() {
+		if events[status.Processor] == nil {
+			events[status.Processor] = []domain.CircuitBreakerEvent{}
+		}
+	}
+
+	if processor := r.URL.Query().Get("processor"); processor != "" {
+		processorEvents, ok := events[processor]
+		if !ok {
+			writeErrorResponse(w, http.StatusNotFound, "processor not found", fmt.Sprintf("%v: %s", core.ErrProcessorNotFound, processor))
+			return
+		}
+		events = map[string][]domain.CircuitBreakerEvent{processor: processorEvents}
+	}
+
+	writeJSONResponse(w, http.StatusOK, events)
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
+)
+
+// stubCircuitBreakerService reports the given breakers and events
+type stubCircuitBreakerService struct {
+	interfaces.CircuitBreakerServiceInterface
+	processors []string
+	events     map[string][]domain.CircuitBreakerEvent
+}
+
+func (s *stubCircuitBreakerService) CircuitBreakers() []domain.CircuitBreakerStatus {
+	statuses := make([]domain.CircuitBreakerStatus, 0, len(s.processors))
+	for _, name := range s.processors {
+		statuses = append(statuses, domain.CircuitBreakerStatus{Processor: name})
+	}
+	return statuses
+}
+
+func (s *stubCircuitBreakerService) Events() map[string][]domain.CircuitBreakerEvent {
+	events := make(map[string][]domain.CircuitBreakerEvent, len(s.events))
+	for name, e := range s.events {
+		events[name] = e
+	}
+	return events
+}
+
+func TestCircuitBreakerController_Events(t *testing.T) {
+	controller := NewCircuitBreakerController(&stubCircuitBreakerService{
+		processors: []string{"default", "fallback"},
+		events: map[string][]domain.CircuitBreakerEvent{
+			"default": {{Processor: "default", From: "closed", To: "open"}},
+		},
+	})
+
+	get := func(t *testing.T, target string) (*httptest.ResponseRecorder, map[string][]domain.CircuitBreakerEvent) {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		controller.Events(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var events map[string][]domain.CircuitBreakerEvent
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+				t.Fatalf("Expected a JSON body, got: %v", err)
+			}
+		}
+		return w, events
+	}
+
+	t.Run("Lists every processor, with no events as an empty list", func(t *testing.T) {
+		w, events := get(t, "/admin/circuit-breakers/events")
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", w.Code)
+		}
+		if len(events["default"]) != 1 {
+			t.Errorf("Expected 1 default event, got: %v", events["default"])
+		}
+		if fallback, ok := events["fallback"]; !ok || fallback == nil || len(fallback) != 0 {
+			t.Errorf("Expected an empty fallback list, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("Returns an empty list for a known processor without events", func(t *testing.T) {
+		w, events := get(t, "/admin/circuit-breakers/events?processor=fallback")
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", w.Code)
+		}
+		if fallback, ok := events["fallback"]; !ok || fallback == nil || len(events) != 1 {
+			t.Errorf("Expected only an empty fallback list, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("Returns 404 for an unknown processor", func(t *testing.T) {
+		w, _ := get(t, "/admin/circuit-breakers/events?processor=unknown")
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got: %d", w.Code)
+		}
+	})
+}
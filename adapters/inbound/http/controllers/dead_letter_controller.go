@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
+	"github.com/google/uuid"
+)
+
+// Dead letters listed per request unless the limit query parameter says otherwise
+const (
+	defaultDeadLetterLimit = 100
+	maxDeadLetterLimit     = 1000
+)
+
+type DeadLetterController struct {
+	s interfaces.DeadLetterServiceInterface
+}
+
+func NewDeadLetterController(s interfaces.DeadLetterServiceInterface) *DeadLetterController {
+	return &DeadLetterController{s: s}
+}
+
+// DeadLetters lists the dead letters, oldest failure first, paged with the
+// offset and limit query parameters
+func (c *DeadLetterController) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	offset, err := parseIntParam(r, "offset", 0)
+	if err != nil || offset < 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "offset must be a non-negative integer")
+		return
+	}
+
+	limit, err := parseIntParam(r, "limit", defaultDeadLetterLimit)
+	if err != nil || limit <= 0 || limit > maxDeadLetterLimit {
+		writeErrorResponse(w, http.StatusBadRequest, "limit must be an integer between 1 and "+strconv.Itoa(maxDeadLetterLimit))
+		return
+	}
+
+	letters, err := c.s.DeadLetters(r.Context(), offset, limit)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "failed to list dead letters", err.Error())
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, letters)
+}
+
+// DeadLetter returns a dead letter with the error of each attempt
+func (c *DeadLetterController) DeadLetter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	letter, err := c.s.DeadLetter(r.Context(), id)
+	if err != nil {
+		writeDeadLetterError(w, "failed to get dead letter", err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, letter)
+}
+
+// Replay processes a dead-lettered payment again and removes it once processed
+func (c *DeadLetterController) Replay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.s.Replay(r.Context(), id); err != nil {
+		writeDeadLetterError(w, "failed to replay dead letter", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReplayAll processes every dead-lettered payment again and reports the ones
+// that failed again
+func (c *DeadLetterController) ReplayAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	result, err := c.s.ReplayAll(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "failed to replay dead letters", err.Error())
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, result)
+}
+
+// Discard removes a dead letter without processing it
+func (c *DeadLetterController) Discard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.s.Discard(r.Context(), id); err != nil {
+		writeDeadLetterError(w, "failed to discard dead letter", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DiscardAll removes every dead letter without processing them
+func (c *DeadLetterController) DiscardAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	discarded, err := c.s.DiscardAll(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "failed to discard dead letters", err.Error())
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]int64{"discarded": discarded})
+}
+
+// deadLetterID parses the id path value, answering 400 when it is not a UUID
+func deadLetterID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid dead letter id", err.Error())
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// writeDeadLetterError answers 404 for unknown dead letters and 500 otherwise
+func writeDeadLetterError(w http.ResponseWriter, message string, err error) {
+	if errors.Is(err, core.ErrDeadLetterNotFound) {
+		writeErrorResponse(w, http.StatusNotFound, "dead letter not found")
+		return
+	}
+
+	writeErrorResponse(w, http.StatusInternalServerError, message, err.Error())
+}
+
+// parseIntParam parses an optional integer query parameter
+func parseIntParam(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return strconv.Atoi(value)
+}
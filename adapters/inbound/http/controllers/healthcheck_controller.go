@@ -5,12 +5,17 @@ import (
 	"net/http"
 
 	"github.com/fabianoflorentino/mr-robot/internal/app/controller"
+	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
 )
 
-type HealthCheckController struct{}
+type HealthCheckController struct {
+	bulkheads interfaces.BulkheadServiceInterface
+}
 
-func NewHealthCheckController() *HealthCheckController {
-	return &HealthCheckController{}
+// NewHealthCheckController creates the health check controller. The health
+// output includes the processors' bulkheads when bulkheads is not nil.
+func NewHealthCheckController(bulkheads interfaces.BulkheadServiceInterface) *HealthCheckController {
+	return &HealthCheckController{bulkheads: bulkheads}
 }
 
 func (h *HealthCheckController) HealthCheck(w http.ResponseWriter, r *http.Request) {
@@ -29,6 +34,14 @@ func (h *HealthCheckController) HealthCheck(w http.ResponseWriter, r *http.Reque
 
 	response := map[string]any{"service": cfg.HostName, "status": http.StatusOK}
 
+	// Report saturation without failing the check: a full bulkhead already
+	// sends payments to the next processor, so the instance stays in rotation
+	if h.bulkheads != nil {
+		if statuses := h.bulkheads.Bulkheads(); len(statuses) > 0 {
+			response["bulkheads"] = statuses
+		}
+	}
+
 	w.Header().Set(cfg.ContentType, cfg.ApplicationJSON)
 	w.WriteHeader(http.StatusOK)
 
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/fabianoflorentino/mr-robot/internal/app/controller"
 )
@@ -48,3 +49,30 @@ func writeJSONResponse(w http.ResponseWriter, statusCode int, data any) {
 		}
 	}
 }
+
+// parseDateRange parses the optional from and to query parameters. Both must
+// be given together; on failure it returns the message to send to the client.
+func parseDateRange(r *http.Request) (*time.Time, *time.Time, string) {
+	queryFrom := r.URL.Query().Get("from")
+	queryTo := r.URL.Query().Get("to")
+
+	// If only one of the dates is provided, return an error
+	if queryFrom == "" || queryTo == "" {
+		if queryFrom != "" || queryTo != "" {
+			return nil, nil, "both from and to dates must be provided"
+		}
+		return nil, nil, ""
+	}
+
+	from, err := time.Parse(time.RFC3339, queryFrom)
+	if err != nil {
+		return nil, nil, "invalid from date format, use RFC3339 format, Ex: 2023-01-01T00:00:00Z"
+	}
+
+	to, err := time.Parse(time.RFC3339, queryTo)
+	if err != nil {
+		return nil, nil, "invalid to date format, use RFC3339 format, Ex: 2023-01-01T00:00:00Z"
+	}
+
+	return &from, &to, ""
+}
@@ -8,15 +8,14 @@ import (
 	"github.com/fabianoflorentino/mr-robot/core"
 	"github.com/fabianoflorentino/mr-robot/core/domain"
 	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
-	"github.com/fabianoflorentino/mr-robot/internal/app/queue"
 )
 
 type PaymentController struct {
-	q *queue.PaymentQueue
+	q interfaces.PaymentQueueInterface
 	s interfaces.PaymentServiceInterface
 }
 
-func NewPaymentController(q *queue.PaymentQueue, s interfaces.PaymentServiceInterface) *PaymentController {
+func NewPaymentController(q interfaces.PaymentQueueInterface, s interfaces.PaymentServiceInterface) *PaymentController {
 	return &PaymentController{q: q, s: s}
 }
 
@@ -33,6 +32,9 @@ func (u *PaymentController) PaymentProcess(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// The intake time is authoritative and is kept through retries and fallbacks
+	payment.RequestedAt = domain.NewRequestedAt()
+
 	u.enqueuePaymentWithTimeout(w, payment)
 }
 
@@ -42,32 +44,9 @@ func (u *PaymentController) PaymentsSummary(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var from, to *time.Time
-
-	queryFrom := r.URL.Query().Get("from")
-	queryTo := r.URL.Query().Get("to")
-
-	// Parse query parameters for date range
-	// If both are provided, parse them and set the from and to variables
-	if queryFrom != "" && queryTo != "" {
-		fromParsed, err := time.Parse(time.RFC3339, queryFrom)
-		if err != nil {
-			writeErrorResponse(w, http.StatusBadRequest, "invalid from date format, use RFC3339 format, Ex: 2023-01-01T00:00:00Z")
-			return
-		}
-
-		toParsed, err := time.Parse(time.RFC3339, queryTo)
-		if err != nil {
-			writeErrorResponse(w, http.StatusBadRequest, "invalid to date format, use RFC3339 format, Ex: 2023-01-01T00:00:00Z")
-			return
-		}
-
-		from = &fromParsed
-		to = &toParsed
-
-		// If only one of the dates is provided, return an error
-	} else if queryFrom != "" || queryTo != "" {
-		writeErrorResponse(w, http.StatusBadRequest, "both from and to dates must be provided")
+	from, to, errMsg := parseDateRange(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
 		return
 	}
 
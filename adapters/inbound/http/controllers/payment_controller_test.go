@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/adapters/outbound/gateway"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/services"
+	"github.com/fabianoflorentino/mr-robot/internal/app/circuitbreaker"
+	"github.com/fabianoflorentino/mr-robot/internal/app/payment"
+	"github.com/fabianoflorentino/mr-robot/internal/app/queue"
+	"github.com/fabianoflorentino/mr-robot/internal/fakeprocessor"
+	"github.com/google/uuid"
+)
+
+// recordingRepository is a PaymentRepository keeping the processed payments
+type recordingRepository struct {
+	payments map[uuid.UUID]domain.Payment
+	mutex    sync.Mutex
+}
+
+func (r *recordingRepository) Process(ctx context.Context, p *domain.Payment, processorName string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.payments[p.CorrelationID] = *p
+	return nil
+}
+
+func (r *recordingRepository) stored(correlationID uuid.UUID) (domain.Payment, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	p, ok := r.payments[correlationID]
+	return p, ok
+}
+
+func (r *recordingRepository) Summary(ctx context.Context, from, to *time.Time) (*domain.PaymentSummary, error) {
+	return &domain.PaymentSummary{}, nil
+}
+
+func (r *recordingRepository) Purge(ctx context.Context) error {
+	return nil
+}
+
+func (r *recordingRepository) MarkInDoubt(ctx context.Context, p *domain.Payment, processorName string) error {
+	return nil
+}
+
+func (r *recordingRepository) InDoubt(ctx context.Context, markedBefore time.Time, limit int) ([]domain.InDoubtPayment, error) {
+	return nil, nil
+}
+
+func (r *recordingRepository) FindInDoubt(ctx context.Context, correlationID uuid.UUID) (*domain.InDoubtPayment, error) {
+	return nil, nil
+}
+
+func (r *recordingRepository) ResolveInDoubt(ctx context.Context, correlationID uuid.UUID, processed bool) (bool, error) {
+	return false, nil
+}
+
+func (r *recordingRepository) CorrelationIDs(ctx context.Context, processorName string, from, to time.Time, limit int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+// newTestRegistration serves a fake processor and registers a gateway for it
+func newTestRegistration(t *testing.T, name string, priority int, processor *fakeprocessor.Processor) services.ProcessorRegistration {
+	t.Helper()
+
+	server := httptest.NewServer(processor)
+	t.Cleanup(server.Close)
+
+	gw := gateway.NewProcessorFactory().CreateProcessor(gateway.ProcessorType(name), gateway.ProcessorConfig{
+		URL:        server.URL + "/payments",
+		Timeout:    time.Second,
+		HTTPClient: server.Client(),
+	})
+
+	return services.ProcessorRegistration{Processor: gw, Priority: priority}
+}
+
+func TestPaymentController_RequestedAt(t *testing.T) {
+	defaultProcessor := fakeprocessor.New(fakeprocessor.Options{})
+	defaultProcessor.SetBehavior(fakeprocessor.Behavior{Failing: true})
+	fallbackProcessor := fakeprocessor.New(fakeprocessor.Options{})
+
+	repo := &recordingRepository{payments: make(map[uuid.UUID]domain.Payment)}
+	service := services.NewPaymentService(repo,
+		[]services.ProcessorRegistration{
+			newTestRegistration(t, "default", 0, defaultProcessor),
+			newTestRegistration(t, "fallback", 1, fallbackProcessor),
+		},
+		&payment.Config{},
+		&circuitbreaker.Config{Timeout: time.Second, ResetTimeout: time.Minute, MaxFailures: 5, RateLimit: 10},
+		services.PaymentServiceOptions{},
+	)
+
+	q := queue.NewPaymentQueue(&queue.Config{Workers: 1, BufferSize: 10, MaxSimultaneousWrites: 1}, service, nil, nil)
+	defer q.Shutdown()
+
+	controller := NewPaymentController(q, service)
+
+	// A requestedAt sent by the client is replaced at intake
+	correlationID := uuid.New()
+	body := `{"correlationId":"` + correlationID.String() + `","amount":19.9,"requestedAt":"2000-01-01T00:00:00Z"}`
+
+	before := time.Now().UTC().Truncate(time.Millisecond)
+	w := httptest.NewRecorder()
+	controller.PaymentProcess(w, httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body)))
+	after := time.Now().UTC()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got: %d", w.Code)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	stored, ok := repo.stored(correlationID)
+	for !ok && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		stored, ok = repo.stored(correlationID)
+	}
+	if !ok {
+		t.Fatal("Expected the payment to be stored")
+	}
+
+	requestedAt := stored.RequestedAt
+	if requestedAt.Before(before) || requestedAt.After(after) {
+		t.Errorf("Expected requestedAt stamped at intake, between %v and %v, got: %v", before, after, requestedAt)
+	}
+	if !requestedAt.Equal(requestedAt.Truncate(time.Millisecond)) {
+		t.Errorf("Expected requestedAt truncated to milliseconds, got: %v", requestedAt)
+	}
+
+	// The default processor failed, the fallback received the same value
+	sent := fallbackProcessor.Payments()
+	if defaultProcessor.Calls() == 0 || len(sent) != 1 {
+		t.Fatalf("Expected a failover to the fallback, got %d default calls and %d fallback payments", defaultProcessor.Calls(), len(sent))
+	}
+	if !sent[0].RequestedAt.Equal(requestedAt) {
+		t.Errorf("Expected the processor to receive %v, got: %v", requestedAt, sent[0].RequestedAt)
+	}
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
+)
+
+type QueueController struct {
+	q interfaces.PaymentQueueInterface
+}
+
+func NewQueueController(q interfaces.PaymentQueueInterface) *QueueController {
+	return &QueueController{q: q}
+}
+
+// Stats returns the depth of the payment queue, the jobs in flight and
+// waiting for a retry, the workers' utilization, the age of the oldest job
+// and the outcome of the attempts since startup
+func (c *QueueController) Stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	stats, err := c.q.Stats(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "failed to get queue stats", err.Error())
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, stats)
+}
+
+// Workers returns the size of the worker pool and the bounds it is scaled within
+func (c *QueueController) Workers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, c.q.Workers())
+}
+
+// ResizeWorkers sets the size of the worker pool or the bounds it is scaled
+// within; fields left out of the JSON body are unchanged
+func (c *QueueController) ResizeWorkers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var resize domain.WorkerPool
+	if err := json.NewDecoder(r.Body).Decode(&resize); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format", err.Error())
+		return
+	}
+
+	pool, err := c.q.ResizeWorkers(resize)
+	if errors.Is(err, core.ErrInvalidWorkerPool) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid worker pool size", err.Error())
+		return
+	}
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "failed to resize the worker pool", err.Error())
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, pool)
+}
@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+const (
+	adminSummaryPath  = "/admin/payments-summary"
+	adminTokenHeader  = "X-Rinha-Token"
+	paymentsPath      = "/payments"
+	adminResponseSize = 4096
+)
+
+// AdminSummary fetches the processor's own payment summary for the given window
+func (p *ProcessGateway) AdminSummary(ctx context.Context, from, to time.Time) (*domain.ProcessorSummary, error) {
+	query := url.Values{}
+	query.Set("from", from.UTC().Format(time.RFC3339Nano))
+	query.Set("to", to.UTC().Format(time.RFC3339Nano))
+
+	req, err := http.NewRequestWithContext(ctx, httpMethodGet, p.adminURL(adminSummaryPath)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error to create admin summary request: %w", err)
+	}
+
+	if p.adminToken != "" {
+		req.Header.Set(adminTokenHeader, p.adminToken)
+	}
+
+	resp, err := p.sendRequest(req)
+	if err != nil {
+		return nil, p.classifyTransportError(err)
+	}
+	defer resp.Body.Close()
+
+	if !p.isSuccessResponse(resp) {
+		return nil, p.classifyResponse(resp)
+	}
+
+	var summary domain.ProcessorSummary
+	if err := json.NewDecoder(io.LimitReader(resp.Body, adminResponseSize)).Decode(&summary); err != nil {
+		return nil, fmt.Errorf("error to decode admin summary from %s: %w", p.ProcessorName(), err)
+	}
+
+	return &summary, nil
+}
+
+// adminURL builds an admin endpoint URL from the processor payments URL
+func (p *ProcessGateway) adminURL(path string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(p.URL, "/"), paymentsPath)
+	return base + path
+}
@@ -1,7 +1,114 @@
 package gateway
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+)
+
+const errorBodySize = 1024
 
 var (
 	ErrHttpClientNotInitialized = errors.New("HTTP client not initialized")
+	ErrHealthCheckRateLimited   = errors.New("health check rate limited by processor")
+
+	// duplicatePatterns identify a "payment already exists" answer in the
+	// response body. The processors answer a known correlationId with
+	// 422 {"message":"payment already exists"}; 422 is also their answer to an
+	// invalid payment, so only the message tells the two apart.
+	duplicatePatterns = []string{"already exists", "already processed", "duplicate"}
 )
+
+// classifyResponse turns a non-2xx processor response into a typed processor error
+func (p *ProcessGateway) classifyResponse(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodySize))
+
+	processorErr := &core.ProcessorError{
+		Processor:  p.ProcessorName(),
+		StatusCode: resp.StatusCode,
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		processorErr.Kind = core.ErrProcessorRateLimited
+		processorErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+
+	case resp.StatusCode == http.StatusUnprocessableEntity && isDuplicateBody(body),
+		resp.StatusCode == http.StatusConflict:
+		processorErr.Kind = core.ErrPaymentDuplicate
+
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusGatewayTimeout:
+		processorErr.Kind = core.ErrProcessorTimeout
+
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		processorErr.Kind = core.ErrProcessorClientError
+
+	default:
+		processorErr.Kind = core.ErrProcessorServerError
+	}
+
+	if len(body) > 0 {
+		processorErr.Err = errors.New(strings.TrimSpace(string(body)))
+	}
+
+	return processorErr
+}
+
+// classifyTransportError turns an error returned by the HTTP client into a
+// typed processor error
+func (p *ProcessGateway) classifyTransportError(err error) error {
+	kind := core.ErrProcessorUnavailable
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		kind = core.ErrProcessorTimeout
+	}
+
+	// Cancellation comes from our side and says nothing about the processor
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return &core.ProcessorError{
+		Kind:      kind,
+		Processor: p.ProcessorName(),
+		Err:       err,
+	}
+}
+
+// isDuplicateBody reports whether a response body says the payment already exists
+func isDuplicateBody(body []byte) bool {
+	msg := strings.ToLower(string(body))
+	for _, pattern := range duplicatePatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
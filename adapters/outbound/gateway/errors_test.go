@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+)
+
+// newTestResponseGateway returns a gateway for a server answering every
+// payment with the given status, body and Retry-After header
+func newTestResponseGateway(t *testing.T, status int, body, retryAfter string) *ProcessGateway {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewProcessorFactory().CreateProcessor(DefaultProcessor, ProcessorConfig{
+		URL:        server.URL + "/payments",
+		Timeout:    time.Second,
+		HTTPClient: server.Client(),
+	})
+}
+
+func TestProcessGateway_ClassifyResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		retryAfter string
+		want       error
+		wantDelay  time.Duration
+	}{
+		{name: "409 duplicate", status: http.StatusConflict, want: core.ErrPaymentDuplicate},
+		{name: "422 duplicate", status: http.StatusUnprocessableEntity, body: `{"message":"payment already exists"}`, want: core.ErrPaymentDuplicate},
+		{name: "422 invalid payment", status: http.StatusUnprocessableEntity, body: `{"message":"invalid payment"}`, want: core.ErrProcessorClientError},
+		{name: "400", status: http.StatusBadRequest, want: core.ErrProcessorClientError},
+		{name: "429 with Retry-After", status: http.StatusTooManyRequests, retryAfter: "3", want: core.ErrProcessorRateLimited, wantDelay: 3 * time.Second},
+		{name: "429 without Retry-After", status: http.StatusTooManyRequests, want: core.ErrProcessorRateLimited},
+		{name: "408", status: http.StatusRequestTimeout, want: core.ErrProcessorTimeout},
+		{name: "500", status: http.StatusInternalServerError, want: core.ErrProcessorServerError},
+		{name: "503", status: http.StatusServiceUnavailable, want: core.ErrProcessorServerError},
+		{name: "504", status: http.StatusGatewayTimeout, want: core.ErrProcessorTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := newTestResponseGateway(t, tt.status, tt.body, tt.retryAfter)
+
+			ok, err := gw.Process(context.Background(), newTestPayment())
+			if ok || !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got: %v, %v", tt.want, ok, err)
+			}
+
+			var processorErr *core.ProcessorError
+			if !errors.As(err, &processorErr) || processorErr.StatusCode != tt.status || processorErr.Processor != "default" {
+				t.Errorf("Expected a processor error with HTTP %d, got: %#v", tt.status, err)
+			}
+			if retryAfter, _ := core.RetryAfterFrom(err); retryAfter != tt.wantDelay {
+				t.Errorf("Expected Retry-After %v, got: %v", tt.wantDelay, retryAfter)
+			}
+		})
+	}
+}
+
+func TestProcessGateway_ClassifyTransportError(t *testing.T) {
+	t.Run("Client timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		client := server.Client()
+		client.Timeout = 20 * time.Millisecond
+		gw := NewProcessorFactory().CreateProcessor(DefaultProcessor, ProcessorConfig{
+			URL:        server.URL + "/payments",
+			HTTPClient: client,
+		})
+
+		if _, err := gw.Process(context.Background(), newTestPayment()); !errors.Is(err, core.ErrProcessorTimeout) {
+			t.Errorf("Expected timeout error, got: %v", err)
+		}
+	})
+
+	t.Run("Connection refused", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		url := server.URL
+		server.Close()
+
+		gw := NewProcessorFactory().CreateProcessor(DefaultProcessor, ProcessorConfig{URL: url + "/payments"})
+
+		if _, err := gw.Process(context.Background(), newTestPayment()); !errors.Is(err, core.ErrProcessorUnavailable) {
+			t.Errorf("Expected unavailable error, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+const (
+	httpMethodGet      = "GET"
+	serviceHealthPath  = "/service-health"
+	healthResponseSize = 1024
+)
+
+// CheckHealth queries the processor's service-health endpoint. The processor
+// allows a single call every 5 seconds and answers 429 otherwise.
+func (p *ProcessGateway) CheckHealth(ctx context.Context) (*domain.ProcessorHealth, error) {
+	req, err := http.NewRequestWithContext(ctx, httpMethodGet, p.healthURL(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error to create health request: %w", err)
+	}
+
+	resp, err := p.sendRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check health of %s: %w", p.ProcessorName(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, ErrHealthCheckRateLimited
+	}
+
+	if !p.isSuccessResponse(resp) {
+		return nil, fmt.Errorf("health check failed: HTTP %d from %s", resp.StatusCode, p.ProcessorName())
+	}
+
+	var health domain.ProcessorHealth
+	if err := json.NewDecoder(io.LimitReader(resp.Body, healthResponseSize)).Decode(&health); err != nil {
+		return nil, fmt.Errorf("error to decode health response from %s: %w", p.ProcessorName(), err)
+	}
+
+	health.CheckedAt = time.Now()
+	return &health, nil
+}
+
+// healthURL builds the service-health URL from the processor payments URL
+func (p *ProcessGateway) healthURL() string {
+	return strings.TrimSuffix(p.URL, "/") + serviceHealthPath
+}
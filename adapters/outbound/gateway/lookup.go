@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// LookupPayment asks the processor whether it recorded the payment with the
+// given correlation ID. It returns false when the processor answers 404.
+func (p *ProcessGateway) LookupPayment(ctx context.Context, correlationID uuid.UUID) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, httpMethodGet, p.paymentURL(correlationID), nil)
+	if err != nil {
+		return false, fmt.Errorf("error to create lookup request: %w", err)
+	}
+
+	resp, err := p.sendRequest(req)
+	if err != nil {
+		return false, p.classifyTransportError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if !p.isSuccessResponse(resp) {
+		return false, p.classifyResponse(resp)
+	}
+
+	return true, nil
+}
+
+// paymentURL builds the URL of a single payment from the processor payments URL
+func (p *ProcessGateway) paymentURL(correlationID uuid.UUID) string {
+	return strings.TrimSuffix(p.URL, "/") + "/" + correlationID.String()
+}
@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -21,6 +22,7 @@ type ProcessGateway struct {
 	URL        string
 	Name       string
 	timeout    time.Duration
+	adminToken string
 	httpClient *http.Client
 }
 
@@ -42,29 +44,29 @@ func (p *ProcessGateway) ProcessorName() string {
 
 // Process requests the payment processor to process the payment. It returns
 // a boolean indicating if the payment was processed successfully and an error
-// if any occurred.
-func (p *ProcessGateway) Process(payment *domain.Payment) (bool, error) {
+// if any occurred. Processor failures are returned as *core.ProcessorError.
+// Cancelling ctx aborts the outbound request.
+func (p *ProcessGateway) Process(ctx context.Context, payment *domain.Payment) (bool, error) {
 	if err := p.validatePayment(payment); err != nil {
 		return false, err
 	}
 
-	req, err := p.createRequest(payment)
+	req, err := p.createRequest(ctx, payment)
 	if err != nil {
 		return false, err
 	}
 
 	resp, err := p.sendRequest(req)
 	if err != nil {
-		return false, fmt.Errorf("failed to send request to %s: %w", p.ProcessorName(), err)
+		return false, p.classifyTransportError(err)
 	}
 	defer resp.Body.Close()
 
-	success := p.isSuccessResponse(resp)
-	if !success {
-		return false, fmt.Errorf("payment processing failed: HTTP %d from %s", resp.StatusCode, p.ProcessorName())
+	if !p.isSuccessResponse(resp) {
+		return false, p.classifyResponse(resp)
 	}
 
-	return success, nil
+	return true, nil
 }
 
 // validatePayment validates the payment object
@@ -72,19 +74,26 @@ func (p *ProcessGateway) validatePayment(payment *domain.Payment) error {
 	if payment == nil {
 		return fmt.Errorf("payment cannot be nil")
 	}
+	if payment.RequestedAt.IsZero() {
+		return fmt.Errorf("payment requestedAt cannot be empty")
+	}
 	return nil
 }
 
 // createRequest creates an HTTP request for the payment
-func (p *ProcessGateway) createRequest(payment *domain.Payment) (*http.Request, error) {
-	processorPayment := map[string]any{"correlationId": payment.CorrelationID, "amount": payment.Amount}
+func (p *ProcessGateway) createRequest(ctx context.Context, payment *domain.Payment) (*http.Request, error) {
+	processorPayment := map[string]any{
+		"correlationId": payment.CorrelationID,
+		"amount":        payment.Amount,
+		"requestedAt":   payment.RequestedAt.UTC().Format(time.RFC3339Nano),
+	}
 
 	payload, err := json.Marshal(processorPayment)
 	if err != nil {
 		return nil, fmt.Errorf("error to serialize payment: %w", err)
 	}
 
-	req, err := http.NewRequest(httpMethodPost, p.URL, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, httpMethodPost, p.URL, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, fmt.Errorf("error to create request: %w", err)
 	}
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"net/http"
 	"time"
 )
 
@@ -12,10 +13,13 @@ const (
 	FallbackProcessor ProcessorType = "fallback"
 )
 
-// ProcessorConfig holds configuration for a processor
+// ProcessorConfig holds configuration for a processor. When HTTPClient is
+// nil, the processor lazily creates its own client.
 type ProcessorConfig struct {
-	URL     string
-	Timeout time.Duration
+	URL        string
+	Timeout    time.Duration
+	AdminToken string
+	HTTPClient *http.Client
 }
 
 // ProcessorFactory creates processors based on type and configuration
@@ -33,8 +37,10 @@ func (f *ProcessorFactory) CreateProcessor(processorType ProcessorType, config P
 	}
 
 	return &ProcessGateway{
-		URL:     config.URL,
-		Name:    string(processorType),
-		timeout: config.Timeout,
+		URL:        config.URL,
+		Name:       string(processorType),
+		timeout:    config.Timeout,
+		adminToken: config.AdminToken,
+		httpClient: config.HTTPClient,
 	}
 }
@@ -0,0 +1,160 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/fakeprocessor"
+	"github.com/google/uuid"
+)
+
+func newTestGateway(t *testing.T, processor *fakeprocessor.Processor) *ProcessGateway {
+	t.Helper()
+
+	server := httptest.NewServer(processor)
+	t.Cleanup(server.Close)
+
+	return NewProcessorFactory().CreateProcessor(DefaultProcessor, ProcessorConfig{
+		URL:        server.URL + "/payments",
+		Timeout:    time.Second,
+		AdminToken: "123",
+		HTTPClient: server.Client(),
+	})
+}
+
+func newTestPayment() *domain.Payment {
+	return &domain.Payment{CorrelationID: uuid.New(), Amount: 19.9, RequestedAt: domain.NewRequestedAt()}
+}
+
+func TestProcessGateway_Process(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		processor := fakeprocessor.New(fakeprocessor.Options{})
+		gw := newTestGateway(t, processor)
+
+		payment := newTestPayment()
+		if ok, err := gw.Process(context.Background(), payment); !ok || err != nil {
+			t.Fatalf("Expected success, got: %v, %v", ok, err)
+		}
+		if !processor.Has(payment.CorrelationID) {
+			t.Error("Expected the processor to record the payment")
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		gw := newTestGateway(t, fakeprocessor.New(fakeprocessor.Options{}))
+
+		payment := newTestPayment()
+		gw.Process(context.Background(), payment)
+		if _, err := gw.Process(context.Background(), payment); !errors.Is(err, core.ErrPaymentDuplicate) {
+			t.Errorf("Expected duplicate error, got: %v", err)
+		}
+	})
+
+	t.Run("Rate limited with Retry-After", func(t *testing.T) {
+		processor := fakeprocessor.New(fakeprocessor.Options{})
+		processor.SetBehavior(fakeprocessor.Behavior{RateLimitEvery: 1, RetryAfter: 2 * time.Second})
+		gw := newTestGateway(t, processor)
+
+		_, err := gw.Process(context.Background(), newTestPayment())
+		if !errors.Is(err, core.ErrProcessorRateLimited) {
+			t.Fatalf("Expected rate limited error, got: %v", err)
+		}
+		if retryAfter, ok := core.RetryAfterFrom(err); !ok || retryAfter != 2*time.Second {
+			t.Errorf("Expected Retry-After 2s, got: %v", retryAfter)
+		}
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		processor := fakeprocessor.New(fakeprocessor.Options{})
+		processor.SetBehavior(fakeprocessor.Behavior{Failing: true})
+		gw := newTestGateway(t, processor)
+
+		if _, err := gw.Process(context.Background(), newTestPayment()); !errors.Is(err, core.ErrProcessorServerError) {
+			t.Errorf("Expected server error, got: %v", err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		processor := fakeprocessor.New(fakeprocessor.Options{})
+		processor.SetBehavior(fakeprocessor.Behavior{Latency: 200 * time.Millisecond})
+		gw := newTestGateway(t, processor)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if _, err := gw.Process(ctx, newTestPayment()); !errors.Is(err, core.ErrProcessorTimeout) {
+			t.Errorf("Expected timeout error, got: %v", err)
+		}
+	})
+}
+
+func TestProcessGateway_Cancel(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+
+	// The processor does not answer until the test ends
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	gw := NewProcessorFactory().CreateProcessor(DefaultProcessor, ProcessorConfig{
+		URL:        server.URL + "/payments",
+		Timeout:    time.Minute,
+		HTTPClient: server.Client(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, err := gw.Process(ctx, newTestPayment())
+		result <- err
+	}()
+
+	<-received
+	cancel()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected cancelled error, got: %v", err)
+		}
+		var processorErr *core.ProcessorError
+		if errors.As(err, &processorErr) {
+			t.Errorf("Expected the cancellation not to be blamed on the processor, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the cancellation to abort the request")
+	}
+}
+
+func TestProcessGateway_LookupAndAdmin(t *testing.T) {
+	processor := fakeprocessor.New(fakeprocessor.Options{Fee: 0.05, AdminToken: "123"})
+	gw := newTestGateway(t, processor)
+
+	payment := newTestPayment()
+	gw.Process(context.Background(), payment)
+
+	if found, err := gw.LookupPayment(context.Background(), payment.CorrelationID); !found || err != nil {
+		t.Errorf("Expected the payment to be found, got: %v, %v", found, err)
+	}
+	if found, err := gw.LookupPayment(context.Background(), uuid.New()); found || err != nil {
+		t.Errorf("Expected an unknown payment not to be found, got: %v, %v", found, err)
+	}
+
+	summary, err := gw.AdminSummary(context.Background(), payment.RequestedAt.Add(-time.Minute), payment.RequestedAt.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if summary.TotalRequests != 1 || summary.TotalAmount != payment.Amount {
+		t.Errorf("Expected 1 request of %.2f, got: %+v", payment.Amount, summary)
+	}
+}
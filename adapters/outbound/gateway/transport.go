@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig holds the connection pooling settings of the shared transport
+type TransportConfig struct {
+	Timeout               time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	KeepAlive             time.Duration
+	DialTimeout           time.Duration
+	ResponseHeaderTimeout time.Duration
+	EnableHTTP2           bool
+}
+
+// NewTransport creates a pooled transport meant to be shared by all processor
+// gateways so connections to the processors are reused across requests
+func NewTransport(cfg TransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		// HTTP/2 is negotiated through TLS ALPN, so it only applies to https processors
+		ForceAttemptHTTP2: cfg.EnableHTTP2,
+	}
+}
+
+// NewHTTPClient creates an HTTP client backed by a new pooled transport
+func NewHTTPClient(cfg TransportConfig) *http.Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &http.Client{
+		Transport: NewTransport(cfg),
+		Timeout:   timeout,
+	}
+}
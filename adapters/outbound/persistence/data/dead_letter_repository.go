@@ -0,0 +1,130 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/google/uuid"
+)
+
+// DataDeadLetterRepository stores dead letters in the payment_dead_letters table
+type DataDeadLetterRepository struct {
+	DB *sql.DB
+}
+
+func NewDataDeadLetterRepository(db *sql.DB) repository.DeadLetterRepository {
+	return &DataDeadLetterRepository{DB: db}
+}
+
+// Add stores a dead letter. Adding the same job twice keeps the first one.
+func (d *DataDeadLetterRepository) Add(ctx context.Context, letter domain.DeadLetter) error {
+	attempts, err := json.Marshal(letter.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter attempts: %w", err)
+	}
+
+	query := `INSERT INTO payment_dead_letters (id, correlation_id, amount, requested_at, attempts, enqueued_at, failed_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)
+	          ON CONFLICT (id) DO NOTHING`
+
+	if _, err := d.DB.ExecContext(ctx, query, letter.ID, letter.Payment.CorrelationID, letter.Payment.Amount,
+		letter.Payment.RequestedAt, string(attempts), letter.EnqueuedAt, letter.FailedAt); err != nil {
+		return fmt.Errorf("failed to add dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// List returns up to limit dead letters after skipping offset, oldest failure first
+func (d *DataDeadLetterRepository) List(ctx context.Context, offset, limit int) ([]domain.DeadLetter, error) {
+	query := `SELECT id, correlation_id, amount, requested_at, attempts, enqueued_at, failed_at
+	          FROM payment_dead_letters ORDER BY failed_at, id OFFSET $1 LIMIT $2`
+
+	rows, err := d.DB.QueryContext(ctx, query, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []domain.DeadLetter
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, *letter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead letter rows: %w", err)
+	}
+
+	return letters, nil
+}
+
+// Get returns a dead letter
+func (d *DataDeadLetterRepository) Get(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error) {
+	query := `SELECT id, correlation_id, amount, requested_at, attempts, enqueued_at, failed_at
+	          FROM payment_dead_letters WHERE id = $1`
+
+	letter, err := scanDeadLetter(d.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrDeadLetterNotFound
+	}
+
+	return letter, err
+}
+
+// Delete removes a dead letter
+func (d *DataDeadLetterRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := d.DB.ExecContext(ctx, `DELETE FROM payment_dead_letters WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+
+	if affected == 0 {
+		return core.ErrDeadLetterNotFound
+	}
+
+	return nil
+}
+
+// Purge removes every dead letter and returns how many were removed
+func (d *DataDeadLetterRepository) Purge(ctx context.Context) (int64, error) {
+	result, err := d.DB.ExecContext(ctx, `DELETE FROM payment_dead_letters`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead letters: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// scanDeadLetter reads a dead letter from a row
+func scanDeadLetter(row interface{ Scan(...any) error }) (*domain.DeadLetter, error) {
+	var letter domain.DeadLetter
+	var attempts []byte
+
+	if err := row.Scan(&letter.ID, &letter.Payment.CorrelationID, &letter.Payment.Amount, &letter.Payment.RequestedAt,
+		&attempts, &letter.EnqueuedAt, &letter.FailedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan dead letter row: %w", err)
+	}
+
+	if err := json.Unmarshal(attempts, &letter.Attempts); err != nil {
+		return nil, fmt.Errorf("failed to decode dead letter attempts: %w", err)
+	}
+
+	letter.Payment.RequestedAt = letter.Payment.RequestedAt.UTC()
+
+	return &letter, nil
+}
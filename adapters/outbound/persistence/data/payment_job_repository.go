@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+)
+
+// DataPaymentJobRepository stores the durable payment queue in the
+// payment_jobs table. Workers claim jobs with FOR UPDATE SKIP LOCKED, so
+// concurrent workers and instances never claim the same job.
+type DataPaymentJobRepository struct {
+	DB *sql.DB
+}
+
+func NewDataPaymentJobRepository(db *sql.DB) repository.PaymentJobRepository {
+	return &DataPaymentJobRepository{DB: db}
+}
+
+// Enqueue stores a job for the payment. A payment already waiting in the
+// queue is not queued twice.
+func (d *DataPaymentJobRepository) Enqueue(ctx context.Context, payment *domain.Payment) error {
+	query := `INSERT INTO payment_jobs (correlation_id, amount, requested_at)
+	          VALUES ($1, $2, $3)
+	          ON CONFLICT (correlation_id) DO NOTHING`
+
+	if _, err := d.DB.ExecContext(ctx, query, payment.CorrelationID, payment.Amount, payment.RequestedAt); err != nil {
+		return fmt.Errorf("failed to enqueue payment job: %w", err)
+	}
+
+	return nil
+}
+
+// Claim leases up to limit visible jobs to owner, oldest first, and hides
+// them from the other workers for the visibility timeout
+func (d *DataPaymentJobRepository) Claim(ctx context.Context, owner string, limit int, visibilityTimeout time.Duration) ([]domain.PaymentJob, error) {
+	query := `UPDATE payment_jobs SET
+	              attempts = attempts + 1,
+	              lease_id = gen_random_uuid(),
+	              leased_by = $1,
+	              visible_at = NOW() + $3 * INTERVAL '1 millisecond'
+	          WHERE id IN (
+	              SELECT id FROM payment_jobs
+	              WHERE visible_at <= NOW()
+	              ORDER BY visible_at
+	              LIMIT $2
+	              FOR UPDATE SKIP LOCKED
+	          )
+	          RETURNING id, correlation_id, amount, requested_at, attempts, attempt_errors, retry_delay_ms, lease_id, created_at`
+
+	rows, err := d.DB.QueryContext(ctx, query, owner, limit, visibilityTimeout.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim payment jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []domain.PaymentJob
+	for rows.Next() {
+		var job domain.PaymentJob
+		var attemptErrors []byte
+		var retryDelayMs int64
+
+		if err := rows.Scan(&job.ID, &job.Payment.CorrelationID, &job.Payment.Amount, &job.Payment.RequestedAt,
+			&job.Attempts, &attemptErrors, &retryDelayMs, &job.LeaseID, &job.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan payment job row: %w", err)
+		}
+
+		if err := json.Unmarshal(attemptErrors, &job.Errors); err != nil {
+			return nil, fmt.Errorf("failed to decode payment job errors: %w", err)
+		}
+
+		job.Payment.RequestedAt = job.Payment.RequestedAt.UTC()
+		job.RetryDelay = time.Duration(retryDelayMs) * time.Millisecond
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment job rows: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Complete removes a job whose lease is still held
+func (d *DataPaymentJobRepository) Complete(ctx context.Context, job domain.PaymentJob) error {
+	result, err := d.DB.ExecContext(ctx, `DELETE FROM payment_jobs WHERE id = $1 AND lease_id = $2`, job.ID, job.LeaseID)
+	if err != nil {
+		return fmt.Errorf("failed to complete payment job: %w", err)
+	}
+
+	return checkLease(result)
+}
+
+// Retry records the failed attempt, gives up the lease and makes the job
+// visible again after delay
+func (d *DataPaymentJobRepository) Retry(ctx context.Context, job domain.PaymentJob, delay time.Duration, failure domain.JobAttempt) error {
+	attempt, err := json.Marshal([]domain.JobAttempt{failure})
+	if err != nil {
+		return fmt.Errorf("failed to encode payment job error: %w", err)
+	}
+
+	query := `UPDATE payment_jobs SET
+	              lease_id = NULL,
+	              leased_by = NULL,
+	              visible_at = NOW() + $3 * INTERVAL '1 millisecond',
+	              retry_delay_ms = $3,
+	              attempt_errors = attempt_errors || $4::jsonb
+	          WHERE id = $1 AND lease_id = $2`
+
+	result, err := d.DB.ExecContext(ctx, query, job.ID, job.LeaseID, delay.Milliseconds(), string(attempt))
+	if err != nil {
+		return fmt.Errorf("failed to retry payment job: %w", err)
+	}
+
+	return checkLease(result)
+}
+
+// Release gives up the lease without counting the attempt, making the job
+// visible right away
+func (d *DataPaymentJobRepository) Release(ctx context.Context, job domain.PaymentJob) error {
+	query := `UPDATE payment_jobs SET
+	              attempts = attempts - 1,
+	              lease_id = NULL,
+	              leased_by = NULL,
+	              visible_at = NOW()
+	          WHERE id = $1 AND lease_id = $2`
+
+	result, err := d.DB.ExecContext(ctx, query, job.ID, job.LeaseID)
+	if err != nil {
+		return fmt.Errorf("failed to release payment job: %w", err)
+	}
+
+	return checkLease(result)
+}
+
+// Stats counts the jobs ready to be claimed and the ones waiting for a retry,
+// across every instance sharing the table. Jobs under a lease are left out;
+// each instance counts its own.
+func (d *DataPaymentJobRepository) Stats(ctx context.Context) (*domain.PaymentJobStats, error) {
+	query := `SELECT
+	              COUNT(*) FILTER (WHERE visible_at <= NOW()),
+	              COUNT(*) FILTER (WHERE visible_at > NOW() AND lease_id IS NULL),
+	              MIN(created_at)
+	          FROM payment_jobs`
+
+	var stats domain.PaymentJobStats
+	var oldest sql.NullTime
+
+	if err := d.DB.QueryRowContext(ctx, query).Scan(&stats.Ready, &stats.Delayed, &oldest); err != nil {
+		return nil, fmt.Errorf("failed to get payment job stats: %w", err)
+	}
+
+	if oldest.Valid {
+		stats.OldestCreatedAt = oldest.Time
+	}
+
+	return &stats, nil
+}
+
+// checkLease reports a lease that expired and may have been claimed again
+func checkLease(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check payment job lease: %w", err)
+	}
+
+	if affected == 0 {
+		return core.ErrJobLeaseExpired
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ type Payment struct {
 	CorrelationID uuid.UUID `json:"correlation_id" db:"correlation_id"`
 	Amount        float64   `json:"amount" db:"amount"`
 	Processor     string    `json:"processor" db:"processor"`
+	Status        string    `json:"status" db:"status"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -29,15 +29,11 @@ func NewDataPaymentRepository(db *sql.DB) repository.PaymentRepository {
 	return &DataPaymentRepository{DB: db}
 }
 
+// Process stores a processed payment. The row's created_at is the payment's
+// requestedAt, the same timestamp sent to the processor. A payment is stored
+// once per processor, so one accepted by two processors counts for both.
 func (d *DataPaymentRepository) Process(ctx context.Context, payment *domain.Payment, processorName string) error {
-	pymt := Payment{
-		ID:            uuid.New(),
-		CorrelationID: payment.CorrelationID,
-		Amount:        payment.Amount,
-		Processor:     processorName,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-	}
+	pymt := newPaymentModel(payment, processorName, domain.PaymentStatusProcessed)
 
 	if err := d.retriesTransactions(ctx, &pymt); err != nil {
 		return fmt.Errorf("failed to process payment: %w", err)
@@ -46,20 +42,102 @@ func (d *DataPaymentRepository) Process(ctx context.Context, payment *domain.Pay
 	return nil
 }
 
-func (d *DataPaymentRepository) Summary(ctx context.Context, from, to *time.Time) (*domain.PaymentSummary, error) {
-	var summary []struct {
-		Processor     string  `db:"processor"`
-		TotalAmount   float64 `db:"total_amount"`
-		TotalRequests int64   `db:"total_requests"`
+// MarkInDoubt stores a payment whose outcome at the processor is unknown. It is
+// left out of the summary until ResolveInDoubt settles it.
+func (d *DataPaymentRepository) MarkInDoubt(ctx context.Context, payment *domain.Payment, processorName string) error {
+	pymt := newPaymentModel(payment, processorName, domain.PaymentStatusInDoubt)
+
+	if err := d.retriesTransactions(ctx, &pymt); err != nil {
+		return fmt.Errorf("failed to mark payment in doubt: %w", err)
+	}
+
+	return nil
+}
+
+// InDoubt returns up to limit payments marked in doubt before markedBefore,
+// oldest first
+func (d *DataPaymentRepository) InDoubt(ctx context.Context, markedBefore time.Time, limit int) ([]domain.InDoubtPayment, error) {
+	query := `SELECT correlation_id, amount, processor, created_at
+	          FROM payments WHERE status = $1 AND updated_at <= $2 ORDER BY created_at LIMIT $3`
+
+	rows, err := d.DB.QueryContext(ctx, query, domain.PaymentStatusInDoubt, markedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get in-doubt payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []domain.InDoubtPayment
+	for rows.Next() {
+		var p domain.InDoubtPayment
+
+		if err := rows.Scan(&p.Payment.CorrelationID, &p.Payment.Amount, &p.Processor, &p.Payment.RequestedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan in-doubt payment row: %w", err)
+		}
+
+		payments = append(payments, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating in-doubt payment rows: %w", err)
 	}
-	s := &domain.PaymentSummary{}
 
+	return payments, nil
+}
+
+// FindInDoubt returns the payment if it is in doubt, or nil
+func (d *DataPaymentRepository) FindInDoubt(ctx context.Context, correlationID uuid.UUID) (*domain.InDoubtPayment, error) {
+	query := `SELECT correlation_id, amount, processor, created_at
+	          FROM payments WHERE correlation_id = $1 AND status = $2 LIMIT 1`
+
+	var p domain.InDoubtPayment
+	err := d.DB.QueryRowContext(ctx, query, correlationID, domain.PaymentStatusInDoubt).
+		Scan(&p.Payment.CorrelationID, &p.Payment.Amount, &p.Processor, &p.Payment.RequestedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get in-doubt payment: %w", err)
+	}
+
+	return &p, nil
+}
+
+// ResolveInDoubt settles an in-doubt payment. A processed payment is counted
+// from now on; an unprocessed one is removed so it can be submitted again.
+// Only the row still in doubt is changed, so when several callers settle the
+// same payment it reports true to one of them.
+func (d *DataPaymentRepository) ResolveInDoubt(ctx context.Context, correlationID uuid.UUID, processed bool) (bool, error) {
+	query := `DELETE FROM payments WHERE correlation_id = $1 AND status = $2`
+	args := []any{correlationID, domain.PaymentStatusInDoubt}
+
+	if processed {
+		query = `UPDATE payments SET status = $3, updated_at = $4 WHERE correlation_id = $1 AND status = $2`
+		args = append(args, domain.PaymentStatusProcessed, time.Now())
+	}
+
+	result, err := d.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve in-doubt payment: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve in-doubt payment: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (d *DataPaymentRepository) Summary(ctx context.Context, from, to *time.Time) (*domain.PaymentSummary, error) {
+	s := domain.PaymentSummary{}
+
+	// In-doubt payments are not counted until they are confirmed by the processor
 	query := `SELECT processor, SUM(amount) as total_amount, COUNT(*) as total_requests
-	          FROM payments`
+	          FROM payments WHERE status = $1`
 
-	var args []interface{}
+	args := []interface{}{domain.PaymentStatusProcessed}
 	if from != nil && to != nil {
-		query += ` WHERE created_at BETWEEN $1 AND $2`
+		query += ` AND created_at BETWEEN $2 AND $3`
 		args = append(args, *from, *to)
 	}
 
@@ -82,32 +160,46 @@ func (d *DataPaymentRepository) Summary(ctx context.Context, from, to *time.Time
 			return nil, fmt.Errorf("failed to scan payment summary row: %w", err)
 		}
 
-		summary = append(summary, r)
+		s[r.Processor] = domain.ProcessorSummary{
+			TotalRequests: r.TotalRequests,
+			TotalAmount:   r.TotalAmount,
+		}
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating payment summary rows: %w", err)
 	}
 
-	for _, r := range summary {
-		switch r.Processor {
-		case "default":
-			s.Default = domain.ProcessorSummary{
-				TotalRequests: r.TotalRequests,
-				TotalAmount:   r.TotalAmount,
-			}
-
-		case "fallback":
-			s.Fallback = domain.ProcessorSummary{
-				TotalRequests: r.TotalRequests,
-				TotalAmount:   r.TotalAmount,
-			}
-		default:
-			return nil, fmt.Errorf("unknown processor: %s", r.Processor)
+	return &s, nil
+}
+
+// CorrelationIDs returns up to limit correlation IDs of the payments processed
+// by a processor within the given window
+func (d *DataPaymentRepository) CorrelationIDs(ctx context.Context, processorName string, from, to time.Time, limit int) ([]uuid.UUID, error) {
+	query := `SELECT correlation_id FROM payments
+	          WHERE processor = $1 AND status = $2 AND created_at BETWEEN $3 AND $4
+	          ORDER BY created_at LIMIT $5`
+
+	rows, err := d.DB.QueryContext(ctx, query, processorName, domain.PaymentStatusProcessed, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get correlation ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan correlation id row: %w", err)
 		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating correlation id rows: %w", err)
 	}
 
-	return s, nil
+	return ids, nil
 }
 
 func (d *DataPaymentRepository) Purge(ctx context.Context) error {
@@ -116,6 +208,19 @@ func (d *DataPaymentRepository) Purge(ctx context.Context) error {
 	return err
 }
 
+// newPaymentModel maps a domain payment to its database model
+func newPaymentModel(payment *domain.Payment, processorName, status string) Payment {
+	return Payment{
+		ID:            uuid.New(),
+		CorrelationID: payment.CorrelationID,
+		Amount:        payment.Amount,
+		Processor:     processorName,
+		Status:        status,
+		CreatedAt:     payment.RequestedAt,
+		UpdatedAt:     time.Now(),
+	}
+}
+
 // retriesTransactions try to process the payment with retries in case of deadlocks
 // It uses exponential backoff for retries
 func (d *DataPaymentRepository) retriesTransactions(ctx context.Context, pymt *Payment) error {
@@ -140,7 +245,7 @@ func (d *DataPaymentRepository) retriesTransactions(ctx context.Context, pymt *P
 }
 
 // processWithTransaction processes the payment within a transaction
-// It checks for idempotency by looking for existing records with the same CorrelationID
+// It checks for idempotency by looking for existing records with the same CorrelationID and processor
 func (d *DataPaymentRepository) processWithTransaction(ctx context.Context, pymt *Payment) error {
 	tx, err := d.DB.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelReadCommitted,
@@ -158,8 +263,8 @@ func (d *DataPaymentRepository) processWithTransaction(ctx context.Context, pymt
 
 	// Verifica se já existe (idempotência)
 	var existingID uuid.UUID
-	checkQuery := `SELECT id FROM payments WHERE correlation_id = $1 LIMIT 1`
-	err = tx.QueryRowContext(ctx, checkQuery, pymt.CorrelationID).Scan(&existingID)
+	checkQuery := `SELECT id FROM payments WHERE correlation_id = $1 AND processor = $2 LIMIT 1`
+	err = tx.QueryRowContext(ctx, checkQuery, pymt.CorrelationID, pymt.Processor).Scan(&existingID)
 
 	if err == nil {
 		// Já existe, não faz nada (idempotente)
@@ -174,11 +279,11 @@ func (d *DataPaymentRepository) processWithTransaction(ctx context.Context, pymt
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	insertQuery := `INSERT INTO payments (id, correlation_id, amount, processor, created_at, updated_at) 
-	                VALUES ($1, $2, $3, $4, $5, $6)`
+	insertQuery := `INSERT INTO payments (id, correlation_id, amount, processor, status, created_at, updated_at) 
+	                VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
 	_, err = tx.ExecContext(ctxWithTimeout, insertQuery,
-		pymt.ID, pymt.CorrelationID, pymt.Amount, pymt.Processor, pymt.CreatedAt, pymt.UpdatedAt)
+		pymt.ID, pymt.CorrelationID, pymt.Amount, pymt.Processor, pymt.Status, pymt.CreatedAt, pymt.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert payment: %w", err)
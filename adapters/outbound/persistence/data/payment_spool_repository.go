@@ -0,0 +1,122 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+)
+
+// DataPaymentSpoolRepository stores the in-memory queue's unfinished jobs in
+// the payment_queue_spool table
+type DataPaymentSpoolRepository struct {
+	DB *sql.DB
+}
+
+func NewDataPaymentSpoolRepository(db *sql.DB) repository.PaymentSpoolRepository {
+	return &DataPaymentSpoolRepository{DB: db}
+}
+
+// Spool stores the jobs in one transaction. A job already spooled is kept once.
+func (d *DataPaymentSpoolRepository) Spool(ctx context.Context, jobs []domain.PaymentJob) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO payment_queue_spool (id, correlation_id, amount, requested_at, attempts, attempt_errors, retry_delay_ms, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	          ON CONFLICT (id) DO NOTHING`
+
+	for _, job := range jobs {
+		attemptErrors, err := json.Marshal(job.Errors)
+		if err != nil {
+			return fmt.Errorf("failed to encode payment job errors: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, job.ID, job.Payment.CorrelationID, job.Payment.Amount, job.Payment.RequestedAt,
+			job.Attempts, string(attemptErrors), job.RetryDelay.Milliseconds(), job.CreatedAt); err != nil {
+			return fmt.Errorf("failed to spool payment job: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Restore removes the spooled jobs and hands them to restore, oldest first,
+// in one transaction. It commits once every row is decoded and restore
+// returned nil; otherwise the jobs stay spooled. Another instance restoring
+// at the same time waits for the commit and finds no job.
+func (d *DataPaymentSpoolRepository) Restore(ctx context.Context, restore func(jobs []domain.PaymentJob) error) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	jobs, err := d.deleteSpooled(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	if err := restore(jobs); err != nil {
+		return fmt.Errorf("failed to restore spooled payment jobs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to remove restored payment jobs from the spool: %w", err)
+	}
+
+	return nil
+}
+
+// deleteSpooled deletes and decodes every spooled job within tx, oldest first
+func (d *DataPaymentSpoolRepository) deleteSpooled(ctx context.Context, tx *sql.Tx) ([]domain.PaymentJob, error) {
+	query := `DELETE FROM payment_queue_spool
+	          RETURNING id, correlation_id, amount, requested_at, attempts, attempt_errors, retry_delay_ms, created_at`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore spooled payment jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []domain.PaymentJob
+	for rows.Next() {
+		var job domain.PaymentJob
+		var attemptErrors []byte
+		var retryDelayMs int64
+
+		if err := rows.Scan(&job.ID, &job.Payment.CorrelationID, &job.Payment.Amount, &job.Payment.RequestedAt,
+			&job.Attempts, &attemptErrors, &retryDelayMs, &job.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan spooled payment job row: %w", err)
+		}
+
+		if err := json.Unmarshal(attemptErrors, &job.Errors); err != nil {
+			return nil, fmt.Errorf("failed to decode payment job errors: %w", err)
+		}
+
+		job.Payment.RequestedAt = job.Payment.RequestedAt.UTC()
+		job.RetryDelay = time.Duration(retryDelayMs) * time.Millisecond
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating spooled payment job rows: %w", err)
+	}
+
+	// DELETE ... RETURNING has no order
+	slices.SortFunc(jobs, func(a, b domain.PaymentJob) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return jobs, nil
+}
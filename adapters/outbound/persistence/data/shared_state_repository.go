@@ -0,0 +1,204 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// DataSharedStateRepository shares processor state through the
+// processor_shared_state table and notifies the other instances with
+// LISTEN/NOTIFY on channel
+type DataSharedStateRepository struct {
+	DB      *sql.DB
+	channel string
+}
+
+func NewDataSharedStateRepository(db *sql.DB, channel string) repository.SharedStateRepository {
+	return &DataSharedStateRepository{DB: db, channel: channel}
+}
+
+// Publish stores the update and notifies the other instances in one transaction
+func (d *DataSharedStateRepository) Publish(ctx context.Context, update domain.SharedStateUpdate) error {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to encode shared state update: %w", err)
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := d.store(ctx, tx, update); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, d.channel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify shared state update: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// store upserts the processor row with the part of the state the update carries
+func (d *DataSharedStateRepository) store(ctx context.Context, tx *sql.Tx, update domain.SharedStateUpdate) error {
+	var err error
+
+	switch {
+	case update.CircuitBreaker != nil:
+		cb := update.CircuitBreaker
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO processor_shared_state (processor, breaker_state, breaker_forced, breaker_reason, breaker_instance, breaker_changed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (processor) DO UPDATE SET
+				breaker_state = EXCLUDED.breaker_state,
+				breaker_forced = EXCLUDED.breaker_forced,
+				breaker_reason = EXCLUDED.breaker_reason,
+				breaker_instance = EXCLUDED.breaker_instance,
+				breaker_changed_at = EXCLUDED.breaker_changed_at`,
+			cb.Processor, cb.State, cb.Forced, cb.Reason, cb.Instance, cb.ChangedAt)
+	case update.Health != nil:
+		h := update.Health
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO processor_shared_state (processor, health_failing, health_min_response_time, health_instance, health_checked_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (processor) DO UPDATE SET
+				health_failing = EXCLUDED.health_failing,
+				health_min_response_time = EXCLUDED.health_min_response_time,
+				health_instance = EXCLUDED.health_instance,
+				health_checked_at = EXCLUDED.health_checked_at`,
+			h.Processor, h.Health.Failing, h.Health.MinResponseTime, h.Instance, h.Health.CheckedAt)
+	default:
+		return fmt.Errorf("empty shared state update")
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to store shared state: %w", err)
+	}
+
+	return nil
+}
+
+// Load returns the last published state of every processor
+func (d *DataSharedStateRepository) Load(ctx context.Context) ([]domain.SharedStateUpdate, error) {
+	query := `SELECT processor, breaker_state, breaker_forced, breaker_reason, breaker_instance, breaker_changed_at,
+	                 health_failing, health_min_response_time, health_instance, health_checked_at
+	          FROM processor_shared_state`
+
+	rows, err := d.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shared state: %w", err)
+	}
+	defer rows.Close()
+
+	var updates []domain.SharedStateUpdate
+	for rows.Next() {
+		var processor string
+		var breakerState, breakerReason, breakerInstance, healthInstance sql.NullString
+		var breakerForced, healthFailing sql.NullBool
+		var healthMinResponseTime sql.NullInt64
+		var breakerChangedAt, healthCheckedAt sql.NullTime
+
+		if err := rows.Scan(&processor, &breakerState, &breakerForced, &breakerReason, &breakerInstance, &breakerChangedAt,
+			&healthFailing, &healthMinResponseTime, &healthInstance, &healthCheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shared state: %w", err)
+		}
+
+		if breakerState.Valid {
+			updates = append(updates, domain.SharedStateUpdate{CircuitBreaker: &domain.CircuitBreakerUpdate{
+				Instance:  breakerInstance.String,
+				Processor: processor,
+				State:     breakerState.String,
+				Forced:    breakerForced.Bool,
+				Reason:    breakerReason.String,
+				ChangedAt: breakerChangedAt.Time,
+			}})
+		}
+
+		if healthCheckedAt.Valid {
+			updates = append(updates, domain.SharedStateUpdate{Health: &domain.HealthUpdate{
+				Instance:  healthInstance.String,
+				Processor: processor,
+				Health: domain.ProcessorHealth{
+					Failing:         healthFailing.Bool,
+					MinResponseTime: int(healthMinResponseTime.Int64),
+					CheckedAt:       healthCheckedAt.Time,
+				},
+			}})
+		}
+	}
+
+	return updates, rows.Err()
+}
+
+// Listen waits for updates published by any instance and hands them to
+// handle until ctx is done or the connection fails. It holds a dedicated
+// connection from the pool while listening.
+func (d *DataSharedStateRepository) Listen(ctx context.Context, handle func(domain.SharedStateUpdate)) error {
+	conn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get listen connection: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+pgx.Identifier{d.channel}.Sanitize()); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", d.channel, err)
+		}
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to wait for shared state notification: %w", err)
+			}
+
+			var update domain.SharedStateUpdate
+			if err := json.Unmarshal([]byte(notification.Payload), &update); err != nil {
+				continue
+			}
+
+			handle(update)
+		}
+	})
+}
+
+// AcquireLease takes or renews the named lease for holder. It succeeds when
+// the lease is free, expired or already held by holder.
+func (d *DataSharedStateRepository) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	query := `INSERT INTO shared_state_leases (name, holder, expires_at)
+	          VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+	          ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+	          WHERE shared_state_leases.holder = EXCLUDED.holder OR shared_state_leases.expires_at < NOW()`
+
+	result, err := d.DB.ExecContext(ctx, query, name, holder, ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+
+	return affected == 1, nil
+}
+
+// ReleaseLease gives up the named lease if holder holds it
+func (d *DataSharedStateRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	if _, err := d.DB.ExecContext(ctx, `DELETE FROM shared_state_leases WHERE name = $1 AND holder = $2`, name, holder); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+
+	return nil
+}
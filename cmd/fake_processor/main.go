@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/internal/fakeprocessor"
+)
+
+func main() {
+	addr := flag.String("addr", ":8001", "address to listen on")
+	fee := flag.Float64("fee", 0.05, "transaction fee reported by the admin summary")
+	token := flag.String("token", "123", "admin token (X-Rinha-Token)")
+	seed := flag.Uint64("seed", 1, "seed for the random failure modes")
+	failing := flag.Bool("failing", false, "answer every payment with 500")
+	errorRate := flag.Float64("error-rate", 0, "fraction of payments answered with 500")
+	latency := flag.Duration("latency", 0, "delay added to every payment response")
+	rateLimitEvery := flag.Int("rate-limit-every", 0, "answer every Nth payment with 429")
+	retryAfter := flag.Duration("retry-after", time.Second, "Retry-After sent with 429 answers")
+	duplicateRate := flag.Float64("duplicate-rate", 0, "fraction of new payments answered as duplicates")
+	outageStart := flag.Duration("outage-start", 0, "start of an outage window, relative to startup")
+	outageEnd := flag.Duration("outage-end", 0, "end of an outage window, relative to startup")
+	flag.Parse()
+
+	processor := fakeprocessor.New(fakeprocessor.Options{
+		Fee:        *fee,
+		AdminToken: *token,
+		Seed:       *seed,
+	})
+
+	behavior := fakeprocessor.Behavior{
+		Failing:         *failing,
+		ErrorRate:       *errorRate,
+		Latency:         *latency,
+		MinResponseTime: int(latency.Milliseconds()),
+		RateLimitEvery:  *rateLimitEvery,
+		RetryAfter:      *retryAfter,
+		DuplicateRate:   *duplicateRate,
+	}
+
+	if *outageEnd > *outageStart {
+		behavior.Outages = []fakeprocessor.Window{{Start: *outageStart, End: *outageEnd}}
+	}
+
+	processor.SetBehavior(behavior)
+
+	log.Printf("Fake payment processor listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, processor); err != nil {
+		log.Fatalf("Failed to start fake payment processor: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -12,9 +13,18 @@ import (
 
 func main() {
 	container := createAppContainer()
-	defer gracefulShutdown(container)
 
-	server.InitHTTPServer(container)
+	// The only signal handler: the HTTP server stops first, then the queue
+	// drains and the rest of the container shuts down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server.InitHTTPServer(ctx, container)
+
+	// A second signal kills the process instead of waiting for the drain
+	stop()
+
+	gracefulShutdown(container)
 }
 
 func createAppContainer() container.Container {
@@ -28,17 +38,10 @@ func createAppContainer() container.Container {
 }
 
 func gracefulShutdown(container container.Container) {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	log.Println("Received shutdown signal, gracefully shutting down...")
 
-	go func() {
-		<-c
-		log.Println("Received shutdown signal, gracefully shutting down...")
-
-		if err := container.Shutdown(); err != nil {
-			log.Printf("Error during shutdown: %v", err)
-		}
-
-		os.Exit(0)
-	}()
+	if err := container.Shutdown(); err != nil {
+		log.Printf("Error during shutdown: %v", err)
+		os.Exit(1)
+	}
 }
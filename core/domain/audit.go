@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminSummaryProvider is implemented by processors exposing their own payment summary
+type AdminSummaryProvider interface {
+	AdminSummary(ctx context.Context, from, to time.Time) (*ProcessorSummary, error)
+	ProcessorName() string
+}
+
+// AuditReport is the result of comparing our summary with the processors'
+// summaries. Consistent covers the processors whose summary was fetched;
+// Incomplete is set when a processor's summary could not be fetched.
+type AuditReport struct {
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	CheckedAt  time.Time        `json:"checkedAt"`
+	Consistent bool             `json:"consistent"`
+	Incomplete bool             `json:"incomplete"`
+	Processors []ProcessorAudit `json:"processors"`
+}
+
+// ProcessorAudit is the comparison for a single processor. Remote is nil when
+// the processor summary could not be fetched.
+type ProcessorAudit struct {
+	Processor          string            `json:"processor"`
+	Local              ProcessorSummary  `json:"local"`
+	Remote             *ProcessorSummary `json:"remote,omitempty"`
+	RequestsDiff       int64             `json:"requestsDiff"`
+	AmountDiff         float64           `json:"amountDiff"`
+	MissingAtProcessor []uuid.UUID       `json:"missingAtProcessor,omitempty"`
+	Error              string            `json:"error,omitempty"`
+}
@@ -0,0 +1,15 @@
+package domain
+
+// BulkheadStatus is a snapshot of a processor's bulkhead. Saturation is the
+// share of its concurrency slots in use; Rejected counts the calls turned
+// away since startup because the queue was full or the wait too long.
+type BulkheadStatus struct {
+	Processor     string  `json:"processor"`
+	MaxConcurrent int     `json:"maxConcurrent"`
+	MaxQueue      int     `json:"maxQueue"`
+	Active        int     `json:"active"`
+	Queued        int     `json:"queued"`
+	Rejected      int64   `json:"rejected"`
+	Saturation    float64 `json:"saturation"`
+	Saturated     bool    `json:"saturated"`
+}
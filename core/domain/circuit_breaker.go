@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// CircuitBreakerEvent describes a state change of a processor's circuit
+// breaker. Instance names the instance the change came from when it was
+// received through the shared state.
+type CircuitBreakerEvent struct {
+	Processor string    `json:"processor"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Forced    bool      `json:"forced"`
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+	Instance  string    `json:"instance,omitempty"`
+}
+
+// CircuitBreakerStatus is a snapshot of a processor's circuit breaker. Forced
+// states stay in place until they are released.
+type CircuitBreakerStatus struct {
+	Processor     string     `json:"processor"`
+	State         string     `json:"state"`
+	Forced        bool       `json:"forced"`
+	FailureCount  int        `json:"failureCount"`
+	LastFailureAt *time.Time `json:"lastFailureAt,omitempty"`
+	LastFailure   string     `json:"lastFailure,omitempty"`
+}
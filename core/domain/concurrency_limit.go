@@ -0,0 +1,10 @@
+package domain
+
+// ConcurrencyLimit is a snapshot of a processor's concurrency limit. Limit
+// changes over time when the limit adapts to the processor's latency.
+type ConcurrencyLimit struct {
+	Processor string `json:"processor"`
+	Mode      string `json:"mode"`
+	Limit     int    `json:"limit"`
+	InFlight  int    `json:"inFlight"`
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobAttempt is a failed attempt to process a queued payment
+type JobAttempt struct {
+	Attempt int       `json:"attempt"`
+	Error   string    `json:"error"`
+	At      time.Time `json:"at"`
+}
+
+// DeadLetter is a queued payment that ran out of attempts, with the error of
+// each attempt
+type DeadLetter struct {
+	ID         uuid.UUID    `json:"id"`
+	Payment    Payment      `json:"payment"`
+	Attempts   []JobAttempt `json:"attempts"`
+	EnqueuedAt time.Time    `json:"enqueuedAt"`
+	FailedAt   time.Time    `json:"failedAt"`
+}
+
+// DeadLetterReplay is the outcome of replaying every dead letter. Failed maps
+// the dead letters that failed again to their error; they stay dead-lettered.
+type DeadLetterReplay struct {
+	Replayed int                  `json:"replayed"`
+	Failed   map[uuid.UUID]string `json:"failed"`
+}
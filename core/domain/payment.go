@@ -1,23 +1,54 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Payment struct {
 	CorrelationID uuid.UUID `json:"correlationId" binding:"required"`
 	Amount        float64   `json:"amount" binding:"required,gt=0"`
+	RequestedAt   time.Time `json:"requestedAt"`
+}
+
+// NewRequestedAt returns the timestamp to stamp a payment with at intake. It is
+// truncated to milliseconds so the value sent to the processor and the value
+// stored in the database are the same.
+func NewRequestedAt() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
 }
 
-type PaymentSummary struct {
-	Default  ProcessorSummary `json:"default"`
-	Fallback ProcessorSummary `json:"fallback"`
+// Payment statuses stored with each processed payment
+const (
+	PaymentStatusProcessed = "processed"
+	PaymentStatusInDoubt   = "in_doubt"
+)
+
+// InDoubtPayment is a payment whose processor call timed out and whose outcome
+// at that processor is not known yet
+type InDoubtPayment struct {
+	Payment   Payment
+	Processor string
 }
 
+// PaymentSummary holds the summary of each registered processor keyed by processor name
+type PaymentSummary map[string]ProcessorSummary
+
 type ProcessorSummary struct {
 	TotalRequests int64   `json:"totalRequests"`
 	TotalAmount   float64 `json:"totalAmount"`
 }
 
 type PaymentProcessor interface {
-	Process(payment *Payment) (bool, error)
+	Process(ctx context.Context, payment *Payment) (bool, error)
+	ProcessorName() string
+}
+
+// PaymentLookup is implemented by processors that can tell whether they
+// recorded a payment
+type PaymentLookup interface {
+	LookupPayment(ctx context.Context, correlationID uuid.UUID) (bool, error)
 	ProcessorName() string
 }
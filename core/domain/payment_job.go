@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentJob is a payment waiting in the durable queue. Attempts counts the
+// times the job was claimed and LeaseID identifies the current claim, so only
+// the worker holding the lease can complete or retry it. Errors holds the
+// failed attempts so far and RetryDelay the backoff before the last retry.
+type PaymentJob struct {
+	ID         uuid.UUID
+	Payment    Payment
+	Attempts   int
+	Errors     []JobAttempt
+	RetryDelay time.Duration
+	LeaseID    uuid.UUID
+	CreatedAt  time.Time
+}
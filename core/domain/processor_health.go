@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ProcessorHealth is the last known health status reported by a payment processor
+type ProcessorHealth struct {
+	Failing         bool      `json:"failing"`
+	MinResponseTime int       `json:"minResponseTime"`
+	CheckedAt       time.Time `json:"checkedAt"`
+}
+
+// ProcessorHealthChecker is implemented by processors that expose a health endpoint
+type ProcessorHealthChecker interface {
+	CheckHealth(ctx context.Context) (*ProcessorHealth, error)
+	ProcessorName() string
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// QueueStats is a snapshot of the payment queue. Depth counts the jobs
+// waiting for a worker, InFlight the jobs being processed and PendingRetries
+// the failed jobs waiting for their backoff. OldestJobAgeMs is the age of the
+// oldest payment still queued, in flight or awaiting a retry, and
+// JobLatencyMs the moving average of the time to process a job. The counters
+// are since startup: Failed counts failed attempts, retried or not.
+type QueueStats struct {
+	Backend        string  `json:"backend"`
+	Workers        int     `json:"workers"`
+	MinWorkers     int     `json:"minWorkers"`
+	MaxWorkers     int     `json:"maxWorkers"`
+	Utilization    float64 `json:"utilization"`
+	Depth          int     `json:"depth"`
+	Capacity       int     `json:"capacity,omitempty"`
+	InFlight       int     `json:"inFlight"`
+	PendingRetries int     `json:"pendingRetries"`
+	OldestJobAgeMs int64   `json:"oldestJobAgeMs"`
+	JobLatencyMs   float64 `json:"jobLatencyMs"`
+	Succeeded      int64   `json:"succeeded"`
+	Failed         int64   `json:"failed"`
+	DeadLettered   int64   `json:"deadLettered"`
+}
+
+// WorkerPool is the size of the queue's worker pool and the bounds it is
+// scaled within. In a resize, zero fields are left unchanged.
+type WorkerPool struct {
+	Workers    int `json:"workers"`
+	MinWorkers int `json:"minWorkers"`
+	MaxWorkers int `json:"maxWorkers"`
+}
+
+// PaymentJobStats counts the jobs of the durable queue: Ready jobs can be
+// claimed, Delayed jobs wait for a retry. OldestCreatedAt is zero when the
+// queue is empty.
+type PaymentJobStats struct {
+	Ready           int
+	Delayed         int
+	OldestCreatedAt time.Time
+}
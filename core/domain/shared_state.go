@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// CircuitBreakerUpdate is a circuit breaker state published by an instance
+type CircuitBreakerUpdate struct {
+	Instance  string    `json:"instance"`
+	Processor string    `json:"processor"`
+	State     string    `json:"state"`
+	Forced    bool      `json:"forced"`
+	Reason    string    `json:"reason"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// HealthUpdate is a processor health status published by the instance
+// polling the processors
+type HealthUpdate struct {
+	Instance  string          `json:"instance"`
+	Processor string          `json:"processor"`
+	Health    ProcessorHealth `json:"health"`
+}
+
+// SharedStateUpdate is a change of processor state shared between instances.
+// Exactly one of its fields is set.
+type SharedStateUpdate struct {
+	CircuitBreaker *CircuitBreakerUpdate `json:"circuitBreaker,omitempty"`
+	Health         *HealthUpdate         `json:"health,omitempty"`
+}
@@ -1,9 +1,72 @@
 package core
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrPaymentNotProcessed     = errors.New("payment can't be processed")
+	ErrInvalidPayment          = errors.New("invalid payment")
 	ErrPaymentProcessingFailed = errors.New("payment processing failed")
+	ErrPaymentInDoubt          = errors.New("payment outcome at the processor is unknown")
 	ErrQueueFull               = errors.New("payment queue is full")
+	ErrInvalidWorkerPool       = errors.New("invalid worker pool size")
+	ErrCircuitBreakerOpen      = errors.New("circuit breaker is open")
+	ErrProcessorNotFound       = errors.New("payment processor not found")
+	ErrProcessorThrottled      = errors.New("no processor request token available in time")
+	ErrBulkheadFull            = errors.New("processor bulkhead is full")
+	ErrJobLeaseExpired         = errors.New("payment job lease expired")
+	ErrDeadLetterNotFound      = errors.New("dead letter not found")
+)
+
+// Processor error kinds, used as the Kind of a ProcessorError
+var (
+	ErrPaymentDuplicate     = errors.New("payment already exists at the processor")
+	ErrProcessorClientError = errors.New("processor rejected the payment")
+	ErrProcessorRateLimited = errors.New("processor rate limit exceeded")
+	ErrProcessorServerError = errors.New("processor server error")
+	ErrProcessorTimeout     = errors.New("processor call timed out")
+	ErrProcessorUnavailable = errors.New("processor unavailable")
 )
+
+// ProcessorError describes a failed processor call. It matches its Kind and
+// its underlying error with errors.Is.
+type ProcessorError struct {
+	Kind       error
+	Processor  string
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ProcessorError) Error() string {
+	msg := fmt.Sprintf("%s: %v", e.Processor, e.Kind)
+
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (HTTP %d)", msg, e.StatusCode)
+	}
+
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+
+	return msg
+}
+
+func (e *ProcessorError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// RetryAfterFrom returns the Retry-After delay carried by a processor error, if any
+func RetryAfterFrom(err error) (time.Duration, bool) {
+	var processorErr *ProcessorError
+	if errors.As(err, &processorErr) && processorErr.RetryAfter > 0 {
+		return processorErr.RetryAfter, true
+	}
+	return 0, false
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/google/uuid"
+)
+
+// DeadLetterRepository stores the queued payments that ran out of attempts.
+// Get and Delete return core.ErrDeadLetterNotFound for unknown IDs.
+type DeadLetterRepository interface {
+	Add(ctx context.Context, letter domain.DeadLetter) error
+	List(ctx context.Context, offset, limit int) ([]domain.DeadLetter, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context) (int64, error)
+}
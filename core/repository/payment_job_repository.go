@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// PaymentJobRepository stores the durable payment queue. A claimed job is
+// leased for the visibility timeout: if it is not completed, retried or
+// released by then, another worker can claim it again.
+type PaymentJobRepository interface {
+	Enqueue(ctx context.Context, payment *domain.Payment) error
+	Claim(ctx context.Context, owner string, limit int, visibilityTimeout time.Duration) ([]domain.PaymentJob, error)
+	Complete(ctx context.Context, job domain.PaymentJob) error
+	Retry(ctx context.Context, job domain.PaymentJob, delay time.Duration, failure domain.JobAttempt) error
+	Release(ctx context.Context, job domain.PaymentJob) error
+	Stats(ctx context.Context) (*domain.PaymentJobStats, error)
+}
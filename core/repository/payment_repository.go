@@ -5,10 +5,20 @@ import (
 	"time"
 
 	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/google/uuid"
 )
 
+// PaymentRepository stores the processed payments. In-doubt payments are kept
+// apart until settled: InDoubt lists those marked before the given time,
+// FindInDoubt returns nil when the payment is not in doubt, and ResolveInDoubt
+// reports whether it settled the payment, false when another caller did first.
 type PaymentRepository interface {
 	Process(ctx context.Context, payment *domain.Payment, processorName string) error
 	Summary(ctx context.Context, from, to *time.Time) (*domain.PaymentSummary, error)
 	Purge(ctx context.Context) error
+	MarkInDoubt(ctx context.Context, payment *domain.Payment, processorName string) error
+	InDoubt(ctx context.Context, markedBefore time.Time, limit int) ([]domain.InDoubtPayment, error)
+	FindInDoubt(ctx context.Context, correlationID uuid.UUID) (*domain.InDoubtPayment, error)
+	ResolveInDoubt(ctx context.Context, correlationID uuid.UUID, processed bool) (bool, error)
+	CorrelationIDs(ctx context.Context, processorName string, from, to time.Time, limit int) ([]uuid.UUID, error)
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// PaymentSpoolRepository keeps the jobs the in-memory queue could not finish
+// before shutting down. Restore hands every spooled job, oldest first, to
+// restore and removes them only once it returns nil, so each spooled job is
+// restored by a single instance and none is lost when restoring fails.
+type PaymentSpoolRepository interface {
+	Spool(ctx context.Context, jobs []domain.PaymentJob) error
+	Restore(ctx context.Context, restore func(jobs []domain.PaymentJob) error) error
+}
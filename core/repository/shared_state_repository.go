@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// SharedStateRepository shares processor state between instances and elects
+// the instance that performs singleton work
+type SharedStateRepository interface {
+	Publish(ctx context.Context, update domain.SharedStateUpdate) error
+	Load(ctx context.Context) ([]domain.SharedStateUpdate, error)
+	Listen(ctx context.Context, handle func(domain.SharedStateUpdate)) error
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
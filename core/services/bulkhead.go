@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// bulkheads publishes the live status of each processor's bulkhead with expvar
+var bulkheads = expvar.NewMap("bulkhead")
+
+// Bulkhead isolates the calls to a processor with their own concurrency
+// slots and waiting queue, so a slow processor cannot take the capacity the
+// others need. Calls beyond the queue are rejected right away.
+type Bulkhead struct {
+	slots    chan struct{}
+	maxQueue int
+	maxWait  time.Duration
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+// NewBulkhead creates a bulkhead allowing maxConcurrent calls in flight and
+// maxQueue waiting calls. A zero maxWait lets calls wait until their deadline.
+func NewBulkhead(maxConcurrent, maxQueue int, maxWait time.Duration) *Bulkhead {
+	return &Bulkhead{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxQueue: maxQueue,
+		maxWait:  maxWait,
+	}
+}
+
+// Execute runs fn in a free slot, waiting in the queue when there is room.
+// It returns core.ErrBulkheadFull when the queue is full or the wait exceeds
+// the maximum, so the caller can try another processor.
+func (b *Bulkhead) Execute(ctx context.Context, fn func() error) error {
+	if err := b.acquire(ctx); err != nil {
+		return err
+	}
+	defer func() { <-b.slots }()
+
+	return fn()
+}
+
+// acquire takes a slot, queueing for one if needed
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if b.queued.Add(1) > int64(b.maxQueue) {
+		b.queued.Add(-1)
+		b.rejected.Add(1)
+		return core.ErrBulkheadFull
+	}
+	defer b.queued.Add(-1)
+
+	var timeout <-chan time.Time
+	if b.maxWait > 0 {
+		timer := time.NewTimer(b.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		b.rejected.Add(1)
+		return core.ErrBulkheadFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// status returns a snapshot of the bulkhead
+func (b *Bulkhead) status() domain.BulkheadStatus {
+	active := len(b.slots)
+
+	return domain.BulkheadStatus{
+		MaxConcurrent: cap(b.slots),
+		MaxQueue:      b.maxQueue,
+		Active:        active,
+		Queued:        int(b.queued.Load()),
+		Rejected:      b.rejected.Load(),
+		Saturation:    float64(active) / float64(cap(b.slots)),
+		Saturated:     active == cap(b.slots),
+	}
+}
+
+// publishBulkhead publishes the live status of a route's bulkhead
+func publishBulkhead(route *processorRoute) {
+	bulkheads.Set(route.processor.ProcessorName(), expvar.Func(func() any {
+		return routeBulkheadStatus(route)
+	}))
+}
+
+// routeBulkheadStatus returns the status of a route's bulkhead
+func routeBulkheadStatus(route *processorRoute) domain.BulkheadStatus {
+	status := route.bulkhead.status()
+	status.Processor = route.processor.ProcessorName()
+	return status
+}
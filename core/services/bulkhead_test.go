@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+)
+
+// occupy holds the bulkhead's slots until the returned function is called
+func occupy(t *testing.T, b *Bulkhead, calls int) func() {
+	t.Helper()
+
+	release := make(chan struct{})
+	for range calls {
+		started := make(chan struct{})
+		go b.Execute(context.Background(), func() error {
+			close(started)
+			<-release
+			return nil
+		})
+		<-started
+	}
+
+	return func() { close(release) }
+}
+
+func TestBulkhead(t *testing.T) {
+	t.Run("Rejects calls beyond the queue", func(t *testing.T) {
+		b := NewBulkhead(1, 0, 0)
+		release := occupy(t, b, 1)
+		defer release()
+
+		err := b.Execute(context.Background(), func() error { return nil })
+		if !errors.Is(err, core.ErrBulkheadFull) {
+			t.Fatalf("Expected bulkhead full error, got: %v", err)
+		}
+
+		status := b.status()
+		if status.Active != 1 || status.Rejected != 1 || !status.Saturated || status.Saturation != 1 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("Queues calls until a slot is free", func(t *testing.T) {
+		b := NewBulkhead(1, 1, 0)
+		release := occupy(t, b, 1)
+
+		done := make(chan error)
+		go func() {
+			done <- b.Execute(context.Background(), func() error { return nil })
+		}()
+
+		deadline := time.Now().Add(time.Second)
+		for b.status().Queued != 1 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		release()
+
+		if err := <-done; err != nil {
+			t.Fatalf("Expected the queued call to run, got: %v", err)
+		}
+	})
+
+	t.Run("Rejects calls waiting too long", func(t *testing.T) {
+		b := NewBulkhead(1, 1, 10*time.Millisecond)
+		release := occupy(t, b, 1)
+		defer release()
+
+		err := b.Execute(context.Background(), func() error { return nil })
+		if !errors.Is(err, core.ErrBulkheadFull) {
+			t.Fatalf("Expected bulkhead full error, got: %v", err)
+		}
+		if b.status().Queued != 0 {
+			t.Errorf("Expected an empty queue, got: %d", b.status().Queued)
+		}
+	})
+
+	t.Run("Stops waiting at the deadline", func(t *testing.T) {
+		b := NewBulkhead(1, 1, 0)
+		release := occupy(t, b, 1)
+		defer release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := b.Execute(ctx, func() error { return nil })
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected deadline exceeded, got: %v", err)
+		}
+	})
+}
@@ -1,9 +1,14 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
 )
 
 // CircuitBreakerState represents the circuit breaker states
@@ -15,59 +20,368 @@ const (
 	HalfOpen
 )
 
-// CircuitBreaker implements the Circuit Breaker pattern for fast failures
+// String returns the state name
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// parseCircuitBreakerState returns the state with the given name
+func parseCircuitBreakerState(name string) (CircuitBreakerState, bool) {
+	for _, state := range []CircuitBreakerState{Closed, Open, HalfOpen} {
+		if state.String() == name {
+			return state, true
+		}
+	}
+
+	return Closed, false
+}
+
+// StateChange describes a circuit breaker state change. Instance is set when
+// the change was received from another instance sharing the breaker state.
+type StateChange struct {
+	From     CircuitBreakerState
+	To       CircuitBreakerState
+	Forced   bool
+	Reason   string
+	At       time.Time
+	Instance string
+}
+
+// StateChangeListener is notified of every circuit breaker state change
+type StateChangeListener func(change StateChange)
+
+// callOutcome is how the result of a protected call affects the breaker
+type callOutcome int
+
+const (
+	outcomeSuccess callOutcome = iota
+	outcomeFailure
+	outcomeNeutral
+)
+
+// CircuitBreaker implements the Circuit Breaker pattern for fast failures.
+// Its failure policy decides when a closed circuit opens. The mutex only
+// guards state checks and result recording; the protected call runs without
+// it, so concurrent calls through a closed breaker never queue.
 type CircuitBreaker struct {
-	maxFailures  int
-	resetTimeout time.Duration
-	failureCount int
-	lastFailTime time.Time
-	state        CircuitBreakerState
-	mutex        sync.RWMutex
+	policy            failurePolicy
+	resetTimeout      time.Duration
+	halfOpenMaxCalls  int
+	lastFailTime      time.Time
+	lastFailure       string
+	openedAt          time.Time
+	forced            bool
+	state             CircuitBreakerState
+	generation        uint64
+	halfOpenCalls     int
+	halfOpenSuccesses int
+	listeners         []StateChangeListener
+	transitions       []StateChange
+	mutex             sync.RWMutex
+}
+
+// NewCircuitBreaker creates a circuit breaker that opens after maxFailures
+// consecutive failures. halfOpenMaxCalls is the number of probe calls allowed
+// while half-open; all of them must succeed to close the circuit.
+func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration, halfOpenMaxCalls int) *CircuitBreaker {
+	return newCircuitBreaker(&consecutivePolicy{maxFailures: maxFailures}, resetTimeout, halfOpenMaxCalls)
 }
 
-// NewCircuitBreaker creates a new circuit breaker instance
-func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
+// NewSlidingWindowCircuitBreaker creates a circuit breaker that opens when
+// the failure rate or slow call rate of its sliding window crosses a threshold
+func NewSlidingWindowCircuitBreaker(settings SlidingWindowSettings, resetTimeout time.Duration, halfOpenMaxCalls int) *CircuitBreaker {
+	return newCircuitBreaker(newSlidingWindowPolicy(settings), resetTimeout, halfOpenMaxCalls)
+}
+
+func newCircuitBreaker(policy failurePolicy, resetTimeout time.Duration, halfOpenMaxCalls int) *CircuitBreaker {
+	if halfOpenMaxCalls <= 0 {
+		halfOpenMaxCalls = 1
+	}
+
 	return &CircuitBreaker{
-		maxFailures:  maxFailures,
-		resetTimeout: resetTimeout,
-		state:        Closed,
+		policy:           policy,
+		resetTimeout:     resetTimeout,
+		halfOpenMaxCalls: halfOpenMaxCalls,
+		state:            Closed,
 	}
 }
 
-// Call executes a function protected by the circuit breaker
-func (cb *CircuitBreaker) Call(fn func() error) error {
+// Call executes a function protected by the circuit breaker. The context is
+// passed to fn; calls cancelled by the caller are not counted as failures.
+func (cb *CircuitBreaker) Call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	generation, err := cb.beforeCall()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = fn(ctx)
+	cb.afterCall(generation, classifyOutcome(ctx, err), time.Since(start), err)
+
+	return err
+}
+
+// OnStateChange registers a listener for state changes. Listeners run
+// outside the breaker's lock, on the goroutine whose call changed the state.
+func (cb *CircuitBreaker) OnStateChange(listener StateChangeListener) {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
+	cb.listeners = append(cb.listeners, listener)
+}
+
+// beforeCall admits a call and returns the generation it belongs to
+func (cb *CircuitBreaker) beforeCall() (uint64, error) {
+	cb.mutex.Lock()
+	defer cb.unlockAndNotify()
+
+	cb.refreshState(time.Now())
 
-	// Check if we should reset the circuit breaker
-	if cb.state == Open && time.Since(cb.lastFailTime) > cb.resetTimeout {
-		cb.state = HalfOpen
-		cb.failureCount = 0
+	switch cb.state {
+	case Open:
+		return 0, core.ErrCircuitBreakerOpen
+	case HalfOpen:
+		if cb.halfOpenCalls >= cb.halfOpenMaxCalls {
+			return 0, core.ErrCircuitBreakerOpen
+		}
+		cb.halfOpenCalls++
 	}
 
-	// If circuit is open, fail fast
-	if cb.state == Open {
-		return fmt.Errorf("circuit breaker is open")
+	return cb.generation, nil
+}
+
+// afterCall records the outcome of a call. Results of calls admitted before
+// the last state change are ignored, they describe a previous period.
+func (cb *CircuitBreaker) afterCall(generation uint64, outcome callOutcome, duration time.Duration, err error) {
+	cb.mutex.Lock()
+	defer cb.unlockAndNotify()
+
+	if generation != cb.generation {
+		return
 	}
 
-	// Execute the function
-	err := fn()
-	if err != nil {
-		cb.failureCount++
-		cb.lastFailTime = time.Now()
+	switch outcome {
+	case outcomeSuccess:
+		cb.onSuccess(time.Now(), duration)
+	case outcomeFailure:
+		cb.lastFailure = err.Error()
+		cb.onFailure(time.Now(), duration)
+	case outcomeNeutral:
+		// A probe that proved nothing frees its slot for another one
+		if cb.state == HalfOpen {
+			cb.halfOpenCalls--
+		}
+	}
+}
 
-		if cb.failureCount >= cb.maxFailures {
-			cb.state = Open
+// onSuccess records a successful call. The caller must hold the mutex.
+func (cb *CircuitBreaker) onSuccess(now time.Time, duration time.Duration) {
+	switch cb.state {
+	case Closed:
+		// Slow successes can open a sliding window breaker too
+		cb.policy.record(now, false, duration)
+		if !cb.forced && cb.policy.shouldOpen(now) {
+			cb.setState(Open, cb.policy.openReason(now), now)
 		}
-		return err
+	case HalfOpen:
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.halfOpenMaxCalls {
+			cb.setState(Closed, fmt.Sprintf("%d half-open probes succeeded", cb.halfOpenSuccesses), now)
+		}
+	}
+}
+
+// onFailure records a failed call. The caller must hold the mutex.
+func (cb *CircuitBreaker) onFailure(now time.Time, duration time.Duration) {
+	cb.lastFailTime = now
+
+	switch cb.state {
+	case Closed:
+		cb.policy.record(now, true, duration)
+		if !cb.forced && cb.policy.shouldOpen(now) {
+			cb.setState(Open, cb.policy.openReason(now), now)
+		}
+	case HalfOpen:
+		cb.setState(Open, "half-open probe failed", now)
+	}
+}
+
+// refreshState moves an open circuit to half-open once its reset timeout has
+// elapsed, unless it was forced open. The caller must hold the mutex.
+func (cb *CircuitBreaker) refreshState(now time.Time) {
+	if cb.state == Open && !cb.forced && now.Sub(cb.openedAt) > cb.resetTimeout {
+		cb.setState(HalfOpen, fmt.Sprintf("reset timeout of %v elapsed", cb.resetTimeout), now)
+	}
+}
+
+// setState changes the state, starts a new generation and queues the
+// transition for the listeners. The caller must hold the mutex.
+func (cb *CircuitBreaker) setState(state CircuitBreakerState, reason string, now time.Time) {
+	cb.changeState(state, reason, now, "")
+}
+
+// changeState changes the state on behalf of an instance, empty for this one.
+// The caller must hold the mutex.
+func (cb *CircuitBreaker) changeState(state CircuitBreakerState, reason string, now time.Time, instance string) {
+	if len(cb.listeners) > 0 {
+		cb.transitions = append(cb.transitions, StateChange{
+			From:     cb.state,
+			To:       state,
+			Forced:   cb.forced,
+			Reason:   reason,
+			At:       now,
+			Instance: instance,
+		})
 	}
 
-	// Success - reset if we were in half-open state
-	if cb.state == HalfOpen {
-		cb.state = Closed
+	cb.state = state
+	cb.generation++
+	cb.halfOpenCalls = 0
+	cb.halfOpenSuccesses = 0
+
+	if state == Open {
+		cb.openedAt = now
+	} else {
+		cb.policy.reset()
 	}
-	cb.failureCount = 0
-	return nil
+}
+
+// ForceOpen opens the circuit and keeps it open until Release or Reset
+func (cb *CircuitBreaker) ForceOpen(reason string) {
+	cb.mutex.Lock()
+	defer cb.unlockAndNotify()
+
+	cb.forced = true
+	cb.setState(Open, "forced open: "+reason, time.Now())
+}
+
+// ForceClosed closes the circuit and keeps it closed, whatever the outcome of
+// the calls, until Release or Reset
+func (cb *CircuitBreaker) ForceClosed(reason string) {
+	cb.mutex.Lock()
+	defer cb.unlockAndNotify()
+
+	cb.forced = true
+	cb.setState(Closed, "forced closed: "+reason, time.Now())
+}
+
+// Release ends a forced state. The breaker resumes from a closed circuit with
+// fresh counts. It reports whether the breaker was forced.
+func (cb *CircuitBreaker) Release(reason string) bool {
+	cb.mutex.Lock()
+	defer cb.unlockAndNotify()
+
+	if !cb.forced {
+		return false
+	}
+
+	cb.forced = false
+	cb.setState(Closed, "released: "+reason, time.Now())
+	return true
+}
+
+// Reset ends any forced state, closes the circuit and forgets every failure
+func (cb *CircuitBreaker) Reset(reason string) {
+	cb.mutex.Lock()
+	defer cb.unlockAndNotify()
+
+	cb.forced = false
+	cb.lastFailTime = time.Time{}
+	cb.lastFailure = ""
+	cb.setState(Closed, "reset: "+reason, time.Now())
+}
+
+// applySharedState applies a state published by another instance. Half-open
+// is local to each instance and is not shared.
+func (cb *CircuitBreaker) applySharedState(state CircuitBreakerState, forced bool, reason string, at time.Time, instance string) {
+	cb.mutex.Lock()
+	defer cb.unlockAndNotify()
+
+	if state == HalfOpen || (cb.state == state && cb.forced == forced) {
+		return
+	}
+
+	cb.forced = forced
+	cb.changeState(state, reason, at, instance)
+}
+
+// IsForced reports whether the current state was forced
+func (cb *CircuitBreaker) IsForced() bool {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.forced
+}
+
+// status returns a snapshot of the breaker
+func (cb *CircuitBreaker) status() domain.CircuitBreakerStatus {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+
+	status := domain.CircuitBreakerStatus{
+		State:        cb.state.String(),
+		Forced:       cb.forced,
+		FailureCount: cb.policy.failures(time.Now()),
+		LastFailure:  cb.lastFailure,
+	}
+
+	if !cb.lastFailTime.IsZero() {
+		lastFailTime := cb.lastFailTime
+		status.LastFailureAt = &lastFailTime
+	}
+
+	return status
+}
+
+// unlockAndNotify releases the mutex, then sends the queued transitions to
+// the listeners
+func (cb *CircuitBreaker) unlockAndNotify() {
+	transitions, listeners := cb.transitions, cb.listeners
+	cb.transitions = nil
+	cb.mutex.Unlock()
+
+	for _, change := range transitions {
+		for _, listener := range listeners {
+			listener(change)
+		}
+	}
+}
+
+// classifyOutcome decides how the result of a call affects the breaker.
+// Calls cancelled by the caller and errors that say nothing about the
+// processor's health are neutral; a duplicate proves the processor is up.
+func classifyOutcome(ctx context.Context, err error) callOutcome {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case errors.Is(ctx.Err(), context.Canceled):
+		return outcomeNeutral
+	case errors.Is(err, core.ErrPaymentDuplicate):
+		return outcomeSuccess
+	case !isBreakerFailure(err):
+		return outcomeNeutral
+	default:
+		return outcomeFailure
+	}
+}
+
+// isBreakerFailure reports whether an error counts against the processor.
+// Duplicates, client errors and rate limits do not.
+func isBreakerFailure(err error) bool {
+	return !errors.Is(err, core.ErrPaymentDuplicate) &&
+		!errors.Is(err, core.ErrProcessorClientError) &&
+		!errors.Is(err, core.ErrProcessorRateLimited)
 }
 
 // GetState returns the current circuit breaker state
@@ -81,5 +395,22 @@ func (cb *CircuitBreaker) GetState() CircuitBreakerState {
 func (cb *CircuitBreaker) GetFailureCount() int {
 	cb.mutex.RLock()
 	defer cb.mutex.RUnlock()
-	return cb.failureCount
+	return cb.policy.failures(time.Now())
+}
+
+// IsAvailable reports whether a call would be attempted right now, i.e. the
+// circuit is closed, its reset timeout has elapsed without it being forced
+// open, or a half-open probe slot is free
+func (cb *CircuitBreaker) IsAvailable() bool {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+
+	switch cb.state {
+	case Open:
+		return !cb.forced && time.Since(cb.openedAt) > cb.resetTimeout
+	case HalfOpen:
+		return cb.halfOpenCalls < cb.halfOpenMaxCalls
+	default:
+		return true
+	}
 }
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"expvar"
+	"log"
+	"sync"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// Circuit breaker metrics, published with expvar
+var (
+	// circuitBreakerTransitions counts transitions by processor and new state
+	circuitBreakerTransitions = expvar.NewMap("circuit_breaker_transitions")
+	// circuitBreakerStates holds the current state name of each processor's breaker
+	circuitBreakerStates = expvar.NewMap("circuit_breaker_state")
+)
+
+// CircuitBreakerEventLog logs circuit breaker state changes, publishes them
+// as metrics and keeps the most recent ones of each processor
+type CircuitBreakerEventLog struct {
+	historySize int
+	events      map[string][]domain.CircuitBreakerEvent
+	mutex       sync.RWMutex
+}
+
+// NewCircuitBreakerEventLog creates an event log keeping historySize events per processor
+func NewCircuitBreakerEventLog(historySize int) *CircuitBreakerEventLog {
+	return &CircuitBreakerEventLog{
+		historySize: historySize,
+		events:      make(map[string][]domain.CircuitBreakerEvent),
+	}
+}
+
+// Record logs the event, updates the metrics and adds it to the history
+func (l *CircuitBreakerEventLog) Record(event domain.CircuitBreakerEvent) {
+	if event.Instance != "" {
+		log.Printf("Circuit breaker for %s changed from %s to %s by instance %s: %s", event.Processor, event.From, event.To, event.Instance, event.Reason)
+	} else {
+		log.Printf("Circuit breaker for %s changed from %s to %s: %s", event.Processor, event.From, event.To, event.Reason)
+	}
+
+	circuitBreakerTransitions.Add(event.Processor+"."+event.To, 1)
+	state := new(expvar.String)
+	state.Set(event.To)
+	circuitBreakerStates.Set(event.Processor, state)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	events := append(l.events[event.Processor], event)
+	if len(events) > l.historySize {
+		events = events[len(events)-l.historySize:]
+	}
+	l.events[event.Processor] = events
+}
+
+// Events returns the recent events of every processor, newest first
+func (l *CircuitBreakerEventLog) Events() map[string][]domain.CircuitBreakerEvent {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	result := make(map[string][]domain.CircuitBreakerEvent, len(l.events))
+	for processor, events := range l.events {
+		newestFirst := make([]domain.CircuitBreakerEvent, len(events))
+		for i, event := range events {
+			newestFirst[len(events)-1-i] = event
+		}
+		result[processor] = newestFirst
+	}
+
+	return result
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// failurePolicy decides when a closed circuit opens, from the outcomes of
+// the calls it let through
+type failurePolicy interface {
+	// record adds the outcome of a call that took the given duration
+	record(now time.Time, failed bool, duration time.Duration)
+	// shouldOpen reports whether the recorded calls should open the circuit
+	shouldOpen(now time.Time) bool
+	// failures returns the number of failures currently counted
+	failures(now time.Time) int
+	// openReason describes why the recorded calls open the circuit
+	openReason(now time.Time) string
+	// reset forgets every recorded call
+	reset()
+}
+
+// consecutivePolicy opens the circuit after maxFailures failures in a row
+type consecutivePolicy struct {
+	maxFailures  int
+	failureCount int
+}
+
+func (p *consecutivePolicy) record(now time.Time, failed bool, duration time.Duration) {
+	if failed {
+		p.failureCount++
+		return
+	}
+	p.failureCount = 0
+}
+
+func (p *consecutivePolicy) shouldOpen(now time.Time) bool {
+	return p.failureCount >= p.maxFailures
+}
+
+func (p *consecutivePolicy) failures(now time.Time) int {
+	return p.failureCount
+}
+
+func (p *consecutivePolicy) openReason(now time.Time) string {
+	return fmt.Sprintf("%d consecutive failures", p.failureCount)
+}
+
+func (p *consecutivePolicy) reset() {
+	p.failureCount = 0
+}
+
+// SlidingWindowSettings configures a failure-rate circuit breaker. The window
+// covers the last Size calls, or the last Duration when TimeBased is set.
+// Rates are fractions between 0 and 1; a call is slow when it takes at least
+// SlowCallDuration, and a zero SlowCallDuration disables slow call tracking.
+type SlidingWindowSettings struct {
+	TimeBased             bool
+	Size                  int
+	Duration              time.Duration
+	FailureRateThreshold  float64
+	SlowCallRateThreshold float64
+	SlowCallDuration      time.Duration
+	MinimumCalls          int
+}
+
+// windowCounts aggregates the calls of a window or of a bucket
+type windowCounts struct {
+	calls    int
+	failures int
+	slow     int
+}
+
+func (c *windowCounts) add(failed, slow bool) {
+	c.calls++
+	if failed {
+		c.failures++
+	}
+	if slow {
+		c.slow++
+	}
+}
+
+// slidingWindowPolicy opens the circuit when the failure rate or the slow
+// call rate of the window crosses its threshold, once the window holds
+// enough calls
+type slidingWindowPolicy struct {
+	settings SlidingWindowSettings
+	window   slidingWindow
+}
+
+// slidingWindow stores call outcomes over a count or time range
+type slidingWindow interface {
+	add(now time.Time, failed, slow bool)
+	counts(now time.Time) windowCounts
+	reset()
+}
+
+func newSlidingWindowPolicy(settings SlidingWindowSettings) *slidingWindowPolicy {
+	var window slidingWindow
+	if settings.TimeBased {
+		window = newTimeWindow(settings.Duration)
+	} else {
+		window = newCountWindow(settings.Size)
+	}
+
+	return &slidingWindowPolicy{settings: settings, window: window}
+}
+
+func (p *slidingWindowPolicy) record(now time.Time, failed bool, duration time.Duration) {
+	slow := p.settings.SlowCallDuration > 0 && duration >= p.settings.SlowCallDuration
+	p.window.add(now, failed, slow)
+}
+
+func (p *slidingWindowPolicy) shouldOpen(now time.Time) bool {
+	counts := p.window.counts(now)
+	if counts.calls == 0 || counts.calls < p.settings.MinimumCalls {
+		return false
+	}
+
+	failureRate := float64(counts.failures) / float64(counts.calls)
+	if failureRate >= p.settings.FailureRateThreshold {
+		return true
+	}
+
+	slowRate := float64(counts.slow) / float64(counts.calls)
+	return p.settings.SlowCallDuration > 0 && slowRate >= p.settings.SlowCallRateThreshold
+}
+
+func (p *slidingWindowPolicy) failures(now time.Time) int {
+	return p.window.counts(now).failures
+}
+
+func (p *slidingWindowPolicy) openReason(now time.Time) string {
+	counts := p.window.counts(now)
+	return fmt.Sprintf("%d failures and %d slow calls out of %d calls", counts.failures, counts.slow, counts.calls)
+}
+
+func (p *slidingWindowPolicy) reset() {
+	p.window.reset()
+}
+
+// countWindow keeps the outcomes of the last size calls in a ring buffer
+type countWindow struct {
+	calls  []windowCall
+	next   int
+	filled int
+	totals windowCounts
+}
+
+type windowCall struct {
+	failed bool
+	slow   bool
+}
+
+func newCountWindow(size int) *countWindow {
+	return &countWindow{calls: make([]windowCall, size)}
+}
+
+func (w *countWindow) add(now time.Time, failed, slow bool) {
+	if w.filled == len(w.calls) {
+		evicted := w.calls[w.next]
+		w.totals.calls--
+		if evicted.failed {
+			w.totals.failures--
+		}
+		if evicted.slow {
+			w.totals.slow--
+		}
+	} else {
+		w.filled++
+	}
+
+	w.calls[w.next] = windowCall{failed: failed, slow: slow}
+	w.next = (w.next + 1) % len(w.calls)
+	w.totals.add(failed, slow)
+}
+
+func (w *countWindow) counts(now time.Time) windowCounts {
+	return w.totals
+}
+
+func (w *countWindow) reset() {
+	clear(w.calls)
+	w.next = 0
+	w.filled = 0
+	w.totals = windowCounts{}
+}
+
+// timeWindowBuckets is the number of buckets a time window is split into
+const timeWindowBuckets = 10
+
+// timeWindow keeps the outcomes of the last duration in fixed buckets
+type timeWindow struct {
+	width   time.Duration
+	buckets [timeWindowBuckets]timeBucket
+}
+
+type timeBucket struct {
+	epoch int64
+	windowCounts
+}
+
+func newTimeWindow(duration time.Duration) *timeWindow {
+	width := duration / timeWindowBuckets
+	if width <= 0 {
+		width = time.Millisecond
+	}
+
+	return &timeWindow{width: width}
+}
+
+func (w *timeWindow) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(w.width)
+}
+
+func (w *timeWindow) add(now time.Time, failed, slow bool) {
+	epoch := w.epoch(now)
+	bucket := &w.buckets[epoch%timeWindowBuckets]
+
+	if bucket.epoch != epoch {
+		*bucket = timeBucket{epoch: epoch}
+	}
+
+	bucket.add(failed, slow)
+}
+
+func (w *timeWindow) counts(now time.Time) windowCounts {
+	epoch := w.epoch(now)
+
+	var totals windowCounts
+	for _, bucket := range w.buckets {
+		if bucket.epoch > epoch-timeWindowBuckets && bucket.epoch <= epoch {
+			totals.calls += bucket.calls
+			totals.failures += bucket.failures
+			totals.slow += bucket.slow
+		}
+	}
+
+	return totals
+}
+
+func (w *timeWindow) reset() {
+	w.buckets = [timeWindowBuckets]timeBucket{}
+}
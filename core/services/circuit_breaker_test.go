@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+var errProcessorDown = &core.ProcessorError{Kind: core.ErrProcessorServerError, Processor: "test"}
+
+func failingCall(ctx context.Context) error { return errProcessorDown }
+
+func succeedingCall(ctx context.Context) error { return nil }
+
+func TestCircuitBreaker_Call(t *testing.T) {
+	t.Run("Opens after max failures", func(t *testing.T) {
+		cb := NewCircuitBreaker(3, time.Minute, 1)
+
+		for range 3 {
+			cb.Call(context.Background(), failingCall)
+		}
+
+		if cb.GetState() != Open {
+			t.Fatalf("Expected breaker to be open, got: %v", cb.GetState())
+		}
+		if err := cb.Call(context.Background(), succeedingCall); !errors.Is(err, core.ErrCircuitBreakerOpen) {
+			t.Errorf("Expected open breaker error, got: %v", err)
+		}
+	})
+
+	t.Run("Neutral errors are not counted", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, time.Minute, 1)
+		rateLimited := &core.ProcessorError{Kind: core.ErrProcessorRateLimited, Processor: "test"}
+
+		cb.Call(context.Background(), func(ctx context.Context) error { return rateLimited })
+
+		if cb.GetState() != Closed || cb.GetFailureCount() != 0 {
+			t.Errorf("Expected closed breaker without failures, got: %v, %d", cb.GetState(), cb.GetFailureCount())
+		}
+	})
+
+	t.Run("Half-open allows the configured probes", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, 10*time.Millisecond, 2)
+		cb.Call(context.Background(), failingCall)
+		time.Sleep(20 * time.Millisecond)
+
+		release := make(chan struct{})
+		started := make(chan struct{}, 2)
+		var wg sync.WaitGroup
+
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cb.Call(context.Background(), func(ctx context.Context) error {
+					started <- struct{}{}
+					<-release
+					return nil
+				})
+			}()
+		}
+
+		<-started
+		<-started
+
+		if err := cb.Call(context.Background(), succeedingCall); !errors.Is(err, core.ErrCircuitBreakerOpen) {
+			t.Errorf("Expected a third probe to be rejected, got: %v", err)
+		}
+
+		close(release)
+		wg.Wait()
+
+		if cb.GetState() != Closed {
+			t.Errorf("Expected breaker to close after successful probes, got: %v", cb.GetState())
+		}
+	})
+
+	t.Run("Failed probe reopens", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, 10*time.Millisecond, 2)
+		cb.Call(context.Background(), failingCall)
+		time.Sleep(20 * time.Millisecond)
+
+		cb.Call(context.Background(), failingCall)
+
+		if cb.GetState() != Open {
+			t.Errorf("Expected breaker to reopen, got: %v", cb.GetState())
+		}
+	})
+
+	t.Run("Does not serialize calls", func(t *testing.T) {
+		cb := NewCircuitBreaker(5, time.Minute, 1)
+		const calls = 20
+		const latency = 50 * time.Millisecond
+
+		var wg sync.WaitGroup
+		start := time.Now()
+
+		for range calls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cb.Call(context.Background(), func(ctx context.Context) error {
+					time.Sleep(latency)
+					return nil
+				})
+			}()
+		}
+		wg.Wait()
+
+		if elapsed := time.Since(start); elapsed > 5*latency {
+			t.Errorf("Expected concurrent calls to overlap, took: %v", elapsed)
+		}
+	})
+}
+
+func TestCircuitBreaker_OnStateChange(t *testing.T) {
+	cb := NewCircuitBreaker(2, 10*time.Millisecond, 1)
+	events := NewCircuitBreakerEventLog(10)
+
+	cb.OnStateChange(func(change StateChange) {
+		events.Record(domain.CircuitBreakerEvent{Processor: "default", From: change.From.String(), To: change.To.String(), Reason: change.Reason, Timestamp: change.At})
+	})
+
+	cb.Call(context.Background(), failingCall)
+	cb.Call(context.Background(), failingCall)
+	time.Sleep(20 * time.Millisecond)
+	cb.Call(context.Background(), succeedingCall)
+
+	got := events.Events()["default"]
+	want := []string{"half_open->closed", "open->half_open", "closed->open"}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d events, got: %+v", len(want), got)
+	}
+	for i, event := range got {
+		if transition := event.From + "->" + event.To; transition != want[i] {
+			t.Errorf("Expected event %d to be %s, got: %s", i, want[i], transition)
+		}
+		if event.Reason == "" || event.Timestamp.IsZero() {
+			t.Errorf("Expected event %d to have a reason and timestamp, got: %+v", i, event)
+		}
+	}
+}
+
+func TestCircuitBreaker_ForcedStates(t *testing.T) {
+	t.Run("Forced open stays open past the reset timeout", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, 10*time.Millisecond, 1)
+		cb.ForceOpen("maintenance")
+		time.Sleep(20 * time.Millisecond)
+
+		if cb.IsAvailable() {
+			t.Error("Expected forced open breaker to be unavailable")
+		}
+		if err := cb.Call(context.Background(), succeedingCall); !errors.Is(err, core.ErrCircuitBreakerOpen) {
+			t.Errorf("Expected open breaker error, got: %v", err)
+		}
+	})
+
+	t.Run("Forced closed ignores failures", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, time.Minute, 1)
+		cb.ForceClosed("processor recovered")
+
+		for range 3 {
+			cb.Call(context.Background(), failingCall)
+		}
+
+		status := cb.status()
+		if status.State != "closed" || !status.Forced {
+			t.Errorf("Expected forced closed breaker, got: %+v", status)
+		}
+		if status.FailureCount != 3 || status.LastFailureAt == nil || status.LastFailure == "" {
+			t.Errorf("Expected failures to be tracked, got: %+v", status)
+		}
+	})
+
+	t.Run("Release resumes normal operation", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, time.Minute, 1)
+		cb.ForceOpen("maintenance")
+
+		if !cb.Release("done") {
+			t.Fatal("Expected release to report a forced breaker")
+		}
+		if cb.GetState() != Closed || cb.IsForced() {
+			t.Errorf("Expected closed unforced breaker, got: %v, %v", cb.GetState(), cb.IsForced())
+		}
+
+		cb.Call(context.Background(), failingCall)
+		if cb.GetState() != Open {
+			t.Errorf("Expected breaker to open again on failures, got: %v", cb.GetState())
+		}
+	})
+
+	t.Run("Reset forgets failures", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, time.Minute, 1)
+		cb.Call(context.Background(), failingCall)
+		cb.Reset("manual")
+
+		status := cb.status()
+		if status.State != "closed" || status.FailureCount != 0 || status.LastFailureAt != nil {
+			t.Errorf("Expected a clean closed breaker, got: %+v", status)
+		}
+	})
+}
+
+func TestCircuitBreaker_SlidingWindow(t *testing.T) {
+	settings := SlidingWindowSettings{
+		Size:                  10,
+		FailureRateThreshold:  0.5,
+		SlowCallRateThreshold: 0.5,
+		MinimumCalls:          10,
+	}
+
+	t.Run("Opens on failure rate despite successes", func(t *testing.T) {
+		cb := NewSlidingWindowCircuitBreaker(settings, time.Minute, 1)
+
+		// 80% failures, never more than four in a row
+		for i := range 10 {
+			if i%5 == 0 {
+				cb.Call(context.Background(), succeedingCall)
+			} else {
+				cb.Call(context.Background(), failingCall)
+			}
+		}
+
+		if cb.GetState() != Open {
+			t.Errorf("Expected breaker to be open, got: %v", cb.GetState())
+		}
+	})
+
+	t.Run("Waits for the minimum calls", func(t *testing.T) {
+		cb := NewSlidingWindowCircuitBreaker(settings, time.Minute, 1)
+
+		for range 9 {
+			cb.Call(context.Background(), failingCall)
+		}
+
+		if cb.GetState() != Closed {
+			t.Errorf("Expected breaker to stay closed, got: %v", cb.GetState())
+		}
+	})
+
+	t.Run("Stays closed below the threshold", func(t *testing.T) {
+		cb := NewSlidingWindowCircuitBreaker(settings, time.Minute, 1)
+
+		for i := range 30 {
+			if i%3 == 0 {
+				cb.Call(context.Background(), failingCall)
+			} else {
+				cb.Call(context.Background(), succeedingCall)
+			}
+		}
+
+		if cb.GetState() != Closed {
+			t.Errorf("Expected breaker to stay closed, got: %v", cb.GetState())
+		}
+	})
+
+	t.Run("Opens on slow call rate", func(t *testing.T) {
+		slow := settings
+		slow.Size = 4
+		slow.MinimumCalls = 4
+		slow.SlowCallDuration = 5 * time.Millisecond
+		cb := NewSlidingWindowCircuitBreaker(slow, time.Minute, 1)
+
+		for range 4 {
+			cb.Call(context.Background(), func(ctx context.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+		}
+
+		if cb.GetState() != Open {
+			t.Errorf("Expected breaker to be open, got: %v", cb.GetState())
+		}
+	})
+
+	t.Run("Time based window forgets old calls", func(t *testing.T) {
+		timed := settings
+		timed.TimeBased = true
+		timed.Duration = 50 * time.Millisecond
+		timed.MinimumCalls = 4
+		cb := NewSlidingWindowCircuitBreaker(timed, time.Minute, 1)
+
+		for range 3 {
+			cb.Call(context.Background(), failingCall)
+		}
+		time.Sleep(60 * time.Millisecond)
+		cb.Call(context.Background(), failingCall)
+
+		if cb.GetState() != Closed {
+			t.Errorf("Expected breaker to stay closed, got: %v", cb.GetState())
+		}
+		if cb.GetFailureCount() != 1 {
+			t.Errorf("Expected one failure in the window, got: %d", cb.GetFailureCount())
+		}
+	})
+}
+
+// BenchmarkCircuitBreaker_Call measures throughput of calls with a simulated
+// round trip; ns/op should drop as parallelism grows.
+func BenchmarkCircuitBreaker_Call(b *testing.B) {
+	for _, parallelism := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("parallelism-%d", parallelism), func(b *testing.B) {
+			cb := NewCircuitBreaker(5, time.Minute, 1)
+			b.SetParallelism(parallelism)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					cb.Call(context.Background(), func(ctx context.Context) error {
+						time.Sleep(100 * time.Microsecond)
+						return nil
+					})
+				}
+			})
+		})
+	}
+}
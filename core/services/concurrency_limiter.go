@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/concurrency"
+)
+
+// minGradient bounds how much a single gradient update shrinks the limit
+const minGradient = 0.5
+
+// concurrencyLimits publishes the current concurrency limit of each processor with expvar
+var concurrencyLimits = expvar.NewMap("concurrency_limit")
+
+// ConcurrencyLimiter caps the number of calls in flight
+type ConcurrencyLimiter interface {
+	// WithRateLimit executes fn once a slot is available
+	WithRateLimit(ctx context.Context, fn func() error) error
+	// Limit returns the number of calls currently allowed in flight
+	Limit() int
+	// InFlight returns the number of calls currently in flight
+	InFlight() int
+}
+
+// limitSample is the measured outcome of a call. Dropped calls are the ones
+// that signal overload, such as timeouts, server errors and rate limits.
+type limitSample struct {
+	latency  time.Duration
+	inFlight int
+	dropped  bool
+}
+
+// limitAlgorithm computes the next limit from the outcome of each call
+type limitAlgorithm interface {
+	update(limit float64, sample limitSample) float64
+}
+
+// aimdAlgorithm grows the limit by one per successful call and shrinks it
+// by a ratio on every dropped or slow call
+type aimdAlgorithm struct {
+	backoffRatio     float64
+	latencyThreshold time.Duration
+}
+
+func (a *aimdAlgorithm) update(limit float64, sample limitSample) float64 {
+	if sample.dropped || (a.latencyThreshold > 0 && sample.latency > a.latencyThreshold) {
+		return limit * a.backoffRatio
+	}
+
+	// Only grow a limit that is actually in use
+	if float64(sample.inFlight)*2 >= limit {
+		return limit + 1
+	}
+
+	return limit
+}
+
+// gradientAlgorithm compares each call latency with a baseline averaged
+// over many calls. While latency stays within the tolerance, the limit grows
+// by its square root, leaving room for a small queue; as latency rises, the
+// limit shrinks in proportion.
+type gradientAlgorithm struct {
+	tolerance float64
+	smoothing float64
+	window    int
+	baseline  float64
+	samples   int
+}
+
+func (g *gradientAlgorithm) update(limit float64, sample limitSample) float64 {
+	gradient := minGradient
+
+	if !sample.dropped {
+		latency := float64(sample.latency)
+
+		if g.samples < g.window {
+			g.samples++
+		}
+		g.baseline += (latency - g.baseline) / float64(g.samples)
+
+		// Let the baseline follow a lasting drop in latency
+		if g.baseline > 2*latency {
+			g.baseline *= 0.95
+		}
+
+		gradient = math.Max(minGradient, math.Min(1, g.tolerance*g.baseline/math.Max(latency, 1)))
+	}
+
+	next := limit*gradient + math.Sqrt(limit)
+
+	// Only grow a limit that is actually in use
+	if next > limit && float64(sample.inFlight)*2 < limit {
+		return limit
+	}
+
+	return limit*(1-g.smoothing) + next*g.smoothing
+}
+
+// AdaptiveLimiter is a concurrency limiter whose limit follows the latency
+// and errors of the calls it admits. Calls wait in arrival order for a slot.
+type AdaptiveLimiter struct {
+	algorithm limitAlgorithm
+	minLimit  int
+	maxLimit  int
+	limit     float64
+	inFlight  int
+	waiters   []chan struct{}
+	mutex     sync.Mutex
+}
+
+// NewAdaptiveLimiter creates a limiter using the AIMD or gradient algorithm
+// of the configuration
+func NewAdaptiveLimiter(cfg *concurrency.Config) *AdaptiveLimiter {
+	var algorithm limitAlgorithm = &aimdAlgorithm{
+		backoffRatio:     cfg.BackoffRatio,
+		latencyThreshold: cfg.LatencyThreshold,
+	}
+
+	if cfg.Mode == concurrency.ModeGradient {
+		algorithm = &gradientAlgorithm{
+			tolerance: cfg.Tolerance,
+			smoothing: cfg.Smoothing,
+			window:    cfg.BaselineWindow,
+		}
+	}
+
+	return &AdaptiveLimiter{
+		algorithm: algorithm,
+		minLimit:  cfg.MinLimit,
+		maxLimit:  cfg.MaxLimit,
+		limit:     float64(cfg.InitialLimit),
+	}
+}
+
+// WithRateLimit executes fn once a slot is available and adjusts the limit
+// with its latency and outcome
+func (l *AdaptiveLimiter) WithRateLimit(ctx context.Context, fn func() error) error {
+	if err := l.acquire(ctx); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := fn()
+	l.release(classifyLimitSample(ctx, err), time.Since(start))
+
+	return err
+}
+
+// Limit returns the number of calls currently allowed in flight
+func (l *AdaptiveLimiter) Limit() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.currentLimit()
+}
+
+// InFlight returns the number of calls currently in flight
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.inFlight
+}
+
+// acquire takes a slot, waiting behind the earlier callers when none is free
+func (l *AdaptiveLimiter) acquire(ctx context.Context) error {
+	l.mutex.Lock()
+	if l.inFlight < l.currentLimit() && len(l.waiters) == 0 {
+		l.inFlight++
+		l.mutex.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.mutex.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i, waiter := range l.waiters {
+		if waiter == ready {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+
+	// The slot was handed over while giving up; pass it on
+	l.inFlight--
+	l.admitWaiters()
+
+	return ctx.Err()
+}
+
+// release frees a slot, updates the limit with the call outcome, if it
+// says anything about the processor, and admits the waiting callers
+func (l *AdaptiveLimiter) release(outcome callOutcome, latency time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if outcome != outcomeNeutral {
+		limit := l.algorithm.update(l.limit, limitSample{
+			latency:  latency,
+			inFlight: l.inFlight,
+			dropped:  outcome == outcomeFailure,
+		})
+		l.limit = math.Max(float64(l.minLimit), math.Min(float64(l.maxLimit), limit))
+	}
+
+	l.inFlight--
+	l.admitWaiters()
+}
+
+// admitWaiters hands the free slots to the waiting callers in order
+func (l *AdaptiveLimiter) admitWaiters() {
+	for len(l.waiters) > 0 && l.inFlight < l.currentLimit() {
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+		l.inFlight++
+	}
+}
+
+// currentLimit returns the whole number of calls allowed in flight
+func (l *AdaptiveLimiter) currentLimit() int {
+	return int(l.limit)
+}
+
+// classifyLimitSample decides how the result of a call affects the limit.
+// Unlike the circuit breaker, rate limits signal overload, while calls that
+// never reached the processor say nothing about its latency.
+func classifyLimitSample(ctx context.Context, err error) callOutcome {
+	switch {
+	case err == nil, errors.Is(err, core.ErrPaymentDuplicate):
+		return outcomeSuccess
+	case errors.Is(ctx.Err(), context.Canceled):
+		return outcomeNeutral
+	case errors.Is(err, core.ErrProcessorRateLimited),
+		errors.Is(err, core.ErrProcessorTimeout),
+		errors.Is(err, core.ErrProcessorServerError),
+		errors.Is(err, core.ErrProcessorUnavailable),
+		errors.Is(err, context.DeadlineExceeded):
+		return outcomeFailure
+	default:
+		return outcomeNeutral
+	}
+}
+
+// publishConcurrencyLimit publishes the live concurrency limit of a route
+func publishConcurrencyLimit(route *processorRoute, mode string) {
+	concurrencyLimits.Set(route.processor.ProcessorName(), expvar.Func(func() any {
+		return routeConcurrencyLimit(route, mode)
+	}))
+}
+
+// routeConcurrencyLimit returns the concurrency limit of a route
+func routeConcurrencyLimit(route *processorRoute, mode string) domain.ConcurrencyLimit {
+	return domain.ConcurrencyLimit{
+		Processor: route.processor.ProcessorName(),
+		Mode:      mode,
+		Limit:     route.limiter.Limit(),
+		InFlight:  route.limiter.InFlight(),
+	}
+}
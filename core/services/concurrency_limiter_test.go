@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/internal/app/concurrency"
+)
+
+func newTestConcurrencyConfig(mode string) *concurrency.Config {
+	return &concurrency.Config{
+		Mode:           mode,
+		InitialLimit:   10,
+		MinLimit:       1,
+		MaxLimit:       100,
+		BackoffRatio:   0.5,
+		Tolerance:      1.5,
+		Smoothing:      1,
+		BaselineWindow: 100,
+	}
+}
+
+// runBusy releases calls of the given latency while the limit is in use
+func runBusy(l *AdaptiveLimiter, calls int, latency time.Duration, outcome callOutcome) {
+	for range calls {
+		l.mutex.Lock()
+		l.inFlight = l.currentLimit()
+		l.mutex.Unlock()
+
+		l.release(outcome, latency)
+	}
+}
+
+func TestAdaptiveLimiter_AIMD(t *testing.T) {
+	t.Run("Grows while calls succeed", func(t *testing.T) {
+		l := NewAdaptiveLimiter(newTestConcurrencyConfig(concurrency.ModeAIMD))
+
+		runBusy(l, 5, 10*time.Millisecond, outcomeSuccess)
+
+		if l.Limit() != 15 {
+			t.Errorf("Expected limit 15, got: %d", l.Limit())
+		}
+	})
+
+	t.Run("Backs off on overload", func(t *testing.T) {
+		l := NewAdaptiveLimiter(newTestConcurrencyConfig(concurrency.ModeAIMD))
+
+		runBusy(l, 1, 10*time.Millisecond, outcomeFailure)
+
+		if l.Limit() != 5 {
+			t.Errorf("Expected limit 5, got: %d", l.Limit())
+		}
+	})
+
+	t.Run("Stays within bounds", func(t *testing.T) {
+		l := NewAdaptiveLimiter(newTestConcurrencyConfig(concurrency.ModeAIMD))
+
+		runBusy(l, 20, 10*time.Millisecond, outcomeFailure)
+		if l.Limit() != 1 {
+			t.Errorf("Expected min limit 1, got: %d", l.Limit())
+		}
+
+		runBusy(l, 200, 10*time.Millisecond, outcomeSuccess)
+		if l.Limit() != 100 {
+			t.Errorf("Expected max limit 100, got: %d", l.Limit())
+		}
+	})
+
+	t.Run("Does not grow an unused limit", func(t *testing.T) {
+		l := NewAdaptiveLimiter(newTestConcurrencyConfig(concurrency.ModeAIMD))
+
+		for range 5 {
+			l.mutex.Lock()
+			l.inFlight = 1
+			l.mutex.Unlock()
+			l.release(outcomeSuccess, 10*time.Millisecond)
+		}
+
+		if l.Limit() != 10 {
+			t.Errorf("Expected limit 10, got: %d", l.Limit())
+		}
+	})
+
+	t.Run("Backs off on slow calls", func(t *testing.T) {
+		cfg := newTestConcurrencyConfig(concurrency.ModeAIMD)
+		cfg.LatencyThreshold = 100 * time.Millisecond
+		l := NewAdaptiveLimiter(cfg)
+
+		runBusy(l, 1, 200*time.Millisecond, outcomeSuccess)
+
+		if l.Limit() != 5 {
+			t.Errorf("Expected limit 5, got: %d", l.Limit())
+		}
+	})
+}
+
+func TestAdaptiveLimiter_Gradient(t *testing.T) {
+	l := NewAdaptiveLimiter(newTestConcurrencyConfig(concurrency.ModeGradient))
+
+	runBusy(l, 20, 10*time.Millisecond, outcomeSuccess)
+	grown := l.Limit()
+	if grown <= 10 {
+		t.Fatalf("Expected the limit to grow at a stable latency, got: %d", grown)
+	}
+
+	runBusy(l, 5, 100*time.Millisecond, outcomeSuccess)
+	if l.Limit() >= grown {
+		t.Errorf("Expected the limit to shrink when latency rises, got: %d (was %d)", l.Limit(), grown)
+	}
+}
+
+func TestAdaptiveLimiter_WithRateLimit(t *testing.T) {
+	t.Run("Waits for a free slot", func(t *testing.T) {
+		cfg := newTestConcurrencyConfig(concurrency.ModeAIMD)
+		cfg.InitialLimit = 1
+		l := NewAdaptiveLimiter(cfg)
+
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		go l.WithRateLimit(context.Background(), func() error {
+			close(started)
+			<-finish
+			return nil
+		})
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := l.WithRateLimit(ctx, func() error { return nil })
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected deadline exceeded while the slot is taken, got: %v", err)
+		}
+
+		close(finish)
+
+		if err := l.WithRateLimit(context.Background(), func() error { return nil }); err != nil {
+			t.Fatalf("Expected no error once the slot is free, got: %v", err)
+		}
+		if l.InFlight() != 0 {
+			t.Errorf("Expected no call in flight, got: %d", l.InFlight())
+		}
+	})
+
+	t.Run("Ignores calls that never reached the processor", func(t *testing.T) {
+		l := NewAdaptiveLimiter(newTestConcurrencyConfig(concurrency.ModeAIMD))
+
+		l.WithRateLimit(context.Background(), func() error { return core.ErrCircuitBreakerOpen })
+		l.WithRateLimit(context.Background(), func() error {
+			return &core.ProcessorError{Kind: core.ErrProcessorClientError, Processor: "test"}
+		})
+
+		if l.Limit() != 10 {
+			t.Errorf("Expected limit 10, got: %d", l.Limit())
+		}
+	})
+
+	t.Run("Treats rate limits as overload", func(t *testing.T) {
+		l := NewAdaptiveLimiter(newTestConcurrencyConfig(concurrency.ModeAIMD))
+
+		l.WithRateLimit(context.Background(), func() error {
+			return &core.ProcessorError{Kind: core.ErrProcessorRateLimited, Processor: "test"}
+		})
+
+		if l.Limit() != 5 {
+			t.Errorf("Expected limit 5, got: %d", l.Limit())
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/google/uuid"
+)
+
+// replayBatchSize is the number of dead letters read at a time when replaying all
+const replayBatchSize = 100
+
+// DeadLetterQueue inspects, replays and discards the queued payments that ran
+// out of attempts. Replayed payments go through process, the normal payment
+// processing path, and leave the dead letters once processed.
+type DeadLetterQueue struct {
+	repo    repository.DeadLetterRepository
+	process func(ctx context.Context, payment *domain.Payment) error
+}
+
+// NewDeadLetterQueue creates a new dead letter queue
+func NewDeadLetterQueue(repo repository.DeadLetterRepository, process func(ctx context.Context, payment *domain.Payment) error) *DeadLetterQueue {
+	return &DeadLetterQueue{repo: repo, process: process}
+}
+
+// DeadLetters returns up to limit dead letters after skipping offset, oldest failure first
+func (q *DeadLetterQueue) DeadLetters(ctx context.Context, offset, limit int) ([]domain.DeadLetter, error) {
+	return q.repo.List(ctx, offset, limit)
+}
+
+// DeadLetter returns a dead letter
+func (q *DeadLetterQueue) DeadLetter(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error) {
+	return q.repo.Get(ctx, id)
+}
+
+// Replay processes a dead-lettered payment again. It stays dead-lettered if
+// processing fails.
+func (q *DeadLetterQueue) Replay(ctx context.Context, id uuid.UUID) error {
+	letter, err := q.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return q.replay(ctx, *letter)
+}
+
+// ReplayAll processes every dead-lettered payment again
+func (q *DeadLetterQueue) ReplayAll(ctx context.Context) (*domain.DeadLetterReplay, error) {
+	result := &domain.DeadLetterReplay{Failed: make(map[uuid.UUID]string)}
+
+	// Replayed letters are removed, so the failed ones are skipped to page
+	for {
+		letters, err := q.repo.List(ctx, len(result.Failed), replayBatchSize)
+		if err != nil {
+			return result, err
+		}
+
+		if len(letters) == 0 {
+			return result, nil
+		}
+
+		for _, letter := range letters {
+			if err := q.replay(ctx, letter); err != nil {
+				result.Failed[letter.ID] = err.Error()
+				continue
+			}
+			result.Replayed++
+		}
+
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+	}
+}
+
+// Discard removes a dead letter without processing it
+func (q *DeadLetterQueue) Discard(ctx context.Context, id uuid.UUID) error {
+	return q.repo.Delete(ctx, id)
+}
+
+// DiscardAll removes every dead letter and returns how many were removed
+func (q *DeadLetterQueue) DiscardAll(ctx context.Context) (int64, error) {
+	return q.repo.Purge(ctx)
+}
+
+// replay processes a dead letter's payment and removes the dead letter once processed
+func (q *DeadLetterQueue) replay(ctx context.Context, letter domain.DeadLetter) error {
+	if err := q.process(ctx, &letter.Payment); err != nil {
+		return err
+	}
+
+	if err := q.repo.Delete(ctx, letter.ID); err != nil {
+		return fmt.Errorf("payment processed but dead letter not removed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/google/uuid"
+)
+
+// memoryDeadLetterRepository is an in-memory DeadLetterRepository for tests
+type memoryDeadLetterRepository struct {
+	letters []domain.DeadLetter
+	mutex   sync.Mutex
+}
+
+func (r *memoryDeadLetterRepository) Add(ctx context.Context, letter domain.DeadLetter) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.letters = append(r.letters, letter)
+	return nil
+}
+
+func (r *memoryDeadLetterRepository) List(ctx context.Context, offset, limit int) ([]domain.DeadLetter, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return slices.Clone(r.letters[min(offset, len(r.letters)):min(offset+limit, len(r.letters))]), nil
+}
+
+func (r *memoryDeadLetterRepository) Get(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, letter := range r.letters {
+		if letter.ID == id {
+			return &letter, nil
+		}
+	}
+	return nil, core.ErrDeadLetterNotFound
+}
+
+func (r *memoryDeadLetterRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, letter := range r.letters {
+		if letter.ID == id {
+			r.letters = slices.Delete(r.letters, i, i+1)
+			return nil
+		}
+	}
+	return core.ErrDeadLetterNotFound
+}
+
+func (r *memoryDeadLetterRepository) Purge(ctx context.Context) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged := int64(len(r.letters))
+	r.letters = nil
+	return purged, nil
+}
+
+// newTestDeadLetters stores count dead letters
+func newTestDeadLetters(count int) *memoryDeadLetterRepository {
+	repo := &memoryDeadLetterRepository{}
+	for range count {
+		repo.letters = append(repo.letters, domain.DeadLetter{
+			ID:       uuid.New(),
+			Payment:  *newTestPayment(),
+			Attempts: []domain.JobAttempt{{Attempt: 1, Error: "processor unavailable", At: time.Now()}},
+			FailedAt: time.Now(),
+		})
+	}
+	return repo
+}
+
+func TestDeadLetterQueue(t *testing.T) {
+	t.Run("Replays a dead letter through the processing path", func(t *testing.T) {
+		repo := newTestDeadLetters(1)
+		letter := repo.letters[0]
+
+		var processed []uuid.UUID
+		q := NewDeadLetterQueue(repo, func(ctx context.Context, payment *domain.Payment) error {
+			processed = append(processed, payment.CorrelationID)
+			return nil
+		})
+
+		if err := q.Replay(context.Background(), letter.ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(processed) != 1 || processed[0] != letter.Payment.CorrelationID {
+			t.Errorf("Expected the payment to be processed, got: %v", processed)
+		}
+		if _, err := q.DeadLetter(context.Background(), letter.ID); !errors.Is(err, core.ErrDeadLetterNotFound) {
+			t.Errorf("Expected the dead letter to be removed, got: %v", err)
+		}
+	})
+
+	t.Run("Keeps a dead letter that fails again", func(t *testing.T) {
+		repo := newTestDeadLetters(1)
+		q := NewDeadLetterQueue(repo, func(ctx context.Context, payment *domain.Payment) error {
+			return core.ErrProcessorUnavailable
+		})
+
+		if err := q.Replay(context.Background(), repo.letters[0].ID); !errors.Is(err, core.ErrProcessorUnavailable) {
+			t.Errorf("Expected unavailable error, got: %v", err)
+		}
+		if len(repo.letters) != 1 {
+			t.Errorf("Expected the dead letter to stay, got: %d", len(repo.letters))
+		}
+	})
+
+	t.Run("Replays every dead letter past the ones that fail again", func(t *testing.T) {
+		repo := newTestDeadLetters(replayBatchSize + 50)
+		failing := make(map[uuid.UUID]bool)
+		for i := 0; i < len(repo.letters); i += 3 {
+			failing[repo.letters[i].Payment.CorrelationID] = true
+		}
+
+		q := NewDeadLetterQueue(repo, func(ctx context.Context, payment *domain.Payment) error {
+			if failing[payment.CorrelationID] {
+				return core.ErrProcessorUnavailable
+			}
+			return nil
+		})
+
+		result, err := q.ReplayAll(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(result.Failed) != len(failing) {
+			t.Errorf("Expected %d failed replays, got: %d", len(failing), len(result.Failed))
+		}
+		if result.Replayed != replayBatchSize+50-len(failing) {
+			t.Errorf("Expected %d replayed, got: %d", replayBatchSize+50-len(failing), result.Replayed)
+		}
+		if len(repo.letters) != len(failing) {
+			t.Errorf("Expected only the failed dead letters to stay, got: %d", len(repo.letters))
+		}
+	})
+
+	t.Run("Discards dead letters", func(t *testing.T) {
+		repo := newTestDeadLetters(3)
+		q := NewDeadLetterQueue(repo, func(ctx context.Context, payment *domain.Payment) error {
+			t.Error("Expected no payment to be processed")
+			return nil
+		})
+
+		if err := q.Discard(context.Background(), repo.letters[0].ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if err := q.Discard(context.Background(), uuid.New()); !errors.Is(err, core.ErrDeadLetterNotFound) {
+			t.Errorf("Expected not found error, got: %v", err)
+		}
+
+		discarded, err := q.DiscardAll(context.Background())
+		if err != nil || discarded != 2 {
+			t.Errorf("Expected 2 discarded, got: %d, %v", discarded, err)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/health"
+)
+
+// HealthMonitor polls the processors' health endpoints in the background and
+// keeps the latest known status of each one
+type HealthMonitor struct {
+	checkers  []domain.ProcessorHealthChecker
+	statuses  map[string]domain.ProcessorHealth
+	config    *health.Config
+	pollGate  func() bool
+	listeners []func(processorName string, status domain.ProcessorHealth)
+	mutex     sync.RWMutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewHealthMonitor creates a new health monitor for the given processors
+func NewHealthMonitor(cfg *health.Config, checkers ...domain.ProcessorHealthChecker) *HealthMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &HealthMonitor{
+		checkers: checkers,
+		statuses: make(map[string]domain.ProcessorHealth),
+		config:   cfg,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// SetPollGate sets a function deciding, before each round, whether this
+// instance polls the processors. It must be called before Start.
+func (m *HealthMonitor) SetPollGate(gate func() bool) {
+	m.pollGate = gate
+}
+
+// OnStatus registers a listener for the statuses this instance polls. It
+// must be called before Start.
+func (m *HealthMonitor) OnStatus(listener func(processorName string, status domain.ProcessorHealth)) {
+	m.listeners = append(m.listeners, listener)
+}
+
+// UpdateStatus stores a status obtained elsewhere, such as by another
+// instance, unless a more recent one is already known
+func (m *HealthMonitor) UpdateStatus(processorName string, status domain.ProcessorHealth) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if current, ok := m.statuses[processorName]; ok && !status.CheckedAt.After(current.CheckedAt) {
+		return
+	}
+
+	m.statuses[processorName] = status
+}
+
+// Start launches one polling goroutine per processor
+func (m *HealthMonitor) Start() {
+	for _, checker := range m.checkers {
+		m.wg.Add(1)
+		go m.poll(checker)
+	}
+}
+
+// Stop stops all polling goroutines, aborting in-flight checks, and waits for them to finish
+func (m *HealthMonitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Status returns the latest health status of a processor. The second return
+// value is false when there is no status or when it is older than the allowed
+// staleness, in which case the caller should not rely on it.
+func (m *HealthMonitor) Status(processorName string) (domain.ProcessorHealth, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	status, ok := m.statuses[processorName]
+	if !ok || time.Since(status.CheckedAt) > m.config.MaxStaleness {
+		return domain.ProcessorHealth{}, false
+	}
+
+	return status, true
+}
+
+// IsFailing reports whether a processor is known to be failing
+func (m *HealthMonitor) IsFailing(processorName string) bool {
+	status, ok := m.Status(processorName)
+	return ok && status.Failing
+}
+
+// poll checks a processor right away and then once per configured interval
+func (m *HealthMonitor) poll(checker domain.ProcessorHealthChecker) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if m.pollGate == nil || m.pollGate() {
+			m.check(checker)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// check performs a single health check and stores its result
+func (m *HealthMonitor) check(checker domain.ProcessorHealthChecker) {
+	ctx, cancel := context.WithTimeout(m.ctx, m.config.CheckTimeout)
+	defer cancel()
+
+	status, err := checker.CheckHealth(ctx)
+	if err != nil {
+		// Keep the previous status; it expires on its own once it becomes stale
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Health check for processor %s failed: %v", checker.ProcessorName(), err)
+		}
+		return
+	}
+
+	m.mutex.Lock()
+	m.statuses[checker.ProcessorName()] = *status
+	m.mutex.Unlock()
+
+	for _, listener := range m.listeners {
+		listener(checker.ProcessorName(), *status)
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/adapters/outbound/gateway"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/health"
+	"github.com/fabianoflorentino/mr-robot/internal/fakeprocessor"
+)
+
+// countingHealthChecker reports a fixed status and signals every check
+type countingHealthChecker struct {
+	name    string
+	calls   atomic.Int32
+	checked chan struct{}
+}
+
+func (c *countingHealthChecker) CheckHealth(ctx context.Context) (*domain.ProcessorHealth, error) {
+	c.calls.Add(1)
+	select {
+	case c.checked <- struct{}{}:
+	default:
+	}
+	return &domain.ProcessorHealth{CheckedAt: time.Now()}, nil
+}
+
+func (c *countingHealthChecker) ProcessorName() string {
+	return c.name
+}
+
+// newTestHealthChecker serves a fake processor and returns a gateway checking its health
+func newTestHealthChecker(t *testing.T, processor *fakeprocessor.Processor) *gateway.ProcessGateway {
+	t.Helper()
+
+	server := httptest.NewServer(processor)
+	t.Cleanup(server.Close)
+
+	return gateway.NewProcessorFactory().CreateProcessor(gateway.DefaultProcessor, gateway.ProcessorConfig{
+		URL:        server.URL + "/payments",
+		Timeout:    time.Second,
+		HTTPClient: server.Client(),
+	})
+}
+
+func newTestHealthConfig(interval time.Duration) *health.Config {
+	return &health.Config{
+		Enabled:       true,
+		CheckInterval: interval,
+		CheckTimeout:  time.Second,
+		MaxStaleness:  time.Minute,
+	}
+}
+
+func TestHealthMonitor(t *testing.T) {
+	t.Run("Checks right away and then once per interval", func(t *testing.T) {
+		checker := &countingHealthChecker{name: "default", checked: make(chan struct{}, 1)}
+		monitor := NewHealthMonitor(newTestHealthConfig(time.Hour), checker)
+		monitor.Start()
+
+		select {
+		case <-checker.checked:
+		case <-time.After(time.Second):
+			t.Fatal("Expected a check on start")
+		}
+		monitor.Stop()
+
+		if calls := checker.calls.Load(); calls != 1 {
+			t.Errorf("Expected a single check before the interval elapsed, got: %d", calls)
+		}
+		if _, ok := monitor.Status("default"); !ok {
+			t.Error("Expected the status of the first check")
+		}
+	})
+
+	t.Run("Keeps the last status when the processor rate limits the check", func(t *testing.T) {
+		processor := fakeprocessor.New(fakeprocessor.Options{HealthInterval: time.Hour})
+		processor.SetBehavior(fakeprocessor.Behavior{MinResponseTime: 40})
+		monitor := NewHealthMonitor(newTestHealthConfig(time.Hour), newTestHealthChecker(t, processor))
+
+		monitor.check(monitor.checkers[0])
+		first, ok := monitor.Status("default")
+		if !ok || first.MinResponseTime != 40 {
+			t.Fatalf("Expected the reported status, got: %+v (%v)", first, ok)
+		}
+
+		// The processor allows one call per interval and answers 429 otherwise
+		processor.SetBehavior(fakeprocessor.Behavior{Failing: true})
+		monitor.check(monitor.checkers[0])
+
+		if status, _ := monitor.Status("default"); status != first {
+			t.Errorf("Expected the status to be kept, got: %+v", status)
+		}
+	})
+
+	t.Run("Reports a failing default so the payment goes to the fallback", func(t *testing.T) {
+		defaultProcessor := fakeprocessor.New(fakeprocessor.Options{})
+		defaultProcessor.SetBehavior(fakeprocessor.Behavior{Failing: true})
+		defaultRegistration := newTestRegistration(t, "default", 0, 0.05, defaultProcessor)
+
+		monitor := NewHealthMonitor(newTestHealthConfig(time.Hour), defaultRegistration.Processor.(domain.ProcessorHealthChecker))
+		monitor.check(monitor.checkers[0])
+		if !monitor.IsFailing("default") {
+			t.Fatal("Expected the default processor to be failing")
+		}
+
+		s := paymentServiceFixture{processors: []testProcessor{
+			{name: "default", priority: 0, fee: 0.05, processor: defaultRegistration.Processor},
+			testFallback(fakeprocessor.Behavior{}),
+		}}.start(t)
+		s.SetHealthMonitor(monitor)
+
+		p := newTestPayment()
+		if err := s.Process(context.Background(), p); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if name, _ := s.repo.processorFor(p.CorrelationID); name != "fallback" {
+			t.Errorf("Expected payment recorded for fallback, got: %q", name)
+		}
+		if calls := defaultProcessor.Calls(); calls != 0 {
+			t.Errorf("Expected the failing default to be skipped, got: %d calls", calls)
+		}
+	})
+
+	t.Run("Ignores statuses older than the known one", func(t *testing.T) {
+		monitor := NewHealthMonitor(newTestHealthConfig(time.Hour))
+		now := time.Now()
+
+		monitor.UpdateStatus("default", domain.ProcessorHealth{Failing: true, CheckedAt: now})
+		monitor.UpdateStatus("default", domain.ProcessorHealth{Failing: false, CheckedAt: now.Add(-time.Second)})
+		monitor.UpdateStatus("default", domain.ProcessorHealth{Failing: false, CheckedAt: now})
+
+		if !monitor.IsFailing("default") {
+			t.Error("Expected the older and equally old statuses to be ignored")
+		}
+
+		monitor.UpdateStatus("default", domain.ProcessorHealth{Failing: false, CheckedAt: now.Add(time.Second)})
+		if monitor.IsFailing("default") {
+			t.Error("Expected the newer status to replace the known one")
+		}
+	})
+
+	t.Run("Ignores stale statuses", func(t *testing.T) {
+		monitor := NewHealthMonitor(newTestHealthConfig(time.Hour))
+		monitor.UpdateStatus("default", domain.ProcessorHealth{Failing: true, CheckedAt: time.Now().Add(-2 * time.Minute)})
+
+		if _, ok := monitor.Status("default"); ok {
+			t.Error("Expected a status older than the max staleness to be ignored")
+		}
+	})
+}
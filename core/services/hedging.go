@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/hedging"
+)
+
+// hedgingStats counts the hedges sent, the ones that won and the payments
+// accepted by two processors, published with expvar
+var hedgingStats = expvar.NewMap("hedging")
+
+// latencyTracker keeps the most recent latencies of a processor's successful calls
+type latencyTracker struct {
+	samples []time.Duration
+	next    int
+	count   int
+	mutex   sync.Mutex
+}
+
+func newLatencyTracker(window int) *latencyTracker {
+	return &latencyTracker{samples: make([]time.Duration, window)}
+}
+
+// record adds a latency, replacing the oldest one once the window is full
+func (t *latencyTracker) record(latency time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.samples[t.next] = latency
+	t.next = (t.next + 1) % len(t.samples)
+	t.count = min(t.count+1, len(t.samples))
+}
+
+// percentile returns the given percentile of the recorded latencies and the
+// number of latencies it was computed from
+func (t *latencyTracker) percentile(p float64) (time.Duration, int) {
+	t.mutex.Lock()
+	samples := slices.Clone(t.samples[:t.count])
+	t.mutex.Unlock()
+
+	if len(samples) == 0 {
+		return 0, 0
+	}
+
+	slices.Sort(samples)
+	return samples[int(p*float64(len(samples)-1))], len(samples)
+}
+
+// hedgeAttempt is the outcome of one attempt of a hedged call
+type hedgeAttempt struct {
+	route *processorRoute
+	err   error
+	hedge bool
+}
+
+// accepted reports whether the processor accepted the payment
+func (a hedgeAttempt) accepted() bool {
+	return a.err == nil || errors.Is(a.err, core.ErrPaymentDuplicate)
+}
+
+// enableHedging enables request hedging: when the preferred processor takes
+// longer than the configured percentile of its recent latencies, a second
+// attempt is sent and the first to succeed wins
+func (s *PaymentService) enableHedging(cfg *hedging.Config) {
+	s.hedging = cfg
+
+	for _, route := range s.routingPolicy.routes {
+		route.latencies = newLatencyTracker(cfg.Window)
+	}
+}
+
+// hedgeDelay returns how long to wait for a route before hedging
+func (s *PaymentService) hedgeDelay(route *processorRoute) time.Duration {
+	latency, samples := route.latencies.percentile(s.hedging.Percentile)
+	if samples < s.hedging.MinSamples {
+		return s.hedging.MaxDelay
+	}
+
+	return max(s.hedging.MinDelay, min(s.hedging.MaxDelay, latency))
+}
+
+// hedgeRoute returns the route a hedge is sent to. The next processor is only
+// used when allowed and when the reconciler can check that it did not accept
+// the payment along with the winner.
+func (s *PaymentService) hedgeRoute(routes []*processorRoute) *processorRoute {
+	if s.hedging.AllowFallback && s.reconciler != nil && len(routes) > 1 {
+		return routes[1]
+	}
+
+	return routes[0]
+}
+
+// tryHedged attempts the first route, hedging it once the hedge delay
+// elapses, and marks the routes it used as attempted. The first attempt to be
+// accepted wins and the other one is cancelled. It returns the route that
+// accepted the payment or, when both attempts failed, the route whose error
+// the caller should handle.
+func (s *PaymentService) tryHedged(ctx context.Context, payment *domain.Payment, routes []*processorRoute, attempted map[*processorRoute]bool) (*processorRoute, error) {
+	primary := routes[0]
+	attempted[primary] = true
+
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeAttempt, 2)
+	go s.attempt(attemptCtx, payment, primary, false, results)
+
+	timer := time.NewTimer(s.hedgeDelay(primary))
+	defer timer.Stop()
+
+	select {
+	case result := <-results:
+		return result.route, result.err
+	case <-timer.C:
+	}
+
+	hedge := s.hedgeRoute(routes)
+	attempted[hedge] = true
+	hedgingStats.Add("hedges", 1)
+	go s.attempt(attemptCtx, payment, hedge, true, results)
+
+	first := <-results
+	if first.accepted() {
+		s.recordHedgeWinner(first)
+
+		// The other attempt is cancelled on return, but another processor
+		// may already have accepted the payment
+		if hedge != primary {
+			go s.verifyHedgeLoser(context.WithoutCancel(ctx), payment, first.route, results)
+		}
+		return first.route, first.err
+	}
+
+	second := <-results
+	if second.accepted() {
+		s.recordHedgeWinner(second)
+
+		if hedge != primary && outcomeUnknown(first.err) {
+			go s.verifyHedgeLoser(context.WithoutCancel(ctx), payment, second.route, settled(first))
+		}
+		return second.route, second.err
+	}
+
+	if first.hedge {
+		first, second = second, first
+	}
+
+	return s.settleHedgeFailure(ctx, payment, first, second)
+}
+
+// settleHedgeFailure picks the failure the caller handles when both attempts
+// failed. When they went to different processors and the one not reported
+// may have charged the payment, it is checked first so the payment is
+// recorded for the processor that accepted it.
+func (s *PaymentService) settleHedgeFailure(ctx context.Context, payment *domain.Payment, primary, hedge hedgeAttempt) (*processorRoute, error) {
+	reported, other := primary, hedge
+
+	// Only the hedge may have charged the payment, so reconcile it instead
+	if outcomeUnknown(hedge.err) && !outcomeUnknown(primary.err) {
+		reported, other = hedge, primary
+	}
+
+	if other.route == reported.route || !outcomeUnknown(other.err) {
+		return reported.route, reported.err
+	}
+
+	processed, err := s.reconciler.Resolve(ctx, payment, other.route.processor.ProcessorName())
+	if err == nil && processed {
+		return other.route, nil
+	}
+
+	return reported.route, reported.err
+}
+
+// attempt runs one attempt of a hedged call and reports its outcome
+func (s *PaymentService) attempt(ctx context.Context, payment *domain.Payment, route *processorRoute, hedge bool, results chan<- hedgeAttempt) {
+	start := time.Now()
+	err := s.tryRoute(ctx, payment, route)
+
+	if err == nil {
+		route.latencies.record(time.Since(start))
+	}
+
+	results <- hedgeAttempt{route: route, err: err, hedge: hedge}
+}
+
+// recordHedgeWinner counts hedges that beat the primary attempt
+func (s *PaymentService) recordHedgeWinner(winner hedgeAttempt) {
+	if winner.hedge {
+		hedgingStats.Add("wins", 1)
+	}
+}
+
+// outcomeUnknown reports whether a failed attempt may still have been
+// accepted by the processor
+func outcomeUnknown(err error) bool {
+	return errors.Is(err, core.ErrProcessorTimeout) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// settled returns a channel holding an attempt that already finished
+func settled(result hedgeAttempt) <-chan hedgeAttempt {
+	results := make(chan hedgeAttempt, 1)
+	results <- result
+	return results
+}
+
+// verifyHedgeLoser waits for the attempt that lost to another processor and
+// checks whether that processor accepted the payment too. A double acceptance
+// is recorded for the loser as well, since both processors charged it, and
+// logged and counted.
+func (s *PaymentService) verifyHedgeLoser(ctx context.Context, payment *domain.Payment, winner *processorRoute, results <-chan hedgeAttempt) {
+	loser := <-results
+	loserName := loser.route.processor.ProcessorName()
+
+	accepted := loser.accepted()
+	if !accepted && outcomeUnknown(loser.err) {
+		processed, err := s.reconciler.Resolve(ctx, payment, loserName)
+		if err != nil {
+			log.Printf("Payment %s may also have been accepted by %s: %v", payment.CorrelationID, loserName, err)
+			return
+		}
+		accepted = processed
+	}
+
+	if !accepted {
+		return
+	}
+
+	if err := s.repo.Process(ctx, payment, loserName); err != nil {
+		log.Printf("Payment %s was accepted by both %s and %s; failed to record it for %s: %v",
+			payment.CorrelationID, winner.processor.ProcessorName(), loserName, loserName, err)
+		return
+	}
+
+	hedgingStats.Add("double_accepted", 1)
+	log.Printf("Payment %s was accepted by both %s and %s; recorded for both",
+		payment.CorrelationID, winner.processor.ProcessorName(), loserName)
+}
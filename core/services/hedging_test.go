@@ -0,0 +1,50 @@
+package services
+
+import (
+	"expvar"
+	"testing"
+	"time"
+)
+
+// hedgingCount returns one of the hedging counters
+func hedgingCount(key string) int64 {
+	if v, ok := hedgingStats.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestLatencyTracker(t *testing.T) {
+	t.Run("Computes the percentile of the recorded latencies", func(t *testing.T) {
+		tracker := newLatencyTracker(100)
+		for i := 1; i <= 100; i++ {
+			tracker.record(time.Duration(i) * time.Millisecond)
+		}
+
+		latency, samples := tracker.percentile(0.95)
+		if samples != 100 {
+			t.Errorf("Expected 100 samples, got: %d", samples)
+		}
+		if latency != 95*time.Millisecond {
+			t.Errorf("Expected p95 of 95ms, got: %v", latency)
+		}
+	})
+
+	t.Run("Keeps only the most recent latencies", func(t *testing.T) {
+		tracker := newLatencyTracker(3)
+		for _, latency := range []time.Duration{time.Second, time.Second, time.Second, time.Millisecond, time.Millisecond, time.Millisecond} {
+			tracker.record(latency)
+		}
+
+		latency, samples := tracker.percentile(0.99)
+		if samples != 3 || latency != time.Millisecond {
+			t.Errorf("Expected 3 samples of 1ms, got: %d samples, %v", samples, latency)
+		}
+	})
+
+	t.Run("Reports no samples when empty", func(t *testing.T) {
+		if _, samples := newLatencyTracker(10).percentile(0.5); samples != 0 {
+			t.Errorf("Expected no samples, got: %d", samples)
+		}
+	})
+}
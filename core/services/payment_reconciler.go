@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/fabianoflorentino/mr-robot/internal/app/reconciliation"
+)
+
+// PaymentReconciler settles payments whose processor call timed out. It asks
+// the original processor whether it recorded the payment before any failover
+// and, in the background, resolves the payments left in doubt.
+type PaymentReconciler struct {
+	repo     repository.PaymentRepository
+	lookups  map[string]domain.PaymentLookup
+	resubmit func(ctx context.Context, payment *domain.Payment) error
+	config   *reconciliation.Config
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewPaymentReconciler creates a new reconciler. Payments found not to be
+// recorded by their processor are handed to resubmit.
+func NewPaymentReconciler(
+	repo repository.PaymentRepository,
+	cfg *reconciliation.Config,
+	resubmit func(ctx context.Context, payment *domain.Payment) error,
+	lookups ...domain.PaymentLookup,
+) *PaymentReconciler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &PaymentReconciler{
+		repo:     repo,
+		lookups:  make(map[string]domain.PaymentLookup),
+		resubmit: resubmit,
+		config:   cfg,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	for _, lookup := range lookups {
+		r.lookups[lookup.ProcessorName()] = lookup
+	}
+
+	return r
+}
+
+// Resolve asks the processor whether it recorded the payment. It returns an
+// error when the outcome is still unknown.
+func (r *PaymentReconciler) Resolve(ctx context.Context, payment *domain.Payment, processorName string) (bool, error) {
+	lookup, ok := r.lookups[processorName]
+	if !ok {
+		return false, fmt.Errorf("processor %s does not support payment lookup", processorName)
+	}
+
+	// The caller's deadline has usually expired already, since the call timed out
+	lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.config.LookupTimeout)
+	defer cancel()
+
+	return lookup.LookupPayment(lookupCtx, payment.CorrelationID)
+}
+
+// Settle settles a payment left in doubt by an earlier attempt before it is
+// processed again, so a retry does not charge it at a second processor. It
+// reports whether the payment needs no further processing: its processor
+// recorded it, or the reconciler or another retry settled it first and
+// resubmits it. The payment stays in doubt, with a core.ErrPaymentInDoubt
+// error, while its processor cannot tell.
+func (r *PaymentReconciler) Settle(ctx context.Context, payment *domain.Payment) (bool, error) {
+	inDoubt, err := r.repo.FindInDoubt(ctx, payment.CorrelationID)
+	if err != nil || inDoubt == nil {
+		return false, err
+	}
+
+	processed, err := r.Resolve(ctx, payment, inDoubt.Processor)
+	if err != nil {
+		return false, fmt.Errorf("%w at %s: %v", core.ErrPaymentInDoubt, inDoubt.Processor, err)
+	}
+
+	settled, err := r.repo.ResolveInDoubt(ctx, payment.CorrelationID, processed)
+	if err != nil {
+		return false, err
+	}
+	if !settled {
+		log.Printf("Payment %s was settled elsewhere, not processing it again", payment.CorrelationID)
+		return true, nil
+	}
+
+	log.Printf("Payment %s settled at %s before processing it again, processed: %v", payment.CorrelationID, inDoubt.Processor, processed)
+	return processed, nil
+}
+
+// Start launches the background resolution of in-doubt payments
+func (r *PaymentReconciler) Start() {
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Stop stops the background resolution and waits for it to finish
+func (r *PaymentReconciler) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// run resolves in-doubt payments once per configured interval
+func (r *PaymentReconciler) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.reconcile(r.ctx)
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// reconcile resolves a batch of in-doubt payments. Payments marked less than
+// an interval ago are left for the next round, as their processor may still
+// be working on them.
+func (r *PaymentReconciler) reconcile(ctx context.Context) {
+	payments, err := r.repo.InDoubt(ctx, time.Now().Add(-r.config.Interval), r.config.BatchSize)
+	if err != nil {
+		log.Printf("Failed to load in-doubt payments: %v", err)
+		return
+	}
+
+	for _, p := range payments {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := r.reconcilePayment(ctx, p); err != nil {
+			log.Printf("Payment %s still in doubt at %s: %v", p.Payment.CorrelationID, p.Processor, err)
+		}
+	}
+}
+
+// reconcilePayment settles a single in-doubt payment
+func (r *PaymentReconciler) reconcilePayment(ctx context.Context, p domain.InDoubtPayment) error {
+	processed, err := r.Resolve(ctx, &p.Payment, p.Processor)
+	if err != nil {
+		return err
+	}
+
+	if processed {
+		log.Printf("Payment %s confirmed by %s", p.Payment.CorrelationID, p.Processor)
+		_, err := r.repo.ResolveInDoubt(ctx, p.Payment.CorrelationID, true)
+		return err
+	}
+
+	// The processor never recorded the payment, so it is safe to submit it
+	// again, unless a retry settled it meanwhile and resubmits it itself
+	settled, err := r.repo.ResolveInDoubt(ctx, p.Payment.CorrelationID, false)
+	if err != nil || !settled {
+		return err
+	}
+
+	log.Printf("Payment %s not found at %s, resubmitting", p.Payment.CorrelationID, p.Processor)
+	if err := r.resubmit(ctx, &p.Payment); err != nil {
+		// A resubmission that timed out again is already in doubt at its new processor
+		if errors.Is(err, core.ErrPaymentInDoubt) {
+			return err
+		}
+
+		// Keep the payment in doubt so it is not lost; the next round retries it
+		if markErr := r.repo.MarkInDoubt(ctx, &p.Payment, p.Processor); markErr != nil {
+			return fmt.Errorf("failed to resubmit payment: %v; failed to keep it in doubt: %w", err, markErr)
+		}
+		return fmt.Errorf("failed to resubmit payment: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/reconciliation"
+	"github.com/google/uuid"
+)
+
+// stubLookup answers every payment lookup with the same outcome. With a
+// barrier, each lookup waits for the others before answering.
+type stubLookup struct {
+	name    string
+	found   bool
+	err     error
+	barrier *sync.WaitGroup
+	calls   atomic.Int32
+}
+
+func (l *stubLookup) LookupPayment(ctx context.Context, correlationID uuid.UUID) (bool, error) {
+	l.calls.Add(1)
+	if l.barrier != nil {
+		l.barrier.Done()
+		l.barrier.Wait()
+	}
+	return l.found, l.err
+}
+
+func (l *stubLookup) ProcessorName() string {
+	return l.name
+}
+
+// newTestReconciler creates a reconciler resubmitting payments to resubmit,
+// counting the calls
+func newTestReconciler(repo *memoryRepository, lookup *stubLookup, resubmit func(ctx context.Context, payment *domain.Payment) error) (*PaymentReconciler, *atomic.Int32) {
+	var resubmitted atomic.Int32
+	reconciler := NewPaymentReconciler(repo,
+		&reconciliation.Config{Interval: time.Minute, LookupTimeout: time.Second, BatchSize: 10},
+		func(ctx context.Context, payment *domain.Payment) error {
+			resubmitted.Add(1)
+			return resubmit(ctx, payment)
+		},
+		lookup,
+	)
+	return reconciler, &resubmitted
+}
+
+// markInDoubtAt marks a payment in doubt at default as if it happened at the given time
+func markInDoubtAt(repo *memoryRepository, p *domain.Payment, markedAt time.Time) {
+	repo.MarkInDoubt(context.Background(), p, "default")
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.markedAt[p.CorrelationID] = markedAt
+}
+
+func TestPaymentReconciler_Resolve(t *testing.T) {
+	t.Run("Fails for a processor without lookup", func(t *testing.T) {
+		reconciler, _ := newTestReconciler(newMemoryRepository(), &stubLookup{name: "default"}, nil)
+
+		if _, err := reconciler.Resolve(context.Background(), newTestPayment(), "fallback"); err == nil {
+			t.Error("Expected an error for a processor without lookup")
+		}
+	})
+
+	t.Run("Looks up after the caller's deadline", func(t *testing.T) {
+		lookup := &stubLookup{name: "default", found: true}
+		reconciler, _ := newTestReconciler(newMemoryRepository(), lookup, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		processed, err := reconciler.Resolve(ctx, newTestPayment(), "default")
+		if err != nil || !processed {
+			t.Errorf("Expected the payment processed, got: %v, %v", processed, err)
+		}
+	})
+}
+
+func TestPaymentReconciler_Reconcile(t *testing.T) {
+	t.Run("Confirms a payment its processor recorded", func(t *testing.T) {
+		repo := newMemoryRepository()
+		reconciler, resubmitted := newTestReconciler(repo, &stubLookup{name: "default", found: true}, nil)
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now().Add(-time.Hour))
+		reconciler.reconcile(context.Background())
+
+		if name, _ := repo.processorFor(p.CorrelationID); name != "default" {
+			t.Errorf("Expected payment recorded for default, got: %q", name)
+		}
+		if repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to be settled")
+		}
+		if resubmitted.Load() != 0 {
+			t.Error("Expected no resubmission")
+		}
+	})
+
+	t.Run("Resubmits a payment its processor never recorded", func(t *testing.T) {
+		repo := newMemoryRepository()
+		reconciler, resubmitted := newTestReconciler(repo, &stubLookup{name: "default"}, func(ctx context.Context, payment *domain.Payment) error {
+			return repo.Process(ctx, payment, "fallback")
+		})
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now().Add(-time.Hour))
+		reconciler.reconcile(context.Background())
+
+		if resubmitted.Load() != 1 {
+			t.Errorf("Expected 1 resubmission, got: %d", resubmitted.Load())
+		}
+		if name, _ := repo.processorFor(p.CorrelationID); name != "fallback" {
+			t.Errorf("Expected payment recorded for fallback, got: %q", name)
+		}
+		if repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to be settled")
+		}
+	})
+
+	t.Run("Keeps the payment in doubt when the resubmission fails", func(t *testing.T) {
+		repo := newMemoryRepository()
+		reconciler, resubmitted := newTestReconciler(repo, &stubLookup{name: "default"}, func(ctx context.Context, payment *domain.Payment) error {
+			return core.ErrPaymentNotProcessed
+		})
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now().Add(-time.Hour))
+		reconciler.reconcile(context.Background())
+
+		if resubmitted.Load() != 1 {
+			t.Errorf("Expected 1 resubmission, got: %d", resubmitted.Load())
+		}
+		if !repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to stay in doubt")
+		}
+	})
+
+	t.Run("Keeps the payment in doubt while the lookup fails", func(t *testing.T) {
+		repo := newMemoryRepository()
+		reconciler, resubmitted := newTestReconciler(repo, &stubLookup{name: "default", err: core.ErrProcessorUnavailable}, nil)
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now().Add(-time.Hour))
+		reconciler.reconcile(context.Background())
+
+		if !repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to stay in doubt")
+		}
+		if resubmitted.Load() != 0 {
+			t.Error("Expected no resubmission")
+		}
+	})
+
+	t.Run("Leaves recently marked payments for the next round", func(t *testing.T) {
+		repo := newMemoryRepository()
+		lookup := &stubLookup{name: "default"}
+		reconciler, _ := newTestReconciler(repo, lookup, nil)
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now())
+		reconciler.reconcile(context.Background())
+
+		if lookup.calls.Load() != 0 {
+			t.Errorf("Expected no lookup of a payment marked less than an interval ago, got: %d", lookup.calls.Load())
+		}
+		if !repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to stay in doubt")
+		}
+	})
+}
+
+func TestPaymentReconciler_Settle(t *testing.T) {
+	t.Run("Ignores a payment that is not in doubt", func(t *testing.T) {
+		lookup := &stubLookup{name: "default"}
+		reconciler, _ := newTestReconciler(newMemoryRepository(), lookup, nil)
+
+		processed, err := reconciler.Settle(context.Background(), newTestPayment())
+		if err != nil || processed {
+			t.Errorf("Expected the payment not processed, got: %v, %v", processed, err)
+		}
+		if lookup.calls.Load() != 0 {
+			t.Error("Expected no lookup")
+		}
+	})
+
+	t.Run("Records a payment its processor recorded", func(t *testing.T) {
+		repo := newMemoryRepository()
+		reconciler, _ := newTestReconciler(repo, &stubLookup{name: "default", found: true}, nil)
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now())
+
+		processed, err := reconciler.Settle(context.Background(), p)
+		if err != nil || !processed {
+			t.Fatalf("Expected the payment processed, got: %v, %v", processed, err)
+		}
+		if name, _ := repo.processorFor(p.CorrelationID); name != "default" {
+			t.Errorf("Expected payment recorded for default, got: %q", name)
+		}
+	})
+
+	t.Run("Releases a payment its processor never recorded", func(t *testing.T) {
+		repo := newMemoryRepository()
+		reconciler, _ := newTestReconciler(repo, &stubLookup{name: "default"}, nil)
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now())
+
+		processed, err := reconciler.Settle(context.Background(), p)
+		if err != nil || processed {
+			t.Fatalf("Expected the payment not processed, got: %v, %v", processed, err)
+		}
+		if repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to be released")
+		}
+	})
+
+	t.Run("Keeps the payment in doubt while the lookup fails", func(t *testing.T) {
+		repo := newMemoryRepository()
+		reconciler, _ := newTestReconciler(repo, &stubLookup{name: "default", err: core.ErrProcessorUnavailable}, nil)
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now())
+
+		if _, err := reconciler.Settle(context.Background(), p); !errors.Is(err, core.ErrPaymentInDoubt) {
+			t.Errorf("Expected in-doubt error, got: %v", err)
+		}
+		if !repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to stay in doubt")
+		}
+	})
+
+	t.Run("Resubmits once when a retry and the reconciler settle it together", func(t *testing.T) {
+		repo := newMemoryRepository()
+		barrier := &sync.WaitGroup{}
+		barrier.Add(2)
+		reconciler, resubmitted := newTestReconciler(repo, &stubLookup{name: "default", barrier: barrier}, func(ctx context.Context, payment *domain.Payment) error {
+			return repo.Process(ctx, payment, "fallback")
+		})
+
+		p := newTestPayment()
+		markInDoubtAt(repo, p, time.Now().Add(-time.Hour))
+
+		var done bool
+		var settleErr error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, settleErr = reconciler.Settle(context.Background(), p)
+		}()
+		reconciler.reconcile(context.Background())
+		wg.Wait()
+
+		if settleErr != nil {
+			t.Fatalf("Expected no error, got: %v", settleErr)
+		}
+
+		// A retry that is not done goes on to process the payment
+		resubmits := resubmitted.Load()
+		if !done {
+			resubmits++
+		}
+		if resubmits != 1 {
+			t.Errorf("Expected 1 resubmission, got: %d", resubmits)
+		}
+		if repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to be settled")
+		}
+	})
+}
@@ -2,91 +2,491 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/fabianoflorentino/mr-robot/core"
 	"github.com/fabianoflorentino/mr-robot/core/domain"
 	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/fabianoflorentino/mr-robot/internal/app/bulkhead"
 	"github.com/fabianoflorentino/mr-robot/internal/app/circuitbreaker"
+	"github.com/fabianoflorentino/mr-robot/internal/app/concurrency"
+	"github.com/fabianoflorentino/mr-robot/internal/app/hedging"
+	"github.com/fabianoflorentino/mr-robot/internal/app/payment"
+	"github.com/google/uuid"
 )
 
-// PaymentService manages payment processing with fallback support
+// ProcessorRegistration describes a registered processor and its settings.
+// Zero values for MaxFailures, ResetTimeout and RateLimit inherit the circuit
+// breaker configuration; a zero RateLimit means no per-processor limit. With
+// adaptive concurrency limits, RateLimit caps the processor's adaptive limit.
+// A zero RequestsPerSecond means no requests per second limit. Zero bulkhead
+// limits inherit the bulkhead configuration.
+type ProcessorRegistration struct {
+	Processor             domain.PaymentProcessor
+	Priority              int
+	Fee                   float64
+	MaxFailures           int
+	ResetTimeout          time.Duration
+	RateLimit             int
+	RequestsPerSecond     float64
+	Burst                 int
+	BulkheadMaxConcurrent int
+	BulkheadMaxQueue      int
+}
+
+// PaymentService manages payment processing across the registered processors
 type PaymentService struct {
-	repo                   repository.PaymentRepository
-	defaultProcessor       domain.PaymentProcessor
-	fallbackProcessor      domain.PaymentProcessor
-	defaultCircuitBreaker  *CircuitBreaker
-	fallbackCircuitBreaker *CircuitBreaker
-	rateLimiter            *RateLimiter
-	config                 *circuitbreaker.Config
+	repo          repository.PaymentRepository
+	rateLimiter   *RateLimiter
+	routingPolicy *RoutingPolicy
+	reconciler    *PaymentReconciler
+	config        *circuitbreaker.Config
+	limitMode     string
+	hedging       *hedging.Config
 }
 
-// NewPaymentService creates a new instance with fallback support
+// PaymentServiceOptions holds the optional resilience features of a
+// PaymentService. A nil or disabled configuration leaves its feature off.
+type PaymentServiceOptions struct {
+	// Concurrency replaces the fixed limits with adaptive ones
+	Concurrency *concurrency.Config
+	// Bulkhead gives every processor its own bulkhead
+	Bulkhead *bulkhead.Config
+	// Hedging hedges slow calls to the preferred processor
+	Hedging *hedging.Config
+}
+
+// NewPaymentService creates a new instance routing payments across the given
+// processors, with the optional features enabled in opts
 func NewPaymentService(
 	r repository.PaymentRepository,
-	defaultProcessor domain.PaymentProcessor,
-	fallbackProcessor domain.PaymentProcessor,
+	processors []ProcessorRegistration,
+	paymentCfg *payment.Config,
 	cfg *circuitbreaker.Config,
+	opts PaymentServiceOptions,
 ) *PaymentService {
+	concurrencyCfg, bulkheadCfg := opts.Concurrency, opts.Bulkhead
+
+	s := &PaymentService{
+		repo:          r,
+		routingPolicy: NewRoutingPolicy(paymentCfg.DefaultRecoveryWait, paymentCfg.RecoveryFeeRatio),
+		config:        cfg,
+		limitMode:     concurrency.ModeFixed,
+	}
+
+	if concurrencyCfg != nil && concurrencyCfg.Adaptive() {
+		s.limitMode = concurrencyCfg.Mode
+	} else {
+		concurrencyCfg = nil
+	}
+
+	if bulkheadCfg != nil && !bulkheadCfg.Enabled {
+		bulkheadCfg = nil
+	}
+
+	// Adaptive limits and bulkheads replace the global fixed limit, whose
+	// slots would otherwise be shared by the calls to every processor
+	if concurrencyCfg == nil && bulkheadCfg == nil {
+		s.rateLimiter = NewRateLimiter(cfg.RateLimit)
+	}
+
+	for _, p := range processors {
+		route := newProcessorRoute(p, cfg, concurrencyCfg)
+		s.routingPolicy.addRoute(route)
+
+		if bulkheadCfg != nil {
+			route.bulkhead = newRouteBulkhead(p, bulkheadCfg)
+			publishBulkhead(route)
+		}
+
+		if route.limiter != nil {
+			publishConcurrencyLimit(route, s.limitMode)
+		}
+	}
+
+	if opts.Hedging != nil && opts.Hedging.Enabled {
+		s.enableHedging(opts.Hedging)
+	}
+
+	return s
+}
+
+// newProcessorRoute creates the route of a registered processor with its own
+// circuit breaker and concurrency limit. Without an adaptive concurrency
+// configuration, the route is only limited when it has its own rate limit.
+func newProcessorRoute(p ProcessorRegistration, cfg *circuitbreaker.Config, concurrencyCfg *concurrency.Config) *processorRoute {
+	maxFailures := cfg.MaxFailures
+	if p.MaxFailures > 0 {
+		maxFailures = p.MaxFailures
+	}
+
+	resetTimeout := cfg.ResetTimeout
+	if p.ResetTimeout > 0 {
+		resetTimeout = p.ResetTimeout
+	}
+
+	route := &processorRoute{
+		processor:      p.Processor,
+		circuitBreaker: newRouteCircuitBreaker(cfg, maxFailures, resetTimeout),
+		priority:       p.Priority,
+		fee:            p.Fee,
+	}
+
+	if p.RequestsPerSecond > 0 {
+		route.tokenBucket = NewTokenBucket(p.RequestsPerSecond, max(p.Burst, 1))
+	}
+
+	switch {
+	case concurrencyCfg != nil:
+		route.limiter = newRouteLimiter(*concurrencyCfg, p.RateLimit)
+	case p.RateLimit > 0:
+		route.limiter = NewRateLimiter(p.RateLimit)
+	}
+
+	return route
+}
+
+// newRouteBulkhead creates a route's bulkhead with the processor's own
+// limits, or the configured ones
+func newRouteBulkhead(p ProcessorRegistration, cfg *bulkhead.Config) *Bulkhead {
+	maxConcurrent := cfg.MaxConcurrent
+	if p.BulkheadMaxConcurrent > 0 {
+		maxConcurrent = p.BulkheadMaxConcurrent
+	}
+
+	maxQueue := cfg.MaxQueue
+	if p.BulkheadMaxQueue > 0 {
+		maxQueue = p.BulkheadMaxQueue
+	}
+
+	return NewBulkhead(maxConcurrent, maxQueue, cfg.MaxWait)
+}
+
+// newRouteLimiter creates a route's adaptive limiter, capped by the
+// processor's own rate limit when it has one
+func newRouteLimiter(cfg concurrency.Config, rateLimit int) *AdaptiveLimiter {
+	if rateLimit > 0 && rateLimit < cfg.MaxLimit {
+		cfg.MaxLimit = rateLimit
+		cfg.MinLimit = min(cfg.MinLimit, rateLimit)
+		cfg.InitialLimit = min(cfg.InitialLimit, rateLimit)
+	}
+
+	return NewAdaptiveLimiter(&cfg)
+}
+
+// newRouteCircuitBreaker creates a route's circuit breaker in the configured
+// mode. maxFailures only applies to the consecutive failure mode.
+func newRouteCircuitBreaker(cfg *circuitbreaker.Config, maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
+	if cfg.Mode != circuitbreaker.ModeSlidingWindow {
+		return NewCircuitBreaker(maxFailures, resetTimeout, cfg.HalfOpenMaxCalls)
+	}
+
+	return NewSlidingWindowCircuitBreaker(SlidingWindowSettings{
+		TimeBased:             cfg.WindowType == circuitbreaker.WindowTime,
+		Size:                  cfg.WindowSize,
+		Duration:              cfg.WindowDuration,
+		FailureRateThreshold:  cfg.FailureRateThreshold,
+		SlowCallRateThreshold: cfg.SlowCallRateThreshold,
+		SlowCallDuration:      cfg.SlowCallDuration,
+		MinimumCalls:          cfg.MinimumCalls,
+	}, resetTimeout, cfg.HalfOpenMaxCalls)
+}
+
+// SetHealthMonitor sets the monitor used to skip processors known to be failing
+func (s *PaymentService) SetHealthMonitor(m *HealthMonitor) {
+	s.routingPolicy.SetHealthMonitor(m)
+}
+
+// OnCircuitBreakerStateChange registers a listener for the state changes of
+// every processor's circuit breaker
+func (s *PaymentService) OnCircuitBreakerStateChange(listener func(domain.CircuitBreakerEvent)) {
+	for _, route := range s.routingPolicy.routes {
+		processorName := route.processor.ProcessorName()
+
+		route.circuitBreaker.OnStateChange(func(change StateChange) {
+			listener(domain.CircuitBreakerEvent{
+				Processor: processorName,
+				From:      change.From.String(),
+				To:        change.To.String(),
+				Forced:    change.Forced,
+				Reason:    change.Reason,
+				Timestamp: change.At,
+				Instance:  change.Instance,
+			})
+		})
+	}
+}
+
+// CircuitBreakers returns the status of every processor's circuit breaker
+func (s *PaymentService) CircuitBreakers() []domain.CircuitBreakerStatus {
+	statuses := make([]domain.CircuitBreakerStatus, 0, len(s.routingPolicy.routes))
+	for _, route := range s.routingPolicy.routes {
+		statuses = append(statuses, routeCircuitBreakerStatus(route))
+	}
+
+	return statuses
+}
+
+// ForceCircuitBreakerOpen drains traffic from a processor until its breaker is released
+func (s *PaymentService) ForceCircuitBreakerOpen(processorName, reason string) (*domain.CircuitBreakerStatus, error) {
+	return s.updateCircuitBreaker(processorName, func(cb *CircuitBreaker) { cb.ForceOpen(reason) })
+}
+
+// ForceCircuitBreakerClosed puts a processor back into service until its breaker is released
+func (s *PaymentService) ForceCircuitBreakerClosed(processorName, reason string) (*domain.CircuitBreakerStatus, error) {
+	return s.updateCircuitBreaker(processorName, func(cb *CircuitBreaker) { cb.ForceClosed(reason) })
+}
+
+// ReleaseCircuitBreaker ends a forced state and lets the breaker decide again
+func (s *PaymentService) ReleaseCircuitBreaker(processorName, reason string) (*domain.CircuitBreakerStatus, error) {
+	return s.updateCircuitBreaker(processorName, func(cb *CircuitBreaker) { cb.Release(reason) })
+}
+
+// ResetCircuitBreaker ends a forced state, closes the breaker and forgets its failures
+func (s *PaymentService) ResetCircuitBreaker(processorName, reason string) (*domain.CircuitBreakerStatus, error) {
+	return s.updateCircuitBreaker(processorName, func(cb *CircuitBreaker) { cb.Reset(reason) })
+}
+
+// updateCircuitBreaker applies update to a processor's breaker and returns its new status
+func (s *PaymentService) updateCircuitBreaker(processorName string, update func(*CircuitBreaker)) (*domain.CircuitBreakerStatus, error) {
+	for _, route := range s.routingPolicy.routes {
+		if route.processor.ProcessorName() == processorName {
+			update(route.circuitBreaker)
+			status := routeCircuitBreakerStatus(route)
+			return &status, nil
+		}
+	}
 
-	return &PaymentService{
-		repo:                   r,
-		defaultProcessor:       defaultProcessor,
-		fallbackProcessor:      fallbackProcessor,
-		defaultCircuitBreaker:  NewCircuitBreaker(cfg.MaxFailures, cfg.ResetTimeout),
-		fallbackCircuitBreaker: NewCircuitBreaker(cfg.MaxFailures, cfg.ResetTimeout),
-		rateLimiter:            NewRateLimiter(cfg.RateLimit),
-		config:                 cfg,
+	return nil, fmt.Errorf("%w: %s", core.ErrProcessorNotFound, processorName)
+}
+
+// applySharedCircuitBreakerState applies a breaker state published by
+// another instance. Updates for unknown processors or states are ignored.
+func (s *PaymentService) applySharedCircuitBreakerState(update domain.CircuitBreakerUpdate) {
+	state, ok := parseCircuitBreakerState(update.State)
+	if !ok {
+		return
+	}
+
+	for _, route := range s.routingPolicy.routes {
+		if route.processor.ProcessorName() == update.Processor {
+			route.circuitBreaker.applySharedState(state, update.Forced, update.Reason, update.ChangedAt, update.Instance)
+			return
+		}
+	}
+}
+
+// ConcurrencyLimits returns the current concurrency limit of every processor
+// that has one
+func (s *PaymentService) ConcurrencyLimits() []domain.ConcurrencyLimit {
+	limits := make([]domain.ConcurrencyLimit, 0, len(s.routingPolicy.routes))
+	for _, route := range s.routingPolicy.routes {
+		if route.limiter != nil {
+			limits = append(limits, routeConcurrencyLimit(route, s.limitMode))
+		}
 	}
+
+	return limits
+}
+
+// Bulkheads returns the status of every processor's bulkhead, if bulkheads
+// are enabled
+func (s *PaymentService) Bulkheads() []domain.BulkheadStatus {
+	statuses := make([]domain.BulkheadStatus, 0, len(s.routingPolicy.routes))
+	for _, route := range s.routingPolicy.routes {
+		if route.bulkhead != nil {
+			statuses = append(statuses, routeBulkheadStatus(route))
+		}
+	}
+
+	return statuses
+}
+
+// routeCircuitBreakerStatus returns the status of a route's breaker
+func routeCircuitBreakerStatus(route *processorRoute) domain.CircuitBreakerStatus {
+	status := route.circuitBreaker.status()
+	status.Processor = route.processor.ProcessorName()
+	return status
+}
+
+// SetReconciler sets the reconciler used to settle timed-out processor calls
+// before failing over to another processor
+func (s *PaymentService) SetReconciler(r *PaymentReconciler) {
+	s.reconciler = r
 }
 
 // Process processes a payment with fallback support
 func (s *PaymentService) Process(ctx context.Context, payment *domain.Payment) error {
+	if payment.CorrelationID == uuid.Nil || payment.Amount <= 0 {
+		return fmt.Errorf("%w: correlationId and a positive amount are required", core.ErrInvalidPayment)
+	}
+
 	processCtx, cancel := context.WithTimeout(ctx, s.config.Timeout)
 	defer cancel()
 
+	if s.rateLimiter == nil {
+		return s.processPayment(processCtx, payment)
+	}
+
 	return s.rateLimiter.WithRateLimit(processCtx, func() error {
 		return s.processPayment(processCtx, payment)
 	})
 }
 
-// Summary returns payment summary (same as original PaymentService)
+// Summary returns the payment summary of every registered processor
 func (s *PaymentService) Summary(ctx context.Context, from, to *time.Time) (*domain.PaymentSummary, error) {
 	if from != nil && to != nil && from.After(*to) {
 		return nil, fmt.Errorf("from date cannot be after to date")
 	}
-	return s.repo.Summary(ctx, from, to)
+
+	summary, err := s.repo.Summary(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registered processors without payments are reported with zero totals
+	for _, name := range s.routingPolicy.routeNames() {
+		if _, ok := (*summary)[name]; !ok {
+			(*summary)[name] = domain.ProcessorSummary{}
+		}
+	}
+
+	return summary, nil
 }
 
 func (s *PaymentService) Purge(ctx context.Context) error {
 	return s.repo.Purge(ctx)
 }
 
-// processPayment tries default processor first, then fallback
+// processPayment tries the processors in the order chosen by the routing
+// policy, preferred healthy processor first
 func (s *PaymentService) processPayment(ctx context.Context, payment *domain.Payment) error {
-	// Try default processor first with its own circuit breaker
-	err := s.tryProcessorWithCircuitBreaker(payment, s.defaultProcessor, s.defaultCircuitBreaker)
-	if err == nil {
-		// Success with default processor
-		return s.repo.Process(ctx, payment, s.defaultProcessor.ProcessorName())
+	// A retry of a payment left in doubt first asks its processor again
+	if s.reconciler != nil {
+		processed, err := s.reconciler.Settle(ctx, payment)
+		if err != nil || processed {
+			return err
+		}
+	}
+
+	routes := s.routingPolicy.order(ctx)
+	if len(routes) == 0 {
+		return core.ErrPaymentNotProcessed
 	}
 
-	// Default failed, try fallback processor with its own circuit breaker
-	fmt.Printf("Default processor (%s) failed: %v, trying fallback...\n", s.defaultProcessor.ProcessorName(), err)
-	if err = s.tryProcessorWithCircuitBreaker(payment, s.fallbackProcessor, s.fallbackCircuitBreaker); err == nil {
-		// Success with fallback processor
-		return s.repo.Process(ctx, payment, s.fallbackProcessor.ProcessorName())
+	var err error
+	attempted := make(map[*processorRoute]bool, len(routes))
+
+	for i, route := range routes {
+		// A hedge may already have tried this processor
+		if attempted[route] {
+			continue
+		}
+
+		if i > 0 {
+			fmt.Printf("Processor failed: %v, trying %s...\n", err, route.processor.ProcessorName())
+		}
+
+		// Only the preferred processor is hedged; the route becomes the
+		// processor whose answer decides the payment
+		if i == 0 && s.hedging != nil {
+			route, err = s.tryHedged(ctx, payment, routes, attempted)
+		} else {
+			err = s.tryRoute(ctx, payment, route)
+		}
+
+		// A duplicate means the processor already accepted this payment
+		if err == nil || errors.Is(err, core.ErrPaymentDuplicate) {
+			return s.record(ctx, payment, route.processor.ProcessorName())
+		}
+
+		// The payment itself was rejected, another processor would reject it too
+		if errors.Is(err, core.ErrProcessorClientError) {
+			return fmt.Errorf("payment rejected by %s: %w", route.processor.ProcessorName(), err)
+		}
+
+		// The processor may have charged the payment, so check before failing
+		// over. Right after the timeout it may still be working on it, so a
+		// payment it does not know yet is in doubt rather than not processed.
+		if errors.Is(err, core.ErrProcessorTimeout) && s.reconciler != nil {
+			processed, lookupErr := s.reconciler.Resolve(ctx, payment, route.processor.ProcessorName())
+			if processed {
+				return s.record(ctx, payment, route.processor.ProcessorName())
+			}
+			if lookupErr == nil {
+				lookupErr = errors.New("payment not found yet")
+			}
+			return s.markInDoubt(ctx, payment, route.processor.ProcessorName(), lookupErr)
+		}
+	}
+
+	// Every processor failed
+	return fmt.Errorf("all payment processors failed: %w", err)
+}
+
+// record stores a payment accepted by a processor. Once the processor has
+// charged it, the row must be written even if the processing deadline expired.
+func (s *PaymentService) record(ctx context.Context, payment *domain.Payment, processorName string) error {
+	return s.repo.Process(context.WithoutCancel(ctx), payment, processorName)
+}
+
+// markInDoubt records a payment whose outcome could not be determined so the
+// reconciler settles it later instead of risking a second charge elsewhere.
+// It returns a core.ErrPaymentInDoubt error so the payment is retried, and
+// settled first, rather than counted as processed.
+func (s *PaymentService) markInDoubt(ctx context.Context, payment *domain.Payment, processorName string, cause error) error {
+	log.Printf("Payment %s is in doubt at %s: %v", payment.CorrelationID, processorName, cause)
+
+	inDoubtErr := fmt.Errorf("%w at %s: %v", core.ErrPaymentInDoubt, processorName, cause)
+	if err := s.repo.MarkInDoubt(context.WithoutCancel(ctx), payment, processorName); err != nil {
+		return fmt.Errorf("%w; failed to mark it in doubt: %w", inDoubtErr, err)
+	}
+
+	return inDoubtErr
+}
+
+// tryRoute attempts to process within the route's requests per second limit,
+// bulkhead and concurrency limit, if any
+func (s *PaymentService) tryRoute(ctx context.Context, payment *domain.Payment, route *processorRoute) error {
+	if route.tokenBucket != nil {
+		if err := route.tokenBucket.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	call := func() error {
+		return s.tryProcessorWithCircuitBreaker(ctx, payment, route.processor, route.circuitBreaker)
+	}
+
+	if route.limiter != nil {
+		limited := call
+		call = func() error { return route.limiter.WithRateLimit(ctx, limited) }
+	}
+
+	if route.bulkhead != nil {
+		isolated := call
+		call = func() error { return route.bulkhead.Execute(ctx, isolated) }
+	}
+
+	err := call()
+
+	// Stop sending requests for as long as the processor asked
+	if route.tokenBucket != nil && errors.Is(err, core.ErrProcessorRateLimited) {
+		if retryAfter, ok := core.RetryAfterFrom(err); ok {
+			route.tokenBucket.Pause(retryAfter)
+		}
 	}
 
-	// Both processors failed
-	return fmt.Errorf("both default and fallback processors failed: %w", err)
+	return err
 }
 
 // tryProcessorWithCircuitBreaker attempts to process with circuit breaker protection
-func (s *PaymentService) tryProcessorWithCircuitBreaker(payment *domain.Payment, processor domain.PaymentProcessor, circuitBreaker *CircuitBreaker) error {
-	return circuitBreaker.Call(func() error {
-		ok, err := processor.Process(payment)
+func (s *PaymentService) tryProcessorWithCircuitBreaker(ctx context.Context, payment *domain.Payment, processor domain.PaymentProcessor, circuitBreaker *CircuitBreaker) error {
+	return circuitBreaker.Call(ctx, func(ctx context.Context) error {
+		ok, err := processor.Process(ctx, payment)
 		if err != nil {
 			return err
 		}
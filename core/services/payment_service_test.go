@@ -0,0 +1,656 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/adapters/outbound/gateway"
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/bulkhead"
+	"github.com/fabianoflorentino/mr-robot/internal/app/circuitbreaker"
+	"github.com/fabianoflorentino/mr-robot/internal/app/concurrency"
+	"github.com/fabianoflorentino/mr-robot/internal/app/hedging"
+	"github.com/fabianoflorentino/mr-robot/internal/app/payment"
+	"github.com/fabianoflorentino/mr-robot/internal/app/reconciliation"
+	"github.com/fabianoflorentino/mr-robot/internal/fakeprocessor"
+	"github.com/google/uuid"
+)
+
+// memoryRepository is an in-memory PaymentRepository for tests
+type memoryRepository struct {
+	processed map[uuid.UUID][]string
+	inDoubt   map[uuid.UUID]domain.InDoubtPayment
+	markedAt  map[uuid.UUID]time.Time
+	mutex     sync.Mutex
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		processed: make(map[uuid.UUID][]string),
+		inDoubt:   make(map[uuid.UUID]domain.InDoubtPayment),
+		markedAt:  make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (r *memoryRepository) Process(ctx context.Context, payment *domain.Payment, processorName string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.record(payment.CorrelationID, processorName)
+	return nil
+}
+
+func (r *memoryRepository) Summary(ctx context.Context, from, to *time.Time) (*domain.PaymentSummary, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	summary := domain.PaymentSummary{}
+	for _, names := range r.processed {
+		for _, name := range names {
+			s := summary[name]
+			s.TotalRequests++
+			summary[name] = s
+		}
+	}
+	return &summary, nil
+}
+
+func (r *memoryRepository) Purge(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.processed = make(map[uuid.UUID][]string)
+	return nil
+}
+
+func (r *memoryRepository) MarkInDoubt(ctx context.Context, payment *domain.Payment, processorName string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.inDoubt[payment.CorrelationID] = domain.InDoubtPayment{Payment: *payment, Processor: processorName}
+	r.markedAt[payment.CorrelationID] = time.Now()
+	return nil
+}
+
+func (r *memoryRepository) InDoubt(ctx context.Context, markedBefore time.Time, limit int) ([]domain.InDoubtPayment, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	payments := make([]domain.InDoubtPayment, 0, len(r.inDoubt))
+	for id, p := range r.inDoubt {
+		if !r.markedAt[id].After(markedBefore) && len(payments) < limit {
+			payments = append(payments, p)
+		}
+	}
+	return payments, nil
+}
+
+func (r *memoryRepository) FindInDoubt(ctx context.Context, correlationID uuid.UUID) (*domain.InDoubtPayment, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, ok := r.inDoubt[correlationID]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func (r *memoryRepository) ResolveInDoubt(ctx context.Context, correlationID uuid.UUID, processed bool) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, ok := r.inDoubt[correlationID]
+	if !ok {
+		return false, nil
+	}
+
+	if processed {
+		r.record(correlationID, p.Processor)
+	}
+	delete(r.inDoubt, correlationID)
+	delete(r.markedAt, correlationID)
+	return true, nil
+}
+
+func (r *memoryRepository) CorrelationIDs(ctx context.Context, processorName string, from, to time.Time, limit int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+// record stores a payment once per processor, like the data repository
+func (r *memoryRepository) record(correlationID uuid.UUID, processorName string) {
+	if !slices.Contains(r.processed[correlationID], processorName) {
+		r.processed[correlationID] = append(r.processed[correlationID], processorName)
+	}
+}
+
+// processorFor returns the processor name a payment was first recorded with
+func (r *memoryRepository) processorFor(correlationID uuid.UUID) (string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	names := r.processed[correlationID]
+	if len(names) == 0 {
+		return "", false
+	}
+	return names[0], true
+}
+
+// processorsFor returns the processor names a payment was recorded with
+func (r *memoryRepository) processorsFor(correlationID uuid.UUID) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Clone(r.processed[correlationID])
+}
+
+// isInDoubt reports whether the payment is still in doubt
+func (r *memoryRepository) isInDoubt(correlationID uuid.UUID) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, ok := r.inDoubt[correlationID]
+	return ok
+}
+
+// newTestRegistration serves a fake processor and registers a gateway for it
+func newTestRegistration(t *testing.T, name string, priority int, fee float64, processor *fakeprocessor.Processor) ProcessorRegistration {
+	t.Helper()
+
+	server := httptest.NewServer(processor)
+	t.Cleanup(server.Close)
+
+	gw := gateway.NewProcessorFactory().CreateProcessor(gateway.ProcessorType(name), gateway.ProcessorConfig{
+		URL:        server.URL + "/payments",
+		Timeout:    time.Second,
+		HTTPClient: server.Client(),
+	})
+
+	return ProcessorRegistration{Processor: gw, Priority: priority, Fee: fee}
+}
+
+// testProcessor describes a processor registered by a paymentServiceFixture.
+// A fake processor following behavior is served, unless processor is set.
+type testProcessor struct {
+	name      string
+	priority  int
+	fee       float64
+	behavior  fakeprocessor.Behavior
+	processor domain.PaymentProcessor
+	configure func(*ProcessorRegistration)
+}
+
+// testDefault describes the cheap preferred processor
+func testDefault(behavior fakeprocessor.Behavior) testProcessor {
+	return testProcessor{name: "default", priority: 0, fee: 0.05, behavior: behavior}
+}
+
+// testFallback describes the more expensive fallback processor
+func testFallback(behavior fakeprocessor.Behavior) testProcessor {
+	return testProcessor{name: "fallback", priority: 1, fee: 0.15, behavior: behavior}
+}
+
+// paymentServiceFixture describes a payment service under test. A zero
+// rateLimit gives a global concurrency limit of 10. With reconciler set,
+// timed-out calls are looked up at the registered processors.
+type paymentServiceFixture struct {
+	processors []testProcessor
+	options    PaymentServiceOptions
+	rateLimit  int
+	reconciler bool
+}
+
+// testPaymentService is a payment service started from a fixture, with its
+// repository and its fake processors by name
+type testPaymentService struct {
+	*PaymentService
+	repo  *memoryRepository
+	fakes map[string]*fakeprocessor.Processor
+}
+
+// start serves the fixture's processors and creates the payment service
+func (f paymentServiceFixture) start(t *testing.T) *testPaymentService {
+	t.Helper()
+
+	s := &testPaymentService{repo: newMemoryRepository(), fakes: make(map[string]*fakeprocessor.Processor)}
+
+	registrations := make([]ProcessorRegistration, 0, len(f.processors))
+	lookups := make([]domain.PaymentLookup, 0, len(f.processors))
+	for _, p := range f.processors {
+		registration := ProcessorRegistration{Processor: p.processor, Priority: p.priority, Fee: p.fee}
+		if p.processor == nil {
+			fake := fakeprocessor.New(fakeprocessor.Options{})
+			fake.SetBehavior(p.behavior)
+			s.fakes[p.name] = fake
+			registration = newTestRegistration(t, p.name, p.priority, p.fee, fake)
+		}
+		if p.configure != nil {
+			p.configure(&registration)
+		}
+
+		registrations = append(registrations, registration)
+		if lookup, ok := registration.Processor.(domain.PaymentLookup); ok {
+			lookups = append(lookups, lookup)
+		}
+	}
+
+	rateLimit := f.rateLimit
+	if rateLimit == 0 {
+		rateLimit = 10
+	}
+
+	s.PaymentService = NewPaymentService(
+		s.repo,
+		registrations,
+		&payment.Config{RecoveryFeeRatio: 2},
+		&circuitbreaker.Config{Timeout: time.Second, ResetTimeout: time.Minute, MaxFailures: 2, RateLimit: rateLimit},
+		f.options,
+	)
+
+	if f.reconciler {
+		s.SetReconciler(NewPaymentReconciler(s.repo, &reconciliation.Config{LookupTimeout: time.Second}, s.Process, lookups...))
+	}
+
+	return s
+}
+
+// assertCalls checks the payment calls received by each fake processor
+func (s *testPaymentService) assertCalls(t *testing.T, want map[string]int) {
+	t.Helper()
+
+	for name, calls := range want {
+		if got := s.fakes[name].Calls(); got != calls {
+			t.Errorf("Expected %d calls to %s, got: %d", calls, name, got)
+		}
+	}
+}
+
+func newTestPayment() *domain.Payment {
+	return &domain.Payment{CorrelationID: uuid.New(), Amount: 19.9, RequestedAt: domain.NewRequestedAt()}
+}
+
+func TestPaymentService_Process(t *testing.T) {
+	healthy := fakeprocessor.Behavior{}
+	failing := fakeprocessor.Behavior{Failing: true}
+
+	tests := []struct {
+		name          string
+		fixture       paymentServiceFixture
+		invalid       bool
+		wantErr       error
+		wantProcessor string
+		wantCalls     map[string]int
+	}{
+		{
+			name:          "Uses the preferred processor",
+			fixture:       paymentServiceFixture{processors: []testProcessor{testDefault(healthy), testFallback(healthy)}},
+			wantProcessor: "default",
+			wantCalls:     map[string]int{"default": 1, "fallback": 0},
+		},
+		{
+			name:          "Fails over when the preferred processor errors",
+			fixture:       paymentServiceFixture{processors: []testProcessor{testDefault(failing), testFallback(healthy)}},
+			wantProcessor: "fallback",
+			wantCalls:     map[string]int{"default": 1, "fallback": 1},
+		},
+		{
+			name:          "Records duplicates as processed",
+			fixture:       paymentServiceFixture{processors: []testProcessor{testDefault(fakeprocessor.Behavior{DuplicateRate: 1}), testFallback(healthy)}},
+			wantProcessor: "default",
+			wantCalls:     map[string]int{"default": 1, "fallback": 0},
+		},
+		{
+			name:      "Rejects an invalid payment without calling a processor",
+			fixture:   paymentServiceFixture{processors: []testProcessor{testDefault(healthy)}},
+			invalid:   true,
+			wantErr:   core.ErrInvalidPayment,
+			wantCalls: map[string]int{"default": 0},
+		},
+		{
+			name:      "Fails when every processor fails",
+			fixture:   paymentServiceFixture{processors: []testProcessor{testDefault(failing)}},
+			wantErr:   core.ErrProcessorServerError,
+			wantCalls: map[string]int{"default": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.fixture.start(t)
+
+			p := newTestPayment()
+			if tt.invalid {
+				p.Amount = 0
+			}
+
+			err := s.Process(context.Background(), p)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got: %v", tt.wantErr, err)
+			}
+
+			if name, _ := s.repo.processorFor(p.CorrelationID); name != tt.wantProcessor {
+				t.Errorf("Expected payment recorded for %q, got: %q", tt.wantProcessor, name)
+			}
+			s.assertCalls(t, tt.wantCalls)
+		})
+	}
+}
+
+func TestPaymentService_InDoubt(t *testing.T) {
+	// The default processor never answers a payment in time, and finds it
+	// only once found is set
+	var found atomic.Bool
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			<-release
+			return
+		}
+		if !found.Load() {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	defaultProcessor := gateway.NewProcessorFactory().CreateProcessor(gateway.DefaultProcessor, gateway.ProcessorConfig{
+		URL:        server.URL + "/payments",
+		Timeout:    time.Second,
+		HTTPClient: server.Client(),
+	})
+
+	s := paymentServiceFixture{
+		processors: []testProcessor{
+			{name: "default", priority: 0, fee: 0.05, processor: defaultProcessor},
+			testFallback(fakeprocessor.Behavior{}),
+		},
+		reconciler: true,
+	}.start(t)
+
+	p := newTestPayment()
+
+	t.Run("Keeps a timed out payment its processor does not know yet in doubt", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if err := s.Process(ctx, p); !errors.Is(err, core.ErrPaymentInDoubt) {
+			t.Fatalf("Expected in-doubt error, got: %v", err)
+		}
+		if !s.repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to be marked in doubt")
+		}
+		s.assertCalls(t, map[string]int{"fallback": 0})
+	})
+
+	t.Run("Settles the payment before processing it again", func(t *testing.T) {
+		found.Store(true)
+
+		if err := s.Process(context.Background(), p); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if name, _ := s.repo.processorFor(p.CorrelationID); name != "default" {
+			t.Errorf("Expected payment recorded for default, got: %q", name)
+		}
+		if s.repo.isInDoubt(p.CorrelationID) {
+			t.Error("Expected the payment to be settled")
+		}
+		s.assertCalls(t, map[string]int{"fallback": 0})
+	})
+}
+
+func TestPaymentService_CircuitBreakers(t *testing.T) {
+	s := paymentServiceFixture{processors: []testProcessor{testDefault(fakeprocessor.Behavior{}), testFallback(fakeprocessor.Behavior{})}}.start(t)
+
+	if _, err := s.ForceCircuitBreakerOpen("unknown", "test"); !errors.Is(err, core.ErrProcessorNotFound) {
+		t.Errorf("Expected processor not found error, got: %v", err)
+	}
+
+	status, err := s.ForceCircuitBreakerOpen("default", "draining")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if status.State != "open" || !status.Forced {
+		t.Errorf("Expected forced open status, got: %+v", status)
+	}
+
+	p := newTestPayment()
+	if err := s.Process(context.Background(), p); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if name, _ := s.repo.processorFor(p.CorrelationID); name != "fallback" {
+		t.Errorf("Expected traffic drained to fallback, got: %q", name)
+	}
+	s.assertCalls(t, map[string]int{"default": 0})
+
+	if _, err := s.ReleaseCircuitBreaker("default", "done"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, status := range s.CircuitBreakers() {
+		if status.State != "closed" || status.Forced {
+			t.Errorf("Expected closed unforced breakers, got: %+v", status)
+		}
+	}
+}
+
+func TestPaymentService_ConcurrencyLimits(t *testing.T) {
+	withRateLimit := func(limit int) func(*ProcessorRegistration) {
+		return func(r *ProcessorRegistration) { r.RateLimit = limit }
+	}
+
+	t.Run("Fixed mode limits processors with their own rate limit", func(t *testing.T) {
+		limited := testDefault(fakeprocessor.Behavior{})
+		limited.configure = withRateLimit(3)
+		s := paymentServiceFixture{processors: []testProcessor{limited, testFallback(fakeprocessor.Behavior{})}}.start(t)
+
+		limits := s.ConcurrencyLimits()
+		if len(limits) != 1 || limits[0].Processor != "default" || limits[0].Limit != 3 || limits[0].Mode != concurrency.ModeFixed {
+			t.Errorf("Expected a fixed limit of 3 for default only, got: %+v", limits)
+		}
+	})
+
+	t.Run("Adaptive mode limits every processor", func(t *testing.T) {
+		capped := testDefault(fakeprocessor.Behavior{})
+		capped.configure = withRateLimit(4)
+		s := paymentServiceFixture{
+			processors: []testProcessor{capped, testFallback(fakeprocessor.Behavior{})},
+			options:    PaymentServiceOptions{Concurrency: newTestConcurrencyConfig(concurrency.ModeAIMD)},
+		}.start(t)
+
+		if err := s.Process(context.Background(), newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		limits := s.ConcurrencyLimits()
+		if len(limits) != 2 {
+			t.Fatalf("Expected 2 limits, got: %+v", limits)
+		}
+		if limits[0].Limit != 4 {
+			t.Errorf("Expected default limit capped at 4, got: %d", limits[0].Limit)
+		}
+		if limits[1].Limit != 10 || limits[1].Mode != concurrency.ModeAIMD {
+			t.Errorf("Expected fallback AIMD limit of 10, got: %+v", limits[1])
+		}
+	})
+}
+
+func TestPaymentService_RequestsPerSecond(t *testing.T) {
+	t.Run("Falls back when no token is available in time", func(t *testing.T) {
+		limited := testDefault(fakeprocessor.Behavior{})
+		limited.configure = func(r *ProcessorRegistration) { r.RequestsPerSecond = 0.1 }
+		s := paymentServiceFixture{processors: []testProcessor{limited, testFallback(fakeprocessor.Behavior{})}}.start(t)
+
+		first, second := newTestPayment(), newTestPayment()
+		for _, p := range []*domain.Payment{first, second} {
+			if err := s.Process(context.Background(), p); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		if name, _ := s.repo.processorFor(first.CorrelationID); name != "default" {
+			t.Errorf("Expected first payment recorded for default, got: %q", name)
+		}
+		if name, _ := s.repo.processorFor(second.CorrelationID); name != "fallback" {
+			t.Errorf("Expected second payment recorded for fallback, got: %q", name)
+		}
+	})
+
+	t.Run("Pauses after a Retry-After", func(t *testing.T) {
+		limited := testDefault(fakeprocessor.Behavior{RateLimitEvery: 1, RetryAfter: 5 * time.Second})
+		limited.configure = func(r *ProcessorRegistration) {
+			r.RequestsPerSecond = 100
+			r.Burst = 10
+		}
+		s := paymentServiceFixture{processors: []testProcessor{limited, testFallback(fakeprocessor.Behavior{})}}.start(t)
+
+		for range 3 {
+			if err := s.Process(context.Background(), newTestPayment()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		// A single call to the rate limited processor
+		s.assertCalls(t, map[string]int{"default": 1})
+	})
+}
+
+func TestPaymentService_Bulkheads(t *testing.T) {
+	s := paymentServiceFixture{
+		processors: []testProcessor{testDefault(fakeprocessor.Behavior{Latency: 300 * time.Millisecond}), testFallback(fakeprocessor.Behavior{})},
+		options:    PaymentServiceOptions{Bulkhead: &bulkhead.Config{Enabled: true, MaxConcurrent: 1, MaxQueue: 0}},
+		rateLimit:  1,
+	}.start(t)
+
+	slow := make(chan error, 1)
+	go func() { slow <- s.Process(context.Background(), newTestPayment()) }()
+
+	deadline := time.Now().Add(time.Second)
+	for s.Bulkheads()[0].Active != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	statuses := s.Bulkheads()
+	if len(statuses) != 2 || statuses[0].Processor != "default" || !statuses[0].Saturated {
+		t.Fatalf("Expected a saturated default bulkhead, got: %+v", statuses)
+	}
+
+	// The global limit of 1 is replaced by the bulkheads, so this payment
+	// does not wait for the slow one and goes to the fallback right away
+	p := newTestPayment()
+	if err := s.Process(context.Background(), p); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	select {
+	case <-slow:
+		t.Error("Expected the payment not to wait for the default processor")
+	default:
+	}
+	if name, _ := s.repo.processorFor(p.CorrelationID); name != "fallback" {
+		t.Errorf("Expected payment recorded for fallback, got: %q", name)
+	}
+
+	if err := <-slow; err != nil {
+		t.Fatalf("Expected no error for the slow payment, got: %v", err)
+	}
+	if rejected := s.Bulkheads()[0].Rejected; rejected != 1 {
+		t.Errorf("Expected 1 rejected call, got: %d", rejected)
+	}
+}
+
+func newTestHedgingConfig(allowFallback bool) *hedging.Config {
+	return &hedging.Config{
+		Enabled:       true,
+		Percentile:    0.95,
+		MinDelay:      10 * time.Millisecond,
+		MaxDelay:      20 * time.Millisecond,
+		MinSamples:    20,
+		Window:        100,
+		AllowFallback: allowFallback,
+	}
+}
+
+func TestPaymentService_Hedging(t *testing.T) {
+	hedged := PaymentServiceOptions{Hedging: newTestHedgingConfig(true)}
+
+	tests := []struct {
+		name        string
+		fixture     paymentServiceFixture
+		wantHedges  int64
+		wantWins    int64
+		wantDoubles int64
+		wantCharges []string
+		wantCalls   map[string]int
+	}{
+		{
+			name: "Does not hedge fast calls",
+			fixture: paymentServiceFixture{
+				processors: []testProcessor{testDefault(fakeprocessor.Behavior{}), testFallback(fakeprocessor.Behavior{})},
+				options:    hedged,
+			},
+			wantCharges: []string{"default"},
+			wantCalls:   map[string]int{"default": 1, "fallback": 0},
+		},
+		{
+			name: "Hedges to the same processor without a reconciler",
+			fixture: paymentServiceFixture{
+				processors: []testProcessor{testDefault(fakeprocessor.Behavior{Latency: 100 * time.Millisecond}), testFallback(fakeprocessor.Behavior{})},
+				options:    hedged,
+			},
+			wantHedges:  1,
+			wantCharges: []string{"default"},
+			wantCalls:   map[string]int{"default": 2, "fallback": 0},
+		},
+		{
+			// The fake default processor stores the payment before its
+			// latency, so the cancelled attempt was accepted too and is
+			// recorded as well
+			name: "Records the fallback that won and detects a double acceptance",
+			fixture: paymentServiceFixture{
+				processors: []testProcessor{testDefault(fakeprocessor.Behavior{Latency: 300 * time.Millisecond}), testFallback(fakeprocessor.Behavior{})},
+				options:    hedged,
+				reconciler: true,
+			},
+			wantHedges:  1,
+			wantWins:    1,
+			wantDoubles: 1,
+			wantCharges: []string{"fallback", "default"},
+			wantCalls:   map[string]int{"default": 1, "fallback": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.fixture.start(t)
+			hedges, wins, doubles := hedgingCount("hedges"), hedgingCount("wins"), hedgingCount("double_accepted")
+
+			p := newTestPayment()
+			if err := s.Process(context.Background(), p); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			// The losing attempt is verified in the background
+			deadline := time.Now().Add(time.Second)
+			for hedgingCount("double_accepted")-doubles < tt.wantDoubles && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+
+			if sent := hedgingCount("hedges") - hedges; sent != tt.wantHedges {
+				t.Errorf("Expected %d hedges, got: %d", tt.wantHedges, sent)
+			}
+			if won := hedgingCount("wins") - wins; won != tt.wantWins {
+				t.Errorf("Expected %d winning hedges, got: %d", tt.wantWins, won)
+			}
+			if double := hedgingCount("double_accepted") - doubles; double != tt.wantDoubles {
+				t.Errorf("Expected %d double acceptances, got: %d", tt.wantDoubles, double)
+			}
+			if charges := s.repo.processorsFor(p.CorrelationID); !slices.Equal(charges, tt.wantCharges) {
+				t.Errorf("Expected the payment recorded for %v, got: %v", tt.wantCharges, charges)
+			}
+			s.assertCalls(t, tt.wantCalls)
+		})
+	}
+}
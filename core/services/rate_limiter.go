@@ -4,7 +4,7 @@ import (
 	"context"
 )
 
-// RateLimiter implements rate control for concurrent processing
+// RateLimiter is a concurrency limiter with a fixed limit
 type RateLimiter struct {
 	limiter chan struct{}
 }
@@ -31,6 +31,16 @@ func (rl *RateLimiter) Release() {
 	<-rl.limiter
 }
 
+// Limit returns the number of calls allowed in flight
+func (rl *RateLimiter) Limit() int {
+	return cap(rl.limiter)
+}
+
+// InFlight returns the number of calls currently in flight
+func (rl *RateLimiter) InFlight() int {
+	return len(rl.limiter)
+}
+
 // WithRateLimit executes a function with rate limiting
 func (rl *RateLimiter) WithRateLimit(ctx context.Context, fn func() error) error {
 	if err := rl.Acquire(ctx); err != nil {
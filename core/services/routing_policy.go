@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// recoveryPollInterval is how often the routing policy re-checks a recovering processor
+const recoveryPollInterval = 25 * time.Millisecond
+
+// processorRoute binds a processor to its circuit breaker, limits and routing settings
+type processorRoute struct {
+	processor      domain.PaymentProcessor
+	circuitBreaker *CircuitBreaker
+	limiter        ConcurrencyLimiter
+	tokenBucket    *TokenBucket
+	bulkhead       *Bulkhead
+	latencies      *latencyTracker
+	priority       int
+	fee            float64
+}
+
+// RoutingPolicy decides in which order the processors are attempted. It
+// prefers the healthy processor with the lowest priority value and, among
+// equal priorities, the cheapest one. When the preferred processor is down and
+// the alternative is much more expensive, it waits briefly for it to recover.
+type RoutingPolicy struct {
+	routes           []*processorRoute
+	healthMonitor    *HealthMonitor
+	recoveryWait     time.Duration
+	recoveryFeeRatio float64
+}
+
+// NewRoutingPolicy creates a new routing policy. A zero recovery wait or fee
+// ratio disables waiting for the cheapest processor.
+func NewRoutingPolicy(recoveryWait time.Duration, recoveryFeeRatio float64) *RoutingPolicy {
+	return &RoutingPolicy{
+		recoveryWait:     recoveryWait,
+		recoveryFeeRatio: recoveryFeeRatio,
+	}
+}
+
+// addRoute registers a processor route
+func (rp *RoutingPolicy) addRoute(route *processorRoute) {
+	rp.routes = append(rp.routes, route)
+}
+
+// SetHealthMonitor sets the monitor used to detect processors known to be failing
+func (rp *RoutingPolicy) SetHealthMonitor(m *HealthMonitor) {
+	rp.healthMonitor = m
+}
+
+// order returns the routes in the order they should be attempted. Available
+// routes come first, by priority and fee; unavailable ones are kept at the end
+// as a last resort.
+func (rp *RoutingPolicy) order(ctx context.Context) []*processorRoute {
+	preferred := rp.preferred()
+
+	if preferred != nil && !rp.isAvailable(ctx, preferred) && rp.shouldWaitFor(ctx, preferred) {
+		rp.waitForRecovery(ctx, preferred)
+	}
+
+	return rp.sorted(ctx)
+}
+
+// routeNames returns the names of all registered processors
+func (rp *RoutingPolicy) routeNames() []string {
+	names := make([]string, 0, len(rp.routes))
+	for _, route := range rp.routes {
+		names = append(names, route.processor.ProcessorName())
+	}
+
+	return names
+}
+
+// sorted orders the routes by availability, priority, fee and reported latency
+func (rp *RoutingPolicy) sorted(ctx context.Context) []*processorRoute {
+	type candidate struct {
+		route     *processorRoute
+		available bool
+		latency   int
+	}
+
+	candidates := make([]candidate, 0, len(rp.routes))
+	for _, route := range rp.routes {
+		candidates = append(candidates, candidate{
+			route:     route,
+			available: rp.isAvailable(ctx, route),
+			latency:   rp.minResponseTime(route),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.available != b.available {
+			return a.available
+		}
+		if a.route.priority != b.route.priority {
+			return a.route.priority < b.route.priority
+		}
+		if a.route.fee != b.route.fee {
+			return a.route.fee < b.route.fee
+		}
+		return a.latency < b.latency
+	})
+
+	ordered := make([]*processorRoute, 0, len(candidates))
+	for _, c := range candidates {
+		ordered = append(ordered, c.route)
+	}
+
+	return ordered
+}
+
+// preferred returns the route with the lowest priority value and, among equal
+// priorities, the lowest fee
+func (rp *RoutingPolicy) preferred() *processorRoute {
+	var preferred *processorRoute
+	for _, route := range rp.routes {
+		if preferred == nil || route.priority < preferred.priority ||
+			(route.priority == preferred.priority && route.fee < preferred.fee) {
+			preferred = route
+		}
+	}
+
+	return preferred
+}
+
+// shouldWaitFor reports whether the best available alternative is expensive
+// enough to justify waiting for the given route to recover
+func (rp *RoutingPolicy) shouldWaitFor(ctx context.Context, target *processorRoute) bool {
+	// A breaker forced open by an operator will not recover on its own
+	if rp.recoveryWait <= 0 || rp.recoveryFeeRatio <= 0 || target.circuitBreaker.IsForced() {
+		return false
+	}
+
+	for _, route := range rp.routes {
+		if route == target || !rp.isAvailable(ctx, route) {
+			continue
+		}
+
+		if route.fee < target.fee*rp.recoveryFeeRatio {
+			return false
+		}
+	}
+
+	return true
+}
+
+// waitForRecovery waits until the route becomes available, the recovery wait
+// elapses or the context is done, whichever happens first
+func (rp *RoutingPolicy) waitForRecovery(ctx context.Context, route *processorRoute) {
+	deadline := time.NewTimer(rp.recoveryWait)
+	defer deadline.Stop()
+
+	ticker := time.NewTicker(recoveryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if rp.isAvailable(ctx, route) {
+				return
+			}
+		case <-deadline.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// isAvailable reports whether a route can be expected to succeed: its circuit
+// breaker lets calls through, its health endpoint does not report it as
+// failing and its minimum response time fits in the remaining deadline. A
+// breaker forced by an operator overrides the health endpoint.
+func (rp *RoutingPolicy) isAvailable(ctx context.Context, route *processorRoute) bool {
+	if !route.circuitBreaker.IsAvailable() {
+		return false
+	}
+
+	if rp.healthMonitor == nil || route.circuitBreaker.IsForced() {
+		return true
+	}
+
+	status, ok := rp.healthMonitor.Status(route.processor.ProcessorName())
+	if !ok {
+		return true
+	}
+
+	if status.Failing {
+		return false
+	}
+
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		minResponseTime := time.Duration(status.MinResponseTime) * time.Millisecond
+		if minResponseTime > time.Until(deadline) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// minResponseTime returns the last reported minimum response time of a route in milliseconds
+func (rp *RoutingPolicy) minResponseTime(route *processorRoute) int {
+	if rp.healthMonitor == nil {
+		return 0
+	}
+
+	status, ok := rp.healthMonitor.Status(route.processor.ProcessorName())
+	if !ok {
+		return 0
+	}
+
+	return status.MinResponseTime
+}
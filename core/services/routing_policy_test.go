@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/fakeprocessor"
+)
+
+// testRoute describes a route of a routing policy test
+type testRoute struct {
+	name     string
+	priority int
+	fee      float64
+	open     bool
+	health   *domain.ProcessorHealth
+}
+
+// newTestRoutingPolicy creates a policy whose routes call fake processors.
+// Routes are opened by a failed call and given their health status, if any.
+func newTestRoutingPolicy(t *testing.T, recoveryWait time.Duration, recoveryFeeRatio float64, routes ...testRoute) *RoutingPolicy {
+	t.Helper()
+
+	policy := NewRoutingPolicy(recoveryWait, recoveryFeeRatio)
+	monitor := NewHealthMonitor(newTestHealthConfig(time.Hour))
+	policy.SetHealthMonitor(monitor)
+
+	for _, r := range routes {
+		registration := newTestRegistration(t, r.name, r.priority, r.fee, fakeprocessor.New(fakeprocessor.Options{}))
+		route := &processorRoute{
+			processor:      registration.Processor,
+			circuitBreaker: NewCircuitBreaker(1, time.Minute, 1),
+			priority:       r.priority,
+			fee:            r.fee,
+		}
+
+		if r.open {
+			route.circuitBreaker.Call(context.Background(), func(ctx context.Context) error {
+				return errors.New("processor down")
+			})
+		}
+
+		if r.health != nil {
+			monitor.UpdateStatus(r.name, *r.health)
+		}
+
+		policy.addRoute(route)
+	}
+
+	return policy
+}
+
+// routeOrder returns the processor names of the routes in order
+func routeOrder(routes []*processorRoute) []string {
+	names := make([]string, 0, len(routes))
+	for _, route := range routes {
+		names = append(names, route.processor.ProcessorName())
+	}
+	return names
+}
+
+func TestRoutingPolicy_Order(t *testing.T) {
+	failing := &domain.ProcessorHealth{Failing: true, CheckedAt: time.Now()}
+	slow := &domain.ProcessorHealth{MinResponseTime: 5000, CheckedAt: time.Now()}
+
+	tests := []struct {
+		name   string
+		routes []testRoute
+		want   []string
+	}{
+		{
+			name: "Lower priority value first, whatever the fee",
+			routes: []testRoute{
+				{name: "fallback", priority: 1, fee: 0.01},
+				{name: "default", priority: 0, fee: 0.15},
+			},
+			want: []string{"default", "fallback"},
+		},
+		{
+			name: "Cheapest first among equal priorities",
+			routes: []testRoute{
+				{name: "fallback", priority: 0, fee: 0.15},
+				{name: "default", priority: 0, fee: 0.05},
+			},
+			want: []string{"default", "fallback"},
+		},
+		{
+			name: "Lowest reported latency first among equal fees",
+			routes: []testRoute{
+				{name: "default", priority: 0, fee: 0.05, health: &domain.ProcessorHealth{MinResponseTime: 80, CheckedAt: time.Now()}},
+				{name: "fallback", priority: 0, fee: 0.05, health: &domain.ProcessorHealth{MinResponseTime: 10, CheckedAt: time.Now()}},
+			},
+			want: []string{"fallback", "default"},
+		},
+		{
+			name: "Open breaker last",
+			routes: []testRoute{
+				{name: "default", priority: 0, fee: 0.05, open: true},
+				{name: "fallback", priority: 1, fee: 0.15},
+			},
+			want: []string{"fallback", "default"},
+		},
+		{
+			name: "Failing health last",
+			routes: []testRoute{
+				{name: "default", priority: 0, fee: 0.05, health: failing},
+				{name: "fallback", priority: 1, fee: 0.15},
+			},
+			want: []string{"fallback", "default"},
+		},
+		{
+			name: "Too slow for the deadline last",
+			routes: []testRoute{
+				{name: "default", priority: 0, fee: 0.05, health: slow},
+				{name: "fallback", priority: 1, fee: 0.15},
+			},
+			want: []string{"fallback", "default"},
+		},
+		{
+			name: "Unavailable routes keep their priority order",
+			routes: []testRoute{
+				{name: "fallback", priority: 1, fee: 0.15, open: true},
+				{name: "default", priority: 0, fee: 0.05, health: failing},
+				{name: "backup", priority: 2, fee: 0.30},
+			},
+			want: []string{"backup", "default", "fallback"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newTestRoutingPolicy(t, 0, 0, tt.routes...)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			got := routeOrder(policy.order(ctx))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got: %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got: %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRoutingPolicy_ShouldWaitFor(t *testing.T) {
+	failing := &domain.ProcessorHealth{Failing: true, CheckedAt: time.Now()}
+
+	tests := []struct {
+		name         string
+		recoveryWait time.Duration
+		feeRatio     float64
+		routes       []testRoute
+		force        bool
+		want         bool
+	}{
+		{
+			name:         "Alternative much more expensive",
+			recoveryWait: time.Second,
+			feeRatio:     2,
+			routes:       []testRoute{{name: "default", fee: 0.05, health: failing}, {name: "fallback", priority: 1, fee: 0.15}},
+			want:         true,
+		},
+		{
+			name:         "Alternative cheap enough",
+			recoveryWait: time.Second,
+			feeRatio:     4,
+			routes:       []testRoute{{name: "default", fee: 0.05, health: failing}, {name: "fallback", priority: 1, fee: 0.15}},
+			want:         false,
+		},
+		{
+			name:         "Cheap alternative unavailable too",
+			recoveryWait: time.Second,
+			feeRatio:     4,
+			routes:       []testRoute{{name: "default", fee: 0.05, health: failing}, {name: "fallback", priority: 1, fee: 0.15, open: true}},
+			want:         true,
+		},
+		{
+			name:     "No recovery wait",
+			feeRatio: 2,
+			routes:   []testRoute{{name: "default", fee: 0.05, health: failing}, {name: "fallback", priority: 1, fee: 0.15}},
+			want:     false,
+		},
+		{
+			name:         "Forced open by an operator",
+			recoveryWait: time.Second,
+			feeRatio:     2,
+			routes:       []testRoute{{name: "default", fee: 0.05}, {name: "fallback", priority: 1, fee: 0.15}},
+			force:        true,
+			want:         false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newTestRoutingPolicy(t, tt.recoveryWait, tt.feeRatio, tt.routes...)
+			preferred := policy.preferred()
+			if tt.force {
+				preferred.circuitBreaker.ForceOpen("maintenance")
+			}
+
+			if got := policy.shouldWaitFor(context.Background(), preferred); got != tt.want {
+				t.Errorf("Expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRoutingPolicy_WaitForRecovery(t *testing.T) {
+	failing := &domain.ProcessorHealth{Failing: true, CheckedAt: time.Now()}
+
+	t.Run("Prefers the cheap processor once it recovers", func(t *testing.T) {
+		policy := newTestRoutingPolicy(t, time.Minute, 2,
+			testRoute{name: "default", fee: 0.05, health: failing},
+			testRoute{name: "fallback", priority: 1, fee: 0.15},
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		ordered := make(chan []string)
+		go func() { ordered <- routeOrder(policy.order(ctx)) }()
+		policy.healthMonitor.UpdateStatus("default", domain.ProcessorHealth{CheckedAt: time.Now().Add(time.Second)})
+
+		// Without the recovery, the wait would last until the context deadline
+		if got := <-ordered; got[0] != "default" || ctx.Err() != nil {
+			t.Errorf("Expected the recovered default first, got: %v", got)
+		}
+	})
+
+	t.Run("Stops waiting when the context is done", func(t *testing.T) {
+		policy := newTestRoutingPolicy(t, time.Minute, 2,
+			testRoute{name: "default", fee: 0.05, health: failing},
+			testRoute{name: "fallback", priority: 1, fee: 0.15},
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ordered := make(chan []string)
+		go func() { ordered <- routeOrder(policy.order(ctx)) }()
+		cancel()
+
+		select {
+		case got := <-ordered:
+			if got[0] != "fallback" {
+				t.Errorf("Expected the fallback first, got: %v", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the wait to stop with the context")
+		}
+	})
+
+	t.Run("Stops waiting after the recovery wait", func(t *testing.T) {
+		policy := newTestRoutingPolicy(t, 10*time.Millisecond, 2,
+			testRoute{name: "default", fee: 0.05, health: failing},
+			testRoute{name: "fallback", priority: 1, fee: 0.15},
+		)
+
+		ordered := make(chan []string)
+		go func() { ordered <- routeOrder(policy.order(context.Background())) }()
+
+		select {
+		case got := <-ordered:
+			if got[0] != "fallback" {
+				t.Errorf("Expected the fallback first, got: %v", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the wait to stop after the recovery wait")
+		}
+	})
+}
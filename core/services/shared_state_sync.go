@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/fabianoflorentino/mr-robot/internal/app/sharedstate"
+)
+
+const (
+	// healthPollerLease elects the instance polling the processors' health endpoints
+	healthPollerLease = "health_poller"
+	// sharedStateBuffer is the number of state changes waiting to be published
+	sharedStateBuffer = 64
+)
+
+// SharedStateSync shares circuit breaker transitions and processor health
+// with the other instances, and elects one instance to poll the rate-limited
+// health endpoints for all of them
+type SharedStateSync struct {
+	repo           repository.SharedStateRepository
+	config         *sharedstate.Config
+	paymentService *PaymentService
+	healthMonitor  *HealthMonitor
+	updates        chan domain.SharedStateUpdate
+	leader         atomic.Bool
+	ctx            context.Context
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
+}
+
+// NewSharedStateSync creates a new shared state sync and hooks it into the
+// payment service breakers and the health monitor, which may be nil. It must
+// be created before the health monitor starts.
+func NewSharedStateSync(
+	repo repository.SharedStateRepository,
+	cfg *sharedstate.Config,
+	paymentService *PaymentService,
+	healthMonitor *HealthMonitor,
+) *SharedStateSync {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &SharedStateSync{
+		repo:           repo,
+		config:         cfg,
+		paymentService: paymentService,
+		healthMonitor:  healthMonitor,
+		updates:        make(chan domain.SharedStateUpdate, sharedStateBuffer),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+
+	paymentService.OnCircuitBreakerStateChange(s.publishCircuitBreaker)
+
+	if healthMonitor != nil {
+		healthMonitor.SetPollGate(s.IsLeader)
+		healthMonitor.OnStatus(s.publishHealth)
+	}
+
+	return s
+}
+
+// Start loads the current shared state and starts publishing, listening and
+// competing for the health poller lease in the background
+func (s *SharedStateSync) Start() {
+	s.load()
+	s.renewLease()
+
+	s.wg.Add(3)
+	go s.publishLoop()
+	go s.listenLoop()
+	go s.leaseLoop()
+}
+
+// Stop stops the background loops and gives up the health poller lease
+func (s *SharedStateSync) Stop() {
+	s.cancel()
+	s.wg.Wait()
+
+	if s.leader.Load() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.PublishTimeout)
+		defer cancel()
+
+		if err := s.repo.ReleaseLease(ctx, healthPollerLease, s.config.InstanceID); err != nil {
+			log.Printf("Failed to release health poller lease: %v", err)
+		}
+	}
+}
+
+// IsLeader reports whether this instance polls the processors' health endpoints
+func (s *SharedStateSync) IsLeader() bool {
+	return s.leader.Load()
+}
+
+// publishCircuitBreaker queues a local breaker transition for the other
+// instances. Half-open is local to each instance and is not shared.
+func (s *SharedStateSync) publishCircuitBreaker(event domain.CircuitBreakerEvent) {
+	if event.Instance != "" || event.To == HalfOpen.String() {
+		return
+	}
+
+	s.enqueue(domain.SharedStateUpdate{CircuitBreaker: &domain.CircuitBreakerUpdate{
+		Instance:  s.config.InstanceID,
+		Processor: event.Processor,
+		State:     event.To,
+		Forced:    event.Forced,
+		Reason:    event.Reason,
+		ChangedAt: event.Timestamp,
+	}})
+}
+
+// publishHealth queues a status polled by this instance for the other instances
+func (s *SharedStateSync) publishHealth(processorName string, status domain.ProcessorHealth) {
+	s.enqueue(domain.SharedStateUpdate{Health: &domain.HealthUpdate{
+		Instance:  s.config.InstanceID,
+		Processor: processorName,
+		Health:    status,
+	}})
+}
+
+// enqueue hands an update to the publish loop without blocking the caller
+func (s *SharedStateSync) enqueue(update domain.SharedStateUpdate) {
+	select {
+	case s.updates <- update:
+	default:
+		log.Printf("Shared state publish queue is full, dropping update")
+	}
+}
+
+// publishLoop writes the queued updates in order
+func (s *SharedStateSync) publishLoop() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case update := <-s.updates:
+			ctx, cancel := context.WithTimeout(s.ctx, s.config.PublishTimeout)
+			if err := s.repo.Publish(ctx, update); err != nil {
+				log.Printf("Failed to publish shared state: %v", err)
+			}
+			cancel()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// listenLoop applies the updates of the other instances, listening again
+// and reloading the state after a lost connection
+func (s *SharedStateSync) listenLoop() {
+	defer s.wg.Done()
+
+	for {
+		err := s.repo.Listen(s.ctx, s.apply)
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("Shared state listener stopped: %v", err)
+
+		select {
+		case <-time.After(s.config.ReconnectDelay):
+			s.load()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// leaseLoop takes or renews the health poller lease three times per TTL
+func (s *SharedStateSync) leaseLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.LeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.renewLease()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// renewLease competes for the health poller lease and records the outcome
+func (s *SharedStateSync) renewLease() {
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.PublishTimeout)
+	defer cancel()
+
+	acquired, err := s.repo.AcquireLease(ctx, healthPollerLease, s.config.InstanceID, s.config.LeaseTTL)
+	if err != nil {
+		log.Printf("Failed to renew health poller lease: %v", err)
+	}
+
+	if s.leader.Swap(acquired) != acquired {
+		if acquired {
+			log.Printf("Instance %s is now polling the processors' health", s.config.InstanceID)
+		} else {
+			log.Printf("Instance %s stopped polling the processors' health", s.config.InstanceID)
+		}
+	}
+}
+
+// load applies the last published state of every processor
+func (s *SharedStateSync) load() {
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.PublishTimeout)
+	defer cancel()
+
+	updates, err := s.repo.Load(ctx)
+	if err != nil {
+		log.Printf("Failed to load shared state: %v", err)
+		return
+	}
+
+	for _, update := range updates {
+		s.applyUpdate(update)
+	}
+}
+
+// apply applies an update published by another instance
+func (s *SharedStateSync) apply(update domain.SharedStateUpdate) {
+	if (update.CircuitBreaker != nil && update.CircuitBreaker.Instance == s.config.InstanceID) ||
+		(update.Health != nil && update.Health.Instance == s.config.InstanceID) {
+		return
+	}
+
+	s.applyUpdate(update)
+}
+
+// applyUpdate applies an update to the local breakers or health statuses
+func (s *SharedStateSync) applyUpdate(update domain.SharedStateUpdate) {
+	if update.CircuitBreaker != nil {
+		s.paymentService.applySharedCircuitBreakerState(*update.CircuitBreaker)
+	}
+
+	if update.Health != nil && s.healthMonitor != nil {
+		s.healthMonitor.UpdateStatus(update.Health.Processor, update.Health.Health)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/internal/app/sharedstate"
+	"github.com/fabianoflorentino/mr-robot/internal/fakeprocessor"
+)
+
+// memorySharedState is an in-memory SharedStateRepository delivering every
+// published update to all listeners, like LISTEN/NOTIFY
+type memorySharedState struct {
+	updates   []domain.SharedStateUpdate
+	listeners map[int]func(domain.SharedStateUpdate)
+	nextID    int
+	leases    map[string]memoryLease
+	mutex     sync.Mutex
+}
+
+type memoryLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+func newMemorySharedState() *memorySharedState {
+	return &memorySharedState{
+		listeners: make(map[int]func(domain.SharedStateUpdate)),
+		leases:    make(map[string]memoryLease),
+	}
+}
+
+func (m *memorySharedState) Publish(ctx context.Context, update domain.SharedStateUpdate) error {
+	m.mutex.Lock()
+	m.updates = append(m.updates, update)
+	listeners := make([]func(domain.SharedStateUpdate), 0, len(m.listeners))
+	for _, listener := range m.listeners {
+		listeners = append(listeners, listener)
+	}
+	m.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener(update)
+	}
+	return nil
+}
+
+// listening returns the number of registered listeners
+func (m *memorySharedState) listening() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.listeners)
+}
+
+func (m *memorySharedState) Load(ctx context.Context) ([]domain.SharedStateUpdate, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]domain.SharedStateUpdate(nil), m.updates...), nil
+}
+
+func (m *memorySharedState) Listen(ctx context.Context, handle func(domain.SharedStateUpdate)) error {
+	m.mutex.Lock()
+	id := m.nextID
+	m.nextID++
+	m.listeners[id] = handle
+	m.mutex.Unlock()
+
+	<-ctx.Done()
+
+	m.mutex.Lock()
+	delete(m.listeners, id)
+	m.mutex.Unlock()
+	return ctx.Err()
+}
+
+func (m *memorySharedState) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	lease, ok := m.leases[name]
+	if ok && lease.holder != holder && time.Now().Before(lease.expiresAt) {
+		return false, nil
+	}
+
+	m.leases[name] = memoryLease{holder: holder, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (m *memorySharedState) ReleaseLease(ctx context.Context, name, holder string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.leases[name].holder == holder {
+		delete(m.leases, name)
+	}
+	return nil
+}
+
+// newTestInstance creates a payment service sharing its state through store
+func newTestInstance(t *testing.T, store *memorySharedState, instanceID string) (*PaymentService, *SharedStateSync) {
+	t.Helper()
+
+	service := paymentServiceFixture{processors: []testProcessor{testDefault(fakeprocessor.Behavior{})}}.start(t).PaymentService
+
+	sync := NewSharedStateSync(store, &sharedstate.Config{
+		Enabled:        true,
+		InstanceID:     instanceID,
+		Channel:        "test",
+		LeaseTTL:       time.Second,
+		ReconnectDelay: 10 * time.Millisecond,
+		PublishTimeout: time.Second,
+	}, service, nil)
+
+	listening := store.listening()
+	sync.Start()
+	t.Cleanup(sync.Stop)
+
+	// Wait for the listener, as updates published before it are not delivered
+	for store.listening() == listening {
+		time.Sleep(time.Millisecond)
+	}
+
+	return service, sync
+}
+
+// waitForState waits until the default processor breaker reaches state
+func waitForState(t *testing.T, service *PaymentService, state string, forced bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		status := service.CircuitBreakers()[0]
+		if status.State == state && status.Forced == forced {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected breaker to become %s (forced %v), got: %+v", state, forced, service.CircuitBreakers()[0])
+}
+
+func TestSharedStateSync(t *testing.T) {
+	store := newMemorySharedState()
+	first, firstSync := newTestInstance(t, store, "api01")
+	second, secondSync := newTestInstance(t, store, "api02")
+
+	t.Run("Shares forced states", func(t *testing.T) {
+		if _, err := first.ForceCircuitBreakerOpen("default", "incident"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		waitForState(t, second, "open", true)
+
+		if _, err := second.ReleaseCircuitBreaker("default", "resolved"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		waitForState(t, first, "closed", false)
+	})
+
+	t.Run("Elects a single health poller", func(t *testing.T) {
+		if firstSync.IsLeader() == secondSync.IsLeader() {
+			t.Errorf("Expected exactly one leader, got: %v and %v", firstSync.IsLeader(), secondSync.IsLeader())
+		}
+	})
+
+	t.Run("New instances load the shared state", func(t *testing.T) {
+		if _, err := first.ForceCircuitBreakerOpen("default", "incident"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		waitForState(t, second, "open", true)
+
+		third, _ := newTestInstance(t, store, "api03")
+		waitForState(t, third, "open", true)
+	})
+}
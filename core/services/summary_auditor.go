@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/fabianoflorentino/mr-robot/internal/app/audit"
+	"github.com/google/uuid"
+)
+
+// SummaryAuditor periodically compares our payment summary with the summary
+// each processor keeps, so differences in the books are noticed early
+type SummaryAuditor struct {
+	repo      repository.PaymentRepository
+	providers []domain.AdminSummaryProvider
+	lookups   map[string]domain.PaymentLookup
+	config    *audit.Config
+	reports   []domain.AuditReport
+	mutex     sync.RWMutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewSummaryAuditor creates a new auditor for the given processors. Lookups are
+// used to list the payments missing at a processor when enabled.
+func NewSummaryAuditor(
+	repo repository.PaymentRepository,
+	cfg *audit.Config,
+	providers []domain.AdminSummaryProvider,
+	lookups []domain.PaymentLookup,
+) *SummaryAuditor {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	a := &SummaryAuditor{
+		repo:      repo,
+		providers: providers,
+		lookups:   make(map[string]domain.PaymentLookup),
+		config:    cfg,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	for _, lookup := range lookups {
+		a.lookups[lookup.ProcessorName()] = lookup
+	}
+
+	return a
+}
+
+// Start launches the periodic audit
+func (a *SummaryAuditor) Start() {
+	a.wg.Add(1)
+	go a.run()
+}
+
+// Stop stops the periodic audit and waits for it to finish
+func (a *SummaryAuditor) Stop() {
+	a.cancel()
+	a.wg.Wait()
+}
+
+// Reports returns the most recent audit reports, newest first
+func (a *SummaryAuditor) Reports() []domain.AuditReport {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	reports := make([]domain.AuditReport, len(a.reports))
+	for i, report := range a.reports {
+		reports[len(a.reports)-1-i] = report
+	}
+
+	return reports
+}
+
+// Audit compares our summary with every processor's summary for the window.
+// A processor whose summary cannot be fetched makes the report incomplete
+// rather than inconsistent.
+func (a *SummaryAuditor) Audit(ctx context.Context, from, to time.Time) (*domain.AuditReport, error) {
+	local, err := a.repo.Summary(ctx, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := domain.AuditReport{
+		From:       from,
+		To:         to,
+		CheckedAt:  time.Now(),
+		Consistent: true,
+	}
+
+	for _, provider := range a.providers {
+		var summary domain.ProcessorSummary
+		if local != nil {
+			summary = (*local)[provider.ProcessorName()]
+		}
+
+		result := a.auditProcessor(ctx, provider, summary, from, to)
+		switch {
+		case result.Remote == nil:
+			report.Incomplete = true
+		case result.RequestsDiff != 0 || result.AmountDiff != 0:
+			report.Consistent = false
+		}
+		report.Processors = append(report.Processors, result)
+	}
+
+	a.store(report)
+	a.log(report)
+
+	return &report, nil
+}
+
+// run audits the latest settled window once per configured interval
+func (a *SummaryAuditor) run() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Payments still in flight would show up as false differences
+			to := time.Now().Add(-a.config.SettleDelay)
+			from := to.Add(-a.config.Window)
+
+			if _, err := a.Audit(a.ctx, from, to); err != nil && a.ctx.Err() == nil {
+				log.Printf("Payment summary audit failed: %v", err)
+			}
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// auditProcessor compares a single processor's summary with ours
+func (a *SummaryAuditor) auditProcessor(ctx context.Context, provider domain.AdminSummaryProvider, local domain.ProcessorSummary, from, to time.Time) domain.ProcessorAudit {
+	result := domain.ProcessorAudit{
+		Processor: provider.ProcessorName(),
+		Local:     local,
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, a.config.RequestTimeout)
+	defer cancel()
+
+	remote, err := provider.AdminSummary(requestCtx, from, to)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Remote = remote
+	result.RequestsDiff = local.TotalRequests - remote.TotalRequests
+	result.AmountDiff = math.Round((local.TotalAmount-remote.TotalAmount)*100) / 100
+
+	if a.config.ListMismatches && result.RequestsDiff > 0 {
+		result.MissingAtProcessor = a.missingAtProcessor(ctx, provider.ProcessorName(), from, to)
+	}
+
+	return result
+}
+
+// missingAtProcessor looks up our payments at the processor and returns the
+// ones it does not know about, bounded by the configured number of lookups
+func (a *SummaryAuditor) missingAtProcessor(ctx context.Context, processorName string, from, to time.Time) []uuid.UUID {
+	lookup, ok := a.lookups[processorName]
+	if !ok || a.config.MaxLookups == 0 {
+		return nil
+	}
+
+	ids, err := a.repo.CorrelationIDs(ctx, processorName, from, to, a.config.MaxLookups)
+	if err != nil {
+		log.Printf("Failed to load correlation ids for %s: %v", processorName, err)
+		return nil
+	}
+
+	var missing []uuid.UUID
+	for _, id := range ids {
+		requestCtx, cancel := context.WithTimeout(ctx, a.config.RequestTimeout)
+		found, err := lookup.LookupPayment(requestCtx, id)
+		cancel()
+
+		if err == nil && !found {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing
+}
+
+// store keeps the report in the bounded history
+func (a *SummaryAuditor) store(report domain.AuditReport) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.reports = append(a.reports, report)
+	if len(a.reports) > a.config.HistorySize {
+		a.reports = a.reports[len(a.reports)-a.config.HistorySize:]
+	}
+}
+
+// log writes the differences found by an audit and the processors left out
+func (a *SummaryAuditor) log(report domain.AuditReport) {
+	var unavailable []string
+	for _, p := range report.Processors {
+		switch {
+		case p.Remote == nil:
+			unavailable = append(unavailable, p.Processor)
+		case p.RequestsDiff != 0 || p.AmountDiff != 0:
+			log.Printf("Payment summary audit: %s differs by %d requests and %.2f amount (missing at processor: %v)",
+				p.Processor, p.RequestsDiff, p.AmountDiff, p.MissingAtProcessor)
+		}
+	}
+
+	if !report.Consistent {
+		return
+	}
+
+	window := report.From.Format(time.RFC3339) + " - " + report.To.Format(time.RFC3339)
+	if report.Incomplete {
+		log.Printf("Payment summary audit %s: consistent, without the summary of %v", window, unavailable)
+		return
+	}
+	log.Printf("Payment summary audit %s: consistent", window)
+}
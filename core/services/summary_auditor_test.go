@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/fabianoflorentino/mr-robot/internal/app/audit"
+)
+
+// stubSummaryProvider answers every admin summary request with the same outcome
+type stubSummaryProvider struct {
+	name    string
+	summary *domain.ProcessorSummary
+	err     error
+}
+
+func (p *stubSummaryProvider) AdminSummary(ctx context.Context, from, to time.Time) (*domain.ProcessorSummary, error) {
+	return p.summary, p.err
+}
+
+func (p *stubSummaryProvider) ProcessorName() string {
+	return p.name
+}
+
+// noSummaryRepository is a repository returning no summary at all
+type noSummaryRepository struct {
+	*memoryRepository
+}
+
+func (r noSummaryRepository) Summary(ctx context.Context, from, to *time.Time) (*domain.PaymentSummary, error) {
+	return nil, nil
+}
+
+// newTestSummaryAuditor creates an auditor comparing repo with the given providers
+func newTestSummaryAuditor(repo repository.PaymentRepository, providers ...domain.AdminSummaryProvider) *SummaryAuditor {
+	return NewSummaryAuditor(repo, &audit.Config{HistorySize: 10, RequestTimeout: time.Second}, providers, nil)
+}
+
+func TestSummaryAuditor_Audit(t *testing.T) {
+	t.Run("Reports a difference as inconsistent", func(t *testing.T) {
+		repo := newMemoryRepository()
+		repo.Process(context.Background(), newTestPayment(), "default")
+
+		auditor := newTestSummaryAuditor(repo, &stubSummaryProvider{name: "default", summary: &domain.ProcessorSummary{}})
+
+		report, err := auditor.Audit(context.Background(), time.Now().Add(-time.Minute), time.Now())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if report.Consistent || report.Incomplete {
+			t.Errorf("Expected an inconsistent, complete report, got: %+v", report)
+		}
+		if report.Processors[0].RequestsDiff != 1 {
+			t.Errorf("Expected 1 request missing at the processor, got: %d", report.Processors[0].RequestsDiff)
+		}
+	})
+
+	t.Run("Reports a summary it cannot fetch as incomplete", func(t *testing.T) {
+		auditor := newTestSummaryAuditor(newMemoryRepository(),
+			&stubSummaryProvider{name: "default", summary: &domain.ProcessorSummary{}},
+			&stubSummaryProvider{name: "fallback", err: core.ErrProcessorTimeout},
+		)
+
+		report, err := auditor.Audit(context.Background(), time.Now().Add(-time.Minute), time.Now())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !report.Consistent || !report.Incomplete {
+			t.Errorf("Expected a consistent, incomplete report, got: %+v", report)
+		}
+		if report.Processors[1].Error == "" {
+			t.Error("Expected the fetch error in the fallback audit")
+		}
+	})
+
+	t.Run("Compares with empty books when the repository has no summary", func(t *testing.T) {
+		auditor := newTestSummaryAuditor(noSummaryRepository{newMemoryRepository()},
+			&stubSummaryProvider{name: "default", summary: &domain.ProcessorSummary{TotalRequests: 1, TotalAmount: 19.9}},
+		)
+
+		report, err := auditor.Audit(context.Background(), time.Now().Add(-time.Minute), time.Now())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if report.Consistent || report.Processors[0].RequestsDiff != -1 {
+			t.Errorf("Expected 1 request missing in our books, got: %+v", report)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+)
+
+// TokenBucket limits the requests per second sent to a processor. Tokens are
+// issued at a steady rate up to a burst size; each request takes one.
+type TokenBucket struct {
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	mutex       sync.Mutex
+	now         func() time.Time
+}
+
+// NewTokenBucket creates a full bucket issuing rate tokens per second and
+// holding at most burst tokens
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Wait takes a token, waiting for it when the next one is issued before the
+// context deadline. It returns core.ErrProcessorThrottled as soon as the
+// token would come too late, so the caller can try another processor. A
+// pause started while waiting is waited out too, and the token then comes
+// after the pause as late as it would have come without it.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	b.mutex.Lock()
+	now := b.now()
+	b.refill(now)
+
+	b.tokens--
+	if b.tokens >= 0 {
+		b.mutex.Unlock()
+		return nil
+	}
+
+	// The token is reserved now and becomes available after the delay
+	debt := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	delay := debt
+	if b.pausedUntil.After(now) {
+		delay += b.pausedUntil.Sub(now)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		b.tokens++
+		b.mutex.Unlock()
+		return core.ErrProcessorThrottled
+	}
+	b.mutex.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			b.mutex.Lock()
+			b.tokens++
+			b.mutex.Unlock()
+			return ctx.Err()
+		}
+
+		b.mutex.Lock()
+		now := b.now()
+		if !b.pausedUntil.After(now) {
+			b.mutex.Unlock()
+			return nil
+		}
+
+		// Paused while waiting, so no token is issued until the pause ends
+		// and the reserved one comes after those issued before it
+		ready := b.pausedUntil.Add(debt)
+		if deadline, ok := ctx.Deadline(); ok && ready.After(deadline) {
+			b.tokens++
+			b.mutex.Unlock()
+			return core.ErrProcessorThrottled
+		}
+		timer.Reset(ready.Sub(now))
+		b.mutex.Unlock()
+	}
+}
+
+// Pause stops issuing tokens for the given duration and drops the tokens
+// left, following a processor's Retry-After
+func (b *TokenBucket) Pause(d time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.now()
+	b.refill(now)
+
+	if until := now.Add(d); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+	b.tokens = math.Min(b.tokens, 0)
+}
+
+// refill issues the tokens accrued since the last refill, outside pauses
+func (b *TokenBucket) refill(now time.Time) {
+	from := b.last
+	if b.pausedUntil.After(from) {
+		from = b.pausedUntil
+	}
+
+	if now.After(from) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(from).Seconds()*b.rate)
+	}
+
+	if now.After(b.last) {
+		b.last = now
+	}
+}
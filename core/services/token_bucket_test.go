@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+)
+
+// newTestTokenBucket creates a token bucket on a clock the test advances
+func newTestTokenBucket(rate float64, burst int) (*TokenBucket, *time.Time) {
+	now := time.Now()
+	b := NewTokenBucket(rate, burst)
+	b.last = now
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Run("Allows a burst, then throttles", func(t *testing.T) {
+		b, _ := newTestTokenBucket(1, 3)
+
+		for i := range 3 {
+			if err := b.Wait(context.Background()); err != nil {
+				t.Fatalf("Expected token %d of the burst, got: %v", i+1, err)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		if err := b.Wait(ctx); !errors.Is(err, core.ErrProcessorThrottled) {
+			t.Errorf("Expected throttled error, got: %v", err)
+		}
+	})
+
+	t.Run("Refills at the configured rate", func(t *testing.T) {
+		b, now := newTestTokenBucket(10, 1)
+		b.Wait(context.Background())
+
+		*now = now.Add(100 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+
+		if err := b.Wait(ctx); err != nil {
+			t.Errorf("Expected a refilled token, got: %v", err)
+		}
+	})
+
+	t.Run("Waits for a token within the deadline", func(t *testing.T) {
+		b := NewTokenBucket(50, 1)
+		b.Wait(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		start := time.Now()
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("Expected to wait for a token, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+			t.Errorf("Expected to wait about 20ms, waited: %v", elapsed)
+		}
+	})
+
+	t.Run("Returns the token when cancelled", func(t *testing.T) {
+		b, _ := newTestTokenBucket(1, 1)
+		b.Wait(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected cancelled error, got: %v", err)
+		}
+		if b.tokens != 0 {
+			t.Errorf("Expected the reserved token back, got %v tokens", b.tokens)
+		}
+	})
+
+	t.Run("Pauses token issue", func(t *testing.T) {
+		b, now := newTestTokenBucket(10, 5)
+		b.Pause(2 * time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if err := b.Wait(ctx); !errors.Is(err, core.ErrProcessorThrottled) {
+			t.Fatalf("Expected throttled error during the pause, got: %v", err)
+		}
+
+		*now = now.Add(2 * time.Second)
+		if err := b.Wait(ctx); !errors.Is(err, core.ErrProcessorThrottled) {
+			t.Fatalf("Expected no token issued during the pause, got: %v", err)
+		}
+
+		*now = now.Add(100 * time.Millisecond)
+		if err := b.Wait(ctx); err != nil {
+			t.Errorf("Expected a token after the pause, got: %v", err)
+		}
+	})
+
+	t.Run("Throttles a waiter when a pause outlasts its deadline", func(t *testing.T) {
+		b, _ := newTestTokenBucket(50, 1)
+		b.Wait(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		waited := make(chan error)
+		go func() { waited <- b.Wait(ctx) }()
+		waitForWaiter(t, b)
+
+		b.Pause(time.Hour)
+
+		if err := <-waited; !errors.Is(err, core.ErrProcessorThrottled) {
+			t.Fatalf("Expected throttled error after the pause, got: %v", err)
+		}
+		if b.tokens != 0 {
+			t.Errorf("Expected the reserved token back, got %v tokens", b.tokens)
+		}
+	})
+
+	t.Run("Waits out a pause started while waiting, then its reserved delay", func(t *testing.T) {
+		b := NewTokenBucket(50, 1)
+		b.Wait(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		waited := make(chan error)
+		go func() { waited <- b.Wait(ctx) }()
+		waitForWaiter(t, b)
+
+		b.Pause(50 * time.Millisecond)
+		b.mutex.Lock()
+		pausedUntil := b.pausedUntil
+		b.mutex.Unlock()
+
+		if err := <-waited; err != nil {
+			t.Fatalf("Expected a token after the pause, got: %v", err)
+		}
+		// The token reserved at 50 per second comes 20ms after the pause
+		if ready := pausedUntil.Add(20 * time.Millisecond); time.Now().Before(ready) {
+			t.Errorf("Expected the waiter to return %v after the pause, returned %v early", 20*time.Millisecond, time.Until(ready))
+		}
+	})
+}
+
+// waitForWaiter waits until a token is reserved by a waiter
+func waitForWaiter(t *testing.T, b *TokenBucket) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		b.mutex.Lock()
+		waiting := b.tokens < 0
+		b.mutex.Unlock()
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("Expected a waiter")
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"fmt"
+	"os"
+)
+
+// minTokenLength is the shortest admin token accepted
+const minTokenLength = 8
+
+// Config holds admin API configuration
+type Config struct {
+	// Token authenticates admin requests; the admin API is disabled when empty
+	Token string
+}
+
+// ConfigManager manages admin API configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new admin API configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads admin API configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	cm.config = &Config{
+		Token: getEnvOrDefault("ADMIN_API_TOKEN", ""),
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded admin API configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the admin API configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("admin configuration not loaded")
+	}
+
+	if cm.config.Token != "" && len(cm.config.Token) < minTokenLength {
+		return fmt.Errorf("admin API token must be at least %d characters", minTokenLength)
+	}
+
+	return nil
+}
+
+// Enabled reports whether the admin API is enabled
+func (c *Config) Enabled() bool {
+	return c != nil && c.Token != ""
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
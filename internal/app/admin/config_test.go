@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	original := os.Getenv("ADMIN_API_TOKEN")
+
+	// Cleanup function
+	defer func() {
+		if original == "" {
+			os.Unsetenv("ADMIN_API_TOKEN")
+		} else {
+			os.Setenv("ADMIN_API_TOKEN", original)
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		os.Unsetenv("ADMIN_API_TOKEN")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if cm.GetConfig().Enabled() {
+			t.Error("Expected admin API to be disabled by default")
+		}
+	})
+
+	t.Run("Custom token", func(t *testing.T) {
+		os.Setenv("ADMIN_API_TOKEN", "s3cr3t-token")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if !config.Enabled() || config.Token != "s3cr3t-token" {
+			t.Errorf("Expected admin API to be enabled with the token, got: %+v", config)
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{})
+
+		if err := cm.Validate(); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Short token", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Token: "123"})
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for short token")
+		}
+	})
+
+	t.Run("Not loaded", func(t *testing.T) {
+		cm := NewConfigManager()
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error when configuration is not loaded")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the processor summary audit configuration
+type Config struct {
+	Enabled        bool
+	Interval       time.Duration
+	Window         time.Duration
+	SettleDelay    time.Duration
+	AdminToken     string
+	ListMismatches bool
+	MaxLookups     int
+	HistorySize    int
+	RequestTimeout time.Duration
+}
+
+// ConfigManager manages audit configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new audit configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads audit configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	enabled, err := strconv.ParseBool(getEnvOrDefault("AUDIT_ENABLED", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid AUDIT_ENABLED value: %w", err)
+	}
+
+	interval, err := time.ParseDuration(getEnvOrDefault("AUDIT_INTERVAL", "1m"))
+	if err != nil {
+		return fmt.Errorf("invalid AUDIT_INTERVAL value: %w", err)
+	}
+
+	window, err := time.ParseDuration(getEnvOrDefault("AUDIT_WINDOW", "1m"))
+	if err != nil {
+		return fmt.Errorf("invalid AUDIT_WINDOW value: %w", err)
+	}
+
+	settleDelay, err := time.ParseDuration(getEnvOrDefault("AUDIT_SETTLE_DELAY", "10s"))
+	if err != nil {
+		return fmt.Errorf("invalid AUDIT_SETTLE_DELAY value: %w", err)
+	}
+
+	listMismatches, err := strconv.ParseBool(getEnvOrDefault("AUDIT_LIST_MISMATCHES", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid AUDIT_LIST_MISMATCHES value: %w", err)
+	}
+
+	maxLookups, err := strconv.Atoi(getEnvOrDefault("AUDIT_MAX_LOOKUPS", "100"))
+	if err != nil {
+		return fmt.Errorf("invalid AUDIT_MAX_LOOKUPS value: %w", err)
+	}
+
+	historySize, err := strconv.Atoi(getEnvOrDefault("AUDIT_HISTORY_SIZE", "20"))
+	if err != nil {
+		return fmt.Errorf("invalid AUDIT_HISTORY_SIZE value: %w", err)
+	}
+
+	requestTimeout, err := time.ParseDuration(getEnvOrDefault("AUDIT_REQUEST_TIMEOUT", "5s"))
+	if err != nil {
+		return fmt.Errorf("invalid AUDIT_REQUEST_TIMEOUT value: %w", err)
+	}
+
+	cm.config = &Config{
+		Enabled:        enabled,
+		Interval:       interval,
+		Window:         window,
+		SettleDelay:    settleDelay,
+		AdminToken:     os.Getenv("AUDIT_ADMIN_TOKEN"),
+		ListMismatches: listMismatches,
+		MaxLookups:     maxLookups,
+		HistorySize:    historySize,
+		RequestTimeout: requestTimeout,
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded audit configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the audit configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("audit configuration not loaded")
+	}
+
+	if !cm.config.Enabled {
+		return nil
+	}
+
+	if cm.config.AdminToken == "" {
+		return fmt.Errorf("audit admin token is required when the audit is enabled")
+	}
+
+	if cm.config.Interval <= 0 || cm.config.Window <= 0 {
+		return fmt.Errorf("audit interval and window must be greater than 0")
+	}
+
+	if cm.config.SettleDelay < 0 {
+		return fmt.Errorf("audit settle delay cannot be negative")
+	}
+
+	if cm.config.MaxLookups < 0 {
+		return fmt.Errorf("audit max lookups cannot be negative")
+	}
+
+	if cm.config.HistorySize <= 0 {
+		return fmt.Errorf("audit history size must be greater than 0")
+	}
+
+	if cm.config.RequestTimeout <= 0 {
+		return fmt.Errorf("audit request timeout must be greater than 0")
+	}
+
+	return nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"AUDIT_ENABLED":         os.Getenv("AUDIT_ENABLED"),
+		"AUDIT_INTERVAL":        os.Getenv("AUDIT_INTERVAL"),
+		"AUDIT_ADMIN_TOKEN":     os.Getenv("AUDIT_ADMIN_TOKEN"),
+		"AUDIT_LIST_MISMATCHES": os.Getenv("AUDIT_LIST_MISMATCHES"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.Enabled {
+			t.Error("Expected audit to be disabled by default")
+		}
+		if config.Interval != time.Minute {
+			t.Errorf("Expected interval to be 1m, got: %v", config.Interval)
+		}
+		if config.ListMismatches {
+			t.Error("Expected mismatch listing to be disabled by default")
+		}
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected disabled audit to be valid, got: %v", err)
+		}
+	})
+
+	t.Run("Enabled without token", func(t *testing.T) {
+		os.Setenv("AUDIT_ENABLED", "true")
+		os.Unsetenv("AUDIT_ADMIN_TOKEN")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for enabled audit without admin token")
+		}
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		os.Setenv("AUDIT_INTERVAL", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid interval value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			Enabled:        true,
+			Interval:       time.Minute,
+			Window:         time.Minute,
+			SettleDelay:    10 * time.Second,
+			AdminToken:     "token",
+			MaxLookups:     100,
+			HistorySize:    20,
+			RequestTimeout: 5 * time.Second,
+		}
+	}
+
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(valid())
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid history size", func(t *testing.T) {
+		config := valid()
+		config.HistorySize = 0
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for invalid history size")
+		}
+	})
+
+	t.Run("Nil config", func(t *testing.T) {
+		cm := NewConfigManager()
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for nil config")
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package bulkhead
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the processor bulkhead configuration. Each processor gets its
+// own bulkhead with these limits unless its registration overrides them.
+type Config struct {
+	Enabled bool
+	// MaxConcurrent is the number of calls a processor may have in flight
+	MaxConcurrent int
+	// MaxQueue is the number of calls that may wait for a free slot
+	MaxQueue int
+	// MaxWait bounds the time a call waits in the queue; 0 waits until the processing deadline
+	MaxWait time.Duration
+}
+
+// ConfigManager manages bulkhead configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new bulkhead configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads bulkhead configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	enabled, err := strconv.ParseBool(getEnvOrDefault("BULKHEAD_ENABLED", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid BULKHEAD_ENABLED value: %w", err)
+	}
+
+	maxConcurrent, err := strconv.Atoi(getEnvOrDefault("BULKHEAD_MAX_CONCURRENT", "10"))
+	if err != nil {
+		return fmt.Errorf("invalid BULKHEAD_MAX_CONCURRENT value: %w", err)
+	}
+
+	maxQueue, err := strconv.Atoi(getEnvOrDefault("BULKHEAD_MAX_QUEUE", "50"))
+	if err != nil {
+		return fmt.Errorf("invalid BULKHEAD_MAX_QUEUE value: %w", err)
+	}
+
+	maxWait, err := time.ParseDuration(getEnvOrDefault("BULKHEAD_MAX_WAIT", "0s"))
+	if err != nil {
+		return fmt.Errorf("invalid BULKHEAD_MAX_WAIT value: %w", err)
+	}
+
+	cm.config = &Config{
+		Enabled:       enabled,
+		MaxConcurrent: maxConcurrent,
+		MaxQueue:      maxQueue,
+		MaxWait:       maxWait,
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded bulkhead configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the bulkhead configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("bulkhead configuration not loaded")
+	}
+
+	if !cm.config.Enabled {
+		return nil
+	}
+
+	if cm.config.MaxConcurrent <= 0 {
+		return fmt.Errorf("bulkhead max concurrent must be greater than 0")
+	}
+
+	if cm.config.MaxQueue < 0 {
+		return fmt.Errorf("bulkhead max queue cannot be negative")
+	}
+
+	if cm.config.MaxWait < 0 {
+		return fmt.Errorf("bulkhead max wait cannot be negative")
+	}
+
+	return nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
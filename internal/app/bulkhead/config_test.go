@@ -0,0 +1,124 @@
+package bulkhead
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"BULKHEAD_ENABLED":        os.Getenv("BULKHEAD_ENABLED"),
+		"BULKHEAD_MAX_CONCURRENT": os.Getenv("BULKHEAD_MAX_CONCURRENT"),
+		"BULKHEAD_MAX_QUEUE":      os.Getenv("BULKHEAD_MAX_QUEUE"),
+		"BULKHEAD_MAX_WAIT":       os.Getenv("BULKHEAD_MAX_WAIT"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.Enabled {
+			t.Error("Expected bulkheads to be disabled by default")
+		}
+		if config.MaxConcurrent != 10 {
+			t.Errorf("Expected max concurrent to be 10, got: %d", config.MaxConcurrent)
+		}
+		if config.MaxQueue != 50 {
+			t.Errorf("Expected max queue to be 50, got: %d", config.MaxQueue)
+		}
+		if config.MaxWait != 0 {
+			t.Errorf("Expected max wait to be 0, got: %v", config.MaxWait)
+		}
+	})
+
+	t.Run("Custom values", func(t *testing.T) {
+		os.Setenv("BULKHEAD_ENABLED", "true")
+		os.Setenv("BULKHEAD_MAX_CONCURRENT", "4")
+		os.Setenv("BULKHEAD_MAX_WAIT", "50ms")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if !config.Enabled || config.MaxConcurrent != 4 || config.MaxWait != 50*time.Millisecond {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("Invalid max queue", func(t *testing.T) {
+		os.Setenv("BULKHEAD_MAX_QUEUE", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid max queue value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: true, MaxConcurrent: 10, MaxQueue: 0})
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Disabled config is not checked", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: false})
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Zero max concurrent", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: true, MaxConcurrent: 0, MaxQueue: 10})
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for zero max concurrent")
+		}
+	})
+
+	t.Run("Negative max queue", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: true, MaxConcurrent: 10, MaxQueue: -1})
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for negative max queue")
+		}
+	})
+
+	t.Run("Nil config", func(t *testing.T) {
+		cm := NewConfigManager()
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for nil config")
+		}
+	})
+}
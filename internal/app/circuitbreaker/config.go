@@ -7,12 +7,46 @@ import (
 	"time"
 )
 
+// Circuit breaker modes
+const (
+	// ModeConsecutive opens the circuit after MaxFailures failures in a row
+	ModeConsecutive = "consecutive"
+	// ModeSlidingWindow opens the circuit on the failure or slow call rate of a sliding window
+	ModeSlidingWindow = "sliding_window"
+)
+
+// Sliding window types
+const (
+	WindowCount = "count"
+	WindowTime  = "time"
+)
+
 // Config holds circuit breaker configuration
 type Config struct {
 	Timeout      time.Duration
 	ResetTimeout time.Duration
 	MaxFailures  int
 	RateLimit    int
+	// HalfOpenMaxCalls is the number of probe calls allowed while half-open
+	HalfOpenMaxCalls int
+	// Mode selects how a closed circuit decides to open
+	Mode string
+	// WindowType is the sliding window kind, count or time based
+	WindowType string
+	// WindowSize is the number of calls in a count based window
+	WindowSize int
+	// WindowDuration is the time span of a time based window
+	WindowDuration time.Duration
+	// FailureRateThreshold is the failure rate, from 0 to 1, that opens the circuit
+	FailureRateThreshold float64
+	// SlowCallRateThreshold is the slow call rate, from 0 to 1, that opens the circuit
+	SlowCallRateThreshold float64
+	// SlowCallDuration is the duration from which a call is slow; 0 disables it
+	SlowCallDuration time.Duration
+	// MinimumCalls is the number of calls the window needs before rates are evaluated
+	MinimumCalls int
+	// EventHistorySize is the number of state changes kept per processor
+	EventHistorySize int
 }
 
 // ConfigManager manages circuit breaker configuration
@@ -47,11 +81,61 @@ func (cm *ConfigManager) LoadConfig() error {
 		return fmt.Errorf("invalid CIRCUIT_BREAKER_RATE_LIMIT value: %w", err)
 	}
 
+	halfOpenMaxCalls, err := strconv.Atoi(getEnvOrDefault("CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS", "1"))
+	if err != nil {
+		return fmt.Errorf("invalid CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS value: %w", err)
+	}
+
+	windowSize, err := strconv.Atoi(getEnvOrDefault("CIRCUIT_BREAKER_WINDOW_SIZE", "100"))
+	if err != nil {
+		return fmt.Errorf("invalid CIRCUIT_BREAKER_WINDOW_SIZE value: %w", err)
+	}
+
+	windowDuration, err := time.ParseDuration(getEnvOrDefault("CIRCUIT_BREAKER_WINDOW_DURATION", "10s"))
+	if err != nil {
+		return fmt.Errorf("invalid CIRCUIT_BREAKER_WINDOW_DURATION value: %w", err)
+	}
+
+	failureRateThreshold, err := strconv.ParseFloat(getEnvOrDefault("CIRCUIT_BREAKER_FAILURE_RATE_THRESHOLD", "0.5"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid CIRCUIT_BREAKER_FAILURE_RATE_THRESHOLD value: %w", err)
+	}
+
+	slowCallRateThreshold, err := strconv.ParseFloat(getEnvOrDefault("CIRCUIT_BREAKER_SLOW_CALL_RATE_THRESHOLD", "1"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid CIRCUIT_BREAKER_SLOW_CALL_RATE_THRESHOLD value: %w", err)
+	}
+
+	slowCallDuration, err := time.ParseDuration(getEnvOrDefault("CIRCUIT_BREAKER_SLOW_CALL_DURATION", "0s"))
+	if err != nil {
+		return fmt.Errorf("invalid CIRCUIT_BREAKER_SLOW_CALL_DURATION value: %w", err)
+	}
+
+	minimumCalls, err := strconv.Atoi(getEnvOrDefault("CIRCUIT_BREAKER_MINIMUM_CALLS", "20"))
+	if err != nil {
+		return fmt.Errorf("invalid CIRCUIT_BREAKER_MINIMUM_CALLS value: %w", err)
+	}
+
+	eventHistorySize, err := strconv.Atoi(getEnvOrDefault("CIRCUIT_BREAKER_EVENT_HISTORY", "50"))
+	if err != nil {
+		return fmt.Errorf("invalid CIRCUIT_BREAKER_EVENT_HISTORY value: %w", err)
+	}
+
 	cm.config = &Config{
-		Timeout:      timeout,
-		ResetTimeout: resetTimeout,
-		MaxFailures:  maxFailures,
-		RateLimit:    rateLimit,
+		Timeout:               timeout,
+		ResetTimeout:          resetTimeout,
+		MaxFailures:           maxFailures,
+		RateLimit:             rateLimit,
+		HalfOpenMaxCalls:      halfOpenMaxCalls,
+		Mode:                  getEnvOrDefault("CIRCUIT_BREAKER_MODE", ModeConsecutive),
+		WindowType:            getEnvOrDefault("CIRCUIT_BREAKER_WINDOW_TYPE", WindowCount),
+		WindowSize:            windowSize,
+		WindowDuration:        windowDuration,
+		FailureRateThreshold:  failureRateThreshold,
+		SlowCallRateThreshold: slowCallRateThreshold,
+		SlowCallDuration:      slowCallDuration,
+		MinimumCalls:          minimumCalls,
+		EventHistorySize:      eventHistorySize,
 	}
 
 	return nil
@@ -89,6 +173,60 @@ func (cm *ConfigManager) Validate() error {
 		return fmt.Errorf("circuit breaker rate limit must be greater than 0")
 	}
 
+	if cm.config.HalfOpenMaxCalls <= 0 {
+		return fmt.Errorf("circuit breaker half-open max calls must be greater than 0")
+	}
+
+	if cm.config.EventHistorySize <= 0 {
+		return fmt.Errorf("circuit breaker event history must be greater than 0")
+	}
+
+	switch cm.config.Mode {
+	case ModeConsecutive:
+		return nil
+	case ModeSlidingWindow:
+		return cm.validateSlidingWindow()
+	default:
+		return fmt.Errorf("circuit breaker mode must be %s or %s", ModeConsecutive, ModeSlidingWindow)
+	}
+}
+
+// validateSlidingWindow validates the sliding window settings
+func (cm *ConfigManager) validateSlidingWindow() error {
+	switch cm.config.WindowType {
+	case WindowCount:
+		if cm.config.WindowSize <= 0 {
+			return fmt.Errorf("circuit breaker window size must be greater than 0")
+		}
+	case WindowTime:
+		if cm.config.WindowDuration <= 0 {
+			return fmt.Errorf("circuit breaker window duration must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("circuit breaker window type must be %s or %s", WindowCount, WindowTime)
+	}
+
+	if cm.config.FailureRateThreshold <= 0 || cm.config.FailureRateThreshold > 1 {
+		return fmt.Errorf("circuit breaker failure rate threshold must be between 0 and 1")
+	}
+
+	if cm.config.SlowCallRateThreshold <= 0 || cm.config.SlowCallRateThreshold > 1 {
+		return fmt.Errorf("circuit breaker slow call rate threshold must be between 0 and 1")
+	}
+
+	if cm.config.SlowCallDuration < 0 {
+		return fmt.Errorf("circuit breaker slow call duration cannot be negative")
+	}
+
+	if cm.config.MinimumCalls <= 0 {
+		return fmt.Errorf("circuit breaker minimum calls must be greater than 0")
+	}
+
+	// A count window never holds more calls than its size, so the breaker could never trip
+	if cm.config.WindowType == WindowCount && cm.config.MinimumCalls > cm.config.WindowSize {
+		return fmt.Errorf("circuit breaker minimum calls cannot exceed the window size")
+	}
+
 	return nil
 }
 
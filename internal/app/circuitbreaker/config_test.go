@@ -0,0 +1,235 @@
+package circuitbreaker
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"CIRCUIT_BREAKER_TIMEOUT":                  os.Getenv("CIRCUIT_BREAKER_TIMEOUT"),
+		"CIRCUIT_BREAKER_RESET_TIMEOUT":            os.Getenv("CIRCUIT_BREAKER_RESET_TIMEOUT"),
+		"CIRCUIT_BREAKER_MAX_FAILURES":             os.Getenv("CIRCUIT_BREAKER_MAX_FAILURES"),
+		"CIRCUIT_BREAKER_RATE_LIMIT":               os.Getenv("CIRCUIT_BREAKER_RATE_LIMIT"),
+		"CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS":      os.Getenv("CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS"),
+		"CIRCUIT_BREAKER_MODE":                     os.Getenv("CIRCUIT_BREAKER_MODE"),
+		"CIRCUIT_BREAKER_WINDOW_TYPE":              os.Getenv("CIRCUIT_BREAKER_WINDOW_TYPE"),
+		"CIRCUIT_BREAKER_WINDOW_SIZE":              os.Getenv("CIRCUIT_BREAKER_WINDOW_SIZE"),
+		"CIRCUIT_BREAKER_WINDOW_DURATION":          os.Getenv("CIRCUIT_BREAKER_WINDOW_DURATION"),
+		"CIRCUIT_BREAKER_FAILURE_RATE_THRESHOLD":   os.Getenv("CIRCUIT_BREAKER_FAILURE_RATE_THRESHOLD"),
+		"CIRCUIT_BREAKER_SLOW_CALL_RATE_THRESHOLD": os.Getenv("CIRCUIT_BREAKER_SLOW_CALL_RATE_THRESHOLD"),
+		"CIRCUIT_BREAKER_SLOW_CALL_DURATION":       os.Getenv("CIRCUIT_BREAKER_SLOW_CALL_DURATION"),
+		"CIRCUIT_BREAKER_MINIMUM_CALLS":            os.Getenv("CIRCUIT_BREAKER_MINIMUM_CALLS"),
+		"CIRCUIT_BREAKER_EVENT_HISTORY":            os.Getenv("CIRCUIT_BREAKER_EVENT_HISTORY"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.Mode != ModeConsecutive {
+			t.Errorf("Expected mode to be %s, got: %s", ModeConsecutive, config.Mode)
+		}
+		if config.HalfOpenMaxCalls != 1 {
+			t.Errorf("Expected half-open max calls to be 1, got: %d", config.HalfOpenMaxCalls)
+		}
+		if config.WindowType != WindowCount {
+			t.Errorf("Expected window type to be %s, got: %s", WindowCount, config.WindowType)
+		}
+		if config.WindowSize != 100 {
+			t.Errorf("Expected window size to be 100, got: %d", config.WindowSize)
+		}
+		if config.FailureRateThreshold != 0.5 {
+			t.Errorf("Expected failure rate threshold to be 0.5, got: %v", config.FailureRateThreshold)
+		}
+		if config.MinimumCalls != 20 {
+			t.Errorf("Expected minimum calls to be 20, got: %d", config.MinimumCalls)
+		}
+		if config.EventHistorySize != 50 {
+			t.Errorf("Expected event history to be 50, got: %d", config.EventHistorySize)
+		}
+	})
+
+	t.Run("Sliding window values", func(t *testing.T) {
+		os.Setenv("CIRCUIT_BREAKER_MODE", ModeSlidingWindow)
+		os.Setenv("CIRCUIT_BREAKER_WINDOW_TYPE", WindowTime)
+		os.Setenv("CIRCUIT_BREAKER_WINDOW_DURATION", "30s")
+		os.Setenv("CIRCUIT_BREAKER_SLOW_CALL_DURATION", "300ms")
+		os.Setenv("CIRCUIT_BREAKER_SLOW_CALL_RATE_THRESHOLD", "0.8")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.Mode != ModeSlidingWindow || config.WindowType != WindowTime {
+			t.Errorf("Expected time based sliding window, got: %s/%s", config.Mode, config.WindowType)
+		}
+		if config.WindowDuration != 30*time.Second {
+			t.Errorf("Expected window duration to be 30s, got: %v", config.WindowDuration)
+		}
+		if config.SlowCallDuration != 300*time.Millisecond {
+			t.Errorf("Expected slow call duration to be 300ms, got: %v", config.SlowCallDuration)
+		}
+		if config.SlowCallRateThreshold != 0.8 {
+			t.Errorf("Expected slow call rate threshold to be 0.8, got: %v", config.SlowCallRateThreshold)
+		}
+	})
+
+	t.Run("Invalid failure rate threshold", func(t *testing.T) {
+		os.Setenv("CIRCUIT_BREAKER_FAILURE_RATE_THRESHOLD", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid failure rate threshold value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	validConfig := func() *Config {
+		return &Config{
+			Timeout:               time.Second,
+			ResetTimeout:          10 * time.Second,
+			MaxFailures:           5,
+			RateLimit:             5,
+			HalfOpenMaxCalls:      1,
+			Mode:                  ModeSlidingWindow,
+			WindowType:            WindowCount,
+			WindowSize:            100,
+			WindowDuration:        10 * time.Second,
+			FailureRateThreshold:  0.5,
+			SlowCallRateThreshold: 1,
+			MinimumCalls:          20,
+			EventHistorySize:      50,
+		}
+	}
+
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(validConfig())
+
+		if err := cm.Validate(); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		config := validConfig()
+		config.Mode = "unknown"
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for invalid mode")
+		}
+	})
+
+	t.Run("Invalid window type", func(t *testing.T) {
+		config := validConfig()
+		config.WindowType = "unknown"
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for invalid window type")
+		}
+	})
+
+	t.Run("Failure rate threshold out of range", func(t *testing.T) {
+		config := validConfig()
+		config.FailureRateThreshold = 1.5
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for failure rate threshold above 1")
+		}
+	})
+
+	t.Run("Minimum calls above the count window size", func(t *testing.T) {
+		config := validConfig()
+		config.MinimumCalls = config.WindowSize + 1
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for minimum calls above the window size")
+		}
+	})
+
+	t.Run("Minimum calls above the window size in a time window", func(t *testing.T) {
+		config := validConfig()
+		config.WindowType = WindowTime
+		config.MinimumCalls = config.WindowSize + 1
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Sliding window settings ignored in consecutive mode", func(t *testing.T) {
+		config := validConfig()
+		config.Mode = ModeConsecutive
+		config.MinimumCalls = 0
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid event history", func(t *testing.T) {
+		config := validConfig()
+		config.EventHistorySize = 0
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for zero event history")
+		}
+	})
+
+	t.Run("Invalid half-open max calls", func(t *testing.T) {
+		config := validConfig()
+		config.HalfOpenMaxCalls = 0
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for zero half-open max calls")
+		}
+	})
+}
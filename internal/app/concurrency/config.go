@@ -0,0 +1,177 @@
+package concurrency
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Concurrency limit modes
+const (
+	// ModeFixed caps concurrency with CIRCUIT_BREAKER_RATE_LIMIT and the per-processor rate limits
+	ModeFixed = "fixed"
+	// ModeAIMD grows each processor's limit by one per successful call and shrinks it on overload
+	ModeAIMD = "aimd"
+	// ModeGradient follows the ratio between each processor's baseline and current latency
+	ModeGradient = "gradient"
+)
+
+// Config holds the processor concurrency limit configuration
+type Config struct {
+	Mode string
+	// InitialLimit is the concurrency allowed per processor before any call is measured
+	InitialLimit int
+	// MinLimit and MaxLimit bound the adaptive limit of each processor
+	MinLimit int
+	MaxLimit int
+	// BackoffRatio is the factor applied to the AIMD limit on overload
+	BackoffRatio float64
+	// LatencyThreshold is the latency from which an AIMD call counts as overload; 0 disables it
+	LatencyThreshold time.Duration
+	// Tolerance is how much the gradient mode lets latency grow over its baseline
+	Tolerance float64
+	// Smoothing is the weight, from 0 to 1, of each new gradient limit
+	Smoothing float64
+	// BaselineWindow is the number of calls the gradient latency baseline averages
+	BaselineWindow int
+}
+
+// Adaptive reports whether the concurrency limits adapt to the processors' latency
+func (c *Config) Adaptive() bool {
+	return c.Mode == ModeAIMD || c.Mode == ModeGradient
+}
+
+// ConfigManager manages concurrency limit configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new concurrency limit configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads concurrency limit configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	initialLimit, err := strconv.Atoi(getEnvOrDefault("CONCURRENCY_INITIAL_LIMIT", "10"))
+	if err != nil {
+		return fmt.Errorf("invalid CONCURRENCY_INITIAL_LIMIT value: %w", err)
+	}
+
+	minLimit, err := strconv.Atoi(getEnvOrDefault("CONCURRENCY_MIN_LIMIT", "1"))
+	if err != nil {
+		return fmt.Errorf("invalid CONCURRENCY_MIN_LIMIT value: %w", err)
+	}
+
+	maxLimit, err := strconv.Atoi(getEnvOrDefault("CONCURRENCY_MAX_LIMIT", "200"))
+	if err != nil {
+		return fmt.Errorf("invalid CONCURRENCY_MAX_LIMIT value: %w", err)
+	}
+
+	backoffRatio, err := strconv.ParseFloat(getEnvOrDefault("CONCURRENCY_BACKOFF_RATIO", "0.9"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid CONCURRENCY_BACKOFF_RATIO value: %w", err)
+	}
+
+	latencyThreshold, err := time.ParseDuration(getEnvOrDefault("CONCURRENCY_LATENCY_THRESHOLD", "0s"))
+	if err != nil {
+		return fmt.Errorf("invalid CONCURRENCY_LATENCY_THRESHOLD value: %w", err)
+	}
+
+	tolerance, err := strconv.ParseFloat(getEnvOrDefault("CONCURRENCY_TOLERANCE", "1.5"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid CONCURRENCY_TOLERANCE value: %w", err)
+	}
+
+	smoothing, err := strconv.ParseFloat(getEnvOrDefault("CONCURRENCY_SMOOTHING", "0.2"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid CONCURRENCY_SMOOTHING value: %w", err)
+	}
+
+	baselineWindow, err := strconv.Atoi(getEnvOrDefault("CONCURRENCY_BASELINE_WINDOW", "600"))
+	if err != nil {
+		return fmt.Errorf("invalid CONCURRENCY_BASELINE_WINDOW value: %w", err)
+	}
+
+	cm.config = &Config{
+		Mode:             getEnvOrDefault("CONCURRENCY_LIMIT_MODE", ModeFixed),
+		InitialLimit:     initialLimit,
+		MinLimit:         minLimit,
+		MaxLimit:         maxLimit,
+		BackoffRatio:     backoffRatio,
+		LatencyThreshold: latencyThreshold,
+		Tolerance:        tolerance,
+		Smoothing:        smoothing,
+		BaselineWindow:   baselineWindow,
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded concurrency limit configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the concurrency limit configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("concurrency configuration not loaded")
+	}
+
+	switch cm.config.Mode {
+	case ModeFixed:
+		return nil
+	case ModeAIMD, ModeGradient:
+	default:
+		return fmt.Errorf("concurrency limit mode must be %s, %s or %s", ModeFixed, ModeAIMD, ModeGradient)
+	}
+
+	if cm.config.MinLimit <= 0 {
+		return fmt.Errorf("concurrency min limit must be greater than 0")
+	}
+
+	if cm.config.MaxLimit < cm.config.MinLimit {
+		return fmt.Errorf("concurrency max limit cannot be lower than the min limit")
+	}
+
+	if cm.config.InitialLimit < cm.config.MinLimit || cm.config.InitialLimit > cm.config.MaxLimit {
+		return fmt.Errorf("concurrency initial limit must be between the min and max limits")
+	}
+
+	if cm.config.BackoffRatio <= 0 || cm.config.BackoffRatio >= 1 {
+		return fmt.Errorf("concurrency backoff ratio must be between 0 and 1")
+	}
+
+	if cm.config.LatencyThreshold < 0 {
+		return fmt.Errorf("concurrency latency threshold cannot be negative")
+	}
+
+	if cm.config.Tolerance < 1 {
+		return fmt.Errorf("concurrency tolerance must be at least 1")
+	}
+
+	if cm.config.Smoothing <= 0 || cm.config.Smoothing > 1 {
+		return fmt.Errorf("concurrency smoothing must be between 0 and 1")
+	}
+
+	if cm.config.BaselineWindow <= 0 {
+		return fmt.Errorf("concurrency baseline window must be greater than 0")
+	}
+
+	return nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,158 @@
+package concurrency
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"CONCURRENCY_LIMIT_MODE":        os.Getenv("CONCURRENCY_LIMIT_MODE"),
+		"CONCURRENCY_INITIAL_LIMIT":     os.Getenv("CONCURRENCY_INITIAL_LIMIT"),
+		"CONCURRENCY_MIN_LIMIT":         os.Getenv("CONCURRENCY_MIN_LIMIT"),
+		"CONCURRENCY_MAX_LIMIT":         os.Getenv("CONCURRENCY_MAX_LIMIT"),
+		"CONCURRENCY_BACKOFF_RATIO":     os.Getenv("CONCURRENCY_BACKOFF_RATIO"),
+		"CONCURRENCY_LATENCY_THRESHOLD": os.Getenv("CONCURRENCY_LATENCY_THRESHOLD"),
+		"CONCURRENCY_TOLERANCE":         os.Getenv("CONCURRENCY_TOLERANCE"),
+		"CONCURRENCY_SMOOTHING":         os.Getenv("CONCURRENCY_SMOOTHING"),
+		"CONCURRENCY_BASELINE_WINDOW":   os.Getenv("CONCURRENCY_BASELINE_WINDOW"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.Mode != ModeFixed {
+			t.Errorf("Expected mode to be %s, got: %s", ModeFixed, config.Mode)
+		}
+		if config.Adaptive() {
+			t.Error("Expected the default mode not to be adaptive")
+		}
+		if config.InitialLimit != 10 || config.MinLimit != 1 || config.MaxLimit != 200 {
+			t.Errorf("Expected limits 10, 1 and 200, got: %d, %d and %d", config.InitialLimit, config.MinLimit, config.MaxLimit)
+		}
+		if config.BackoffRatio != 0.9 {
+			t.Errorf("Expected backoff ratio to be 0.9, got: %v", config.BackoffRatio)
+		}
+		if config.Tolerance != 1.5 {
+			t.Errorf("Expected tolerance to be 1.5, got: %v", config.Tolerance)
+		}
+		if config.BaselineWindow != 600 {
+			t.Errorf("Expected baseline window to be 600, got: %d", config.BaselineWindow)
+		}
+	})
+
+	t.Run("Custom values", func(t *testing.T) {
+		os.Setenv("CONCURRENCY_LIMIT_MODE", ModeGradient)
+		os.Setenv("CONCURRENCY_LATENCY_THRESHOLD", "250ms")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if !config.Adaptive() {
+			t.Error("Expected the gradient mode to be adaptive")
+		}
+		if config.LatencyThreshold != 250*time.Millisecond {
+			t.Errorf("Expected latency threshold to be 250ms, got: %v", config.LatencyThreshold)
+		}
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		os.Setenv("CONCURRENCY_MAX_LIMIT", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid max limit value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			Mode:           ModeAIMD,
+			InitialLimit:   10,
+			MinLimit:       1,
+			MaxLimit:       200,
+			BackoffRatio:   0.9,
+			Tolerance:      1.5,
+			Smoothing:      0.2,
+			BaselineWindow: 600,
+		}
+	}
+
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(valid())
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Fixed mode ignores adaptive settings", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Mode: ModeFixed})
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		tests := map[string]func(*Config){
+			"unknown mode":         func(c *Config) { c.Mode = "vegas" },
+			"zero min limit":       func(c *Config) { c.MinLimit = 0 },
+			"max below min":        func(c *Config) { c.MaxLimit = 0 },
+			"initial above max":    func(c *Config) { c.InitialLimit = 201 },
+			"backoff ratio of 1":   func(c *Config) { c.BackoffRatio = 1 },
+			"negative threshold":   func(c *Config) { c.LatencyThreshold = -time.Second },
+			"tolerance below 1":    func(c *Config) { c.Tolerance = 0.5 },
+			"zero smoothing":       func(c *Config) { c.Smoothing = 0 },
+			"zero baseline window": func(c *Config) { c.BaselineWindow = 0 },
+		}
+
+		for name, mutate := range tests {
+			config := valid()
+			mutate(config)
+
+			cm := NewConfigManager()
+			cm.SetConfig(config)
+
+			if err := cm.Validate(); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		}
+	})
+
+	t.Run("Nil config", func(t *testing.T) {
+		cm := NewConfigManager()
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for nil config")
+		}
+	})
+}
@@ -3,11 +3,20 @@ package config
 import (
 	"fmt"
 
+	"github.com/fabianoflorentino/mr-robot/internal/app/admin"
+	"github.com/fabianoflorentino/mr-robot/internal/app/audit"
+	"github.com/fabianoflorentino/mr-robot/internal/app/bulkhead"
 	"github.com/fabianoflorentino/mr-robot/internal/app/circuitbreaker"
+	"github.com/fabianoflorentino/mr-robot/internal/app/concurrency"
 	"github.com/fabianoflorentino/mr-robot/internal/app/controller"
 	"github.com/fabianoflorentino/mr-robot/internal/app/database"
+	"github.com/fabianoflorentino/mr-robot/internal/app/health"
+	"github.com/fabianoflorentino/mr-robot/internal/app/hedging"
+	"github.com/fabianoflorentino/mr-robot/internal/app/httpclient"
 	"github.com/fabianoflorentino/mr-robot/internal/app/payment"
 	"github.com/fabianoflorentino/mr-robot/internal/app/queue"
+	"github.com/fabianoflorentino/mr-robot/internal/app/reconciliation"
+	"github.com/fabianoflorentino/mr-robot/internal/app/sharedstate"
 )
 
 // Manager handles centralized configuration loading and management
@@ -17,6 +26,15 @@ type Manager struct {
 	queueManager          *queue.ConfigManager
 	circuitBreakerManager *circuitbreaker.ConfigManager
 	controllerManager     *controller.ConfigManager
+	healthManager         *health.ConfigManager
+	httpClientManager     *httpclient.ConfigManager
+	reconciliationManager *reconciliation.ConfigManager
+	auditManager          *audit.ConfigManager
+	adminManager          *admin.ConfigManager
+	sharedStateManager    *sharedstate.ConfigManager
+	concurrencyManager    *concurrency.ConfigManager
+	bulkheadManager       *bulkhead.ConfigManager
+	hedgingManager        *hedging.ConfigManager
 }
 
 // NewManager creates a new configuration manager
@@ -27,6 +45,15 @@ func NewManager() *Manager {
 		queueManager:          queue.NewConfigManager(),
 		circuitBreakerManager: circuitbreaker.NewConfigManager(),
 		controllerManager:     controller.NewConfigManager(),
+		healthManager:         health.NewConfigManager(),
+		httpClientManager:     httpclient.NewConfigManager(),
+		reconciliationManager: reconciliation.NewConfigManager(),
+		auditManager:          audit.NewConfigManager(),
+		adminManager:          admin.NewConfigManager(),
+		sharedStateManager:    sharedstate.NewConfigManager(),
+		concurrencyManager:    concurrency.NewConfigManager(),
+		bulkheadManager:       bulkhead.NewConfigManager(),
+		hedgingManager:        hedging.NewConfigManager(),
 	}
 }
 
@@ -57,6 +84,51 @@ func (m *Manager) LoadConfiguration() error {
 		return fmt.Errorf("failed to load controller configuration: %w", err)
 	}
 
+	// Load health check configuration
+	if err := m.healthManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load health check configuration: %w", err)
+	}
+
+	// Load HTTP client configuration
+	if err := m.httpClientManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load http client configuration: %w", err)
+	}
+
+	// Load reconciliation configuration
+	if err := m.reconciliationManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load reconciliation configuration: %w", err)
+	}
+
+	// Load audit configuration
+	if err := m.auditManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load audit configuration: %w", err)
+	}
+
+	// Load admin configuration
+	if err := m.adminManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load admin configuration: %w", err)
+	}
+
+	// Load shared state configuration
+	if err := m.sharedStateManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load shared state configuration: %w", err)
+	}
+
+	// Load concurrency configuration
+	if err := m.concurrencyManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load concurrency configuration: %w", err)
+	}
+
+	// Load bulkhead configuration
+	if err := m.bulkheadManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load bulkhead configuration: %w", err)
+	}
+
+	// Load hedging configuration
+	if err := m.hedgingManager.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load hedging configuration: %w", err)
+	}
+
 	return nil
 }
 
@@ -82,6 +154,42 @@ func (m *Manager) ValidateConfiguration() error {
 		return fmt.Errorf("invalid controller configuration: %w", err)
 	}
 
+	if err := m.healthManager.Validate(); err != nil {
+		return fmt.Errorf("invalid health check configuration: %w", err)
+	}
+
+	if err := m.httpClientManager.Validate(); err != nil {
+		return fmt.Errorf("invalid http client configuration: %w", err)
+	}
+
+	if err := m.reconciliationManager.Validate(); err != nil {
+		return fmt.Errorf("invalid reconciliation configuration: %w", err)
+	}
+
+	if err := m.auditManager.Validate(); err != nil {
+		return fmt.Errorf("invalid audit configuration: %w", err)
+	}
+
+	if err := m.adminManager.Validate(); err != nil {
+		return fmt.Errorf("invalid admin configuration: %w", err)
+	}
+
+	if err := m.sharedStateManager.Validate(); err != nil {
+		return fmt.Errorf("invalid shared state configuration: %w", err)
+	}
+
+	if err := m.concurrencyManager.Validate(); err != nil {
+		return fmt.Errorf("invalid concurrency configuration: %w", err)
+	}
+
+	if err := m.bulkheadManager.Validate(); err != nil {
+		return fmt.Errorf("invalid bulkhead configuration: %w", err)
+	}
+
+	if err := m.hedgingManager.Validate(); err != nil {
+		return fmt.Errorf("invalid hedging configuration: %w", err)
+	}
+
 	return nil
 }
 
@@ -110,6 +218,51 @@ func (m *Manager) GetControllerConfig() *controller.Config {
 	return m.controllerManager.GetConfig()
 }
 
+// GetHealthConfig returns the health check configuration
+func (m *Manager) GetHealthConfig() *health.Config {
+	return m.healthManager.GetConfig()
+}
+
+// GetHTTPClientConfig returns the HTTP client configuration
+func (m *Manager) GetHTTPClientConfig() *httpclient.Config {
+	return m.httpClientManager.GetConfig()
+}
+
+// GetReconciliationConfig returns the reconciliation configuration
+func (m *Manager) GetReconciliationConfig() *reconciliation.Config {
+	return m.reconciliationManager.GetConfig()
+}
+
+// GetAuditConfig returns the audit configuration
+func (m *Manager) GetAuditConfig() *audit.Config {
+	return m.auditManager.GetConfig()
+}
+
+// GetAdminConfig returns the admin configuration
+func (m *Manager) GetAdminConfig() *admin.Config {
+	return m.adminManager.GetConfig()
+}
+
+// GetSharedStateConfig returns the shared state configuration
+func (m *Manager) GetSharedStateConfig() *sharedstate.Config {
+	return m.sharedStateManager.GetConfig()
+}
+
+// GetConcurrencyConfig returns the concurrency configuration
+func (m *Manager) GetConcurrencyConfig() *concurrency.Config {
+	return m.concurrencyManager.GetConfig()
+}
+
+// GetBulkheadConfig returns the bulkhead configuration
+func (m *Manager) GetBulkheadConfig() *bulkhead.Config {
+	return m.bulkheadManager.GetConfig()
+}
+
+// GetHedgingConfig returns the hedging configuration
+func (m *Manager) GetHedgingConfig() *hedging.Config {
+	return m.hedgingManager.GetConfig()
+}
+
 // GetDatabaseManager returns the database config manager
 func (m *Manager) GetDatabaseManager() *database.ConfigManager {
 	return m.databaseManager
@@ -134,3 +287,48 @@ func (m *Manager) GetCircuitBreakerManager() *circuitbreaker.ConfigManager {
 func (m *Manager) GetControllerManager() *controller.ConfigManager {
 	return m.controllerManager
 }
+
+// GetHealthManager returns the health check config manager
+func (m *Manager) GetHealthManager() *health.ConfigManager {
+	return m.healthManager
+}
+
+// GetHTTPClientManager returns the HTTP client config manager
+func (m *Manager) GetHTTPClientManager() *httpclient.ConfigManager {
+	return m.httpClientManager
+}
+
+// GetReconciliationManager returns the reconciliation config manager
+func (m *Manager) GetReconciliationManager() *reconciliation.ConfigManager {
+	return m.reconciliationManager
+}
+
+// GetAuditManager returns the audit config manager
+func (m *Manager) GetAuditManager() *audit.ConfigManager {
+	return m.auditManager
+}
+
+// GetAdminManager returns the admin config manager
+func (m *Manager) GetAdminManager() *admin.ConfigManager {
+	return m.adminManager
+}
+
+// GetSharedStateManager returns the shared state config manager
+func (m *Manager) GetSharedStateManager() *sharedstate.ConfigManager {
+	return m.sharedStateManager
+}
+
+// GetConcurrencyManager returns the concurrency config manager
+func (m *Manager) GetConcurrencyManager() *concurrency.ConfigManager {
+	return m.concurrencyManager
+}
+
+// GetBulkheadManager returns the bulkhead config manager
+func (m *Manager) GetBulkheadManager() *bulkhead.ConfigManager {
+	return m.bulkheadManager
+}
+
+// GetHedgingManager returns the hedging config manager
+func (m *Manager) GetHedgingManager() *hedging.ConfigManager {
+	return m.hedgingManager
+}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/fabianoflorentino/mr-robot/internal/app/admin"
 	"github.com/fabianoflorentino/mr-robot/internal/app/config"
 	"github.com/fabianoflorentino/mr-robot/internal/app/database"
 	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
 	"github.com/fabianoflorentino/mr-robot/internal/app/migration"
-	"github.com/fabianoflorentino/mr-robot/internal/app/queue"
 	appServices "github.com/fabianoflorentino/mr-robot/internal/app/services"
 )
 
@@ -17,7 +17,12 @@ import (
 type Container interface {
 	GetDB() *sql.DB
 	GetPaymentService() interfaces.PaymentServiceInterface
-	GetPaymentQueue() *queue.PaymentQueue
+	GetPaymentQueue() interfaces.PaymentQueueInterface
+	GetAuditService() interfaces.AuditServiceInterface
+	GetCircuitBreakerService() interfaces.CircuitBreakerServiceInterface
+	GetBulkheadService() interfaces.BulkheadServiceInterface
+	GetDeadLetterService() interfaces.DeadLetterServiceInterface
+	GetAdminConfig() *admin.Config
 	Shutdown() error
 }
 
@@ -50,26 +55,43 @@ func NewAppContainer() (Container, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
-	// Step 3: Initialize service manager
-	container.serviceManager = appServices.NewManager(
-		container.databaseManager.GetDB(),
-		container.configManager.GetPaymentConfig(),
-		container.configManager.GetQueueConfig(),
-		container.configManager.GetCircuitBreakerConfig(),
-	)
+	// Step 3: Initialize migration manager and run migrations
+	container.migrationManager = migration.NewManager(container.databaseManager.GetDB())
+	if err := container.migrationManager.RunMigrations(); err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	// Step 4: Initialize service manager, once the tables it uses exist
+	container.serviceManager = appServices.NewManager(container.databaseManager.GetDB(), serviceConfig(container.configManager))
 	if err := container.serviceManager.InitializeServices(); err != nil {
 		return nil, fmt.Errorf("failed to initialize services: %w", err)
 	}
 
-	// Step 4: Initialize migration manager and run migrations
-	container.migrationManager = migration.NewManager(container.databaseManager.GetDB())
-	if err := container.migrationManager.RunMigrations(); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	// Step 5: Reload the payments the queue spooled at the last shutdown
+	if err := container.serviceManager.RestorePaymentQueue(); err != nil {
+		return nil, fmt.Errorf("failed to restore spooled payments: %w", err)
 	}
 
 	return container, nil
 }
 
+// serviceConfig gathers the configuration of the services from the loaded configuration
+func serviceConfig(cm *config.Manager) appServices.ManagerConfig {
+	return appServices.ManagerConfig{
+		Payment:        cm.GetPaymentConfig(),
+		Queue:          cm.GetQueueConfig(),
+		CircuitBreaker: cm.GetCircuitBreakerConfig(),
+		Health:         cm.GetHealthConfig(),
+		HTTPClient:     cm.GetHTTPClientConfig(),
+		Reconciliation: cm.GetReconciliationConfig(),
+		Audit:          cm.GetAuditConfig(),
+		SharedState:    cm.GetSharedStateConfig(),
+		Concurrency:    cm.GetConcurrencyConfig(),
+		Bulkhead:       cm.GetBulkheadConfig(),
+		Hedging:        cm.GetHedgingConfig(),
+	}
+}
+
 // GetDB returns the database connection
 func (c *AppContainer) GetDB() *sql.DB {
 	return c.databaseManager.GetDB()
@@ -81,10 +103,35 @@ func (c *AppContainer) GetPaymentService() interfaces.PaymentServiceInterface {
 }
 
 // GetPaymentQueue returns the payment queue instance
-func (c *AppContainer) GetPaymentQueue() *queue.PaymentQueue {
+func (c *AppContainer) GetPaymentQueue() interfaces.PaymentQueueInterface {
 	return c.serviceManager.GetPaymentQueue()
 }
 
+// GetAuditService returns the payment summary audit service, or nil when disabled
+func (c *AppContainer) GetAuditService() interfaces.AuditServiceInterface {
+	return c.serviceManager.GetAuditService()
+}
+
+// GetCircuitBreakerService returns the circuit breaker service
+func (c *AppContainer) GetCircuitBreakerService() interfaces.CircuitBreakerServiceInterface {
+	return c.serviceManager.GetCircuitBreakerService()
+}
+
+// GetBulkheadService returns the service reporting the processors' bulkheads
+func (c *AppContainer) GetBulkheadService() interfaces.BulkheadServiceInterface {
+	return c.serviceManager.GetBulkheadService()
+}
+
+// GetDeadLetterService returns the service inspecting and replaying dead letters
+func (c *AppContainer) GetDeadLetterService() interfaces.DeadLetterServiceInterface {
+	return c.serviceManager.GetDeadLetterService()
+}
+
+// GetAdminConfig returns the admin API configuration
+func (c *AppContainer) GetAdminConfig() *admin.Config {
+	return c.configManager.GetAdminConfig()
+}
+
 // Shutdown gracefully shuts down all container components
 func (c *AppContainer) Shutdown() error {
 	log.Println("Shutting down application container...")
@@ -62,21 +62,22 @@ func (b *ContainerBuilder) Build() (Container, error) {
 		}
 	}
 
+	// Run the migrations before the services use the tables
+	migrationManager := migration.NewManager(databaseManager.GetDB())
+	if err := migrationManager.RunMigrations(); err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
 	// Create service manager
-	serviceManager := appServices.NewManager(
-		databaseManager.GetDB(),
-		configManager.GetPaymentConfig(),
-		configManager.GetQueueConfig(),
-		configManager.GetCircuitBreakerConfig(),
-	)
+	serviceManager := appServices.NewManager(databaseManager.GetDB(), serviceConfig(configManager))
 
 	if err := serviceManager.InitializeServices(); err != nil {
 		return nil, fmt.Errorf("failed to initialize services: %w", err)
 	}
 
-	migrationManager := migration.NewManager(databaseManager.GetDB())
-	if err := migrationManager.RunMigrations(); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	// Reload the payments the queue spooled at the last shutdown
+	if err := serviceManager.RestorePaymentQueue(); err != nil {
+		return nil, fmt.Errorf("failed to restore spooled payments: %w", err)
 	}
 
 	// Create container with all managers
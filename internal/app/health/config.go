@@ -0,0 +1,100 @@
+package health
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// minCheckInterval is the smallest interval allowed by the processors' service-health endpoint
+const minCheckInterval = 5 * time.Second
+
+// Config holds processor health monitoring configuration
+type Config struct {
+	Enabled       bool
+	CheckInterval time.Duration
+	CheckTimeout  time.Duration
+	MaxStaleness  time.Duration
+}
+
+// ConfigManager manages health monitoring configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new health monitoring configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads health monitoring configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	enabled, err := strconv.ParseBool(getEnvOrDefault("HEALTH_CHECK_ENABLED", "true"))
+	if err != nil {
+		return fmt.Errorf("invalid HEALTH_CHECK_ENABLED value: %w", err)
+	}
+
+	checkInterval, err := time.ParseDuration(getEnvOrDefault("HEALTH_CHECK_INTERVAL", "5s"))
+	if err != nil {
+		return fmt.Errorf("invalid HEALTH_CHECK_INTERVAL value: %w", err)
+	}
+
+	checkTimeout, err := time.ParseDuration(getEnvOrDefault("HEALTH_CHECK_TIMEOUT", "2s"))
+	if err != nil {
+		return fmt.Errorf("invalid HEALTH_CHECK_TIMEOUT value: %w", err)
+	}
+
+	maxStaleness, err := time.ParseDuration(getEnvOrDefault("HEALTH_CHECK_MAX_STALENESS", "15s"))
+	if err != nil {
+		return fmt.Errorf("invalid HEALTH_CHECK_MAX_STALENESS value: %w", err)
+	}
+
+	cm.config = &Config{
+		Enabled:       enabled,
+		CheckInterval: checkInterval,
+		CheckTimeout:  checkTimeout,
+		MaxStaleness:  maxStaleness,
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded health monitoring configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the health monitoring configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("health check configuration not loaded")
+	}
+
+	if cm.config.CheckInterval < minCheckInterval {
+		return fmt.Errorf("health check interval must be at least %v", minCheckInterval)
+	}
+
+	if cm.config.CheckTimeout <= 0 {
+		return fmt.Errorf("health check timeout must be greater than 0")
+	}
+
+	if cm.config.MaxStaleness < cm.config.CheckInterval {
+		return fmt.Errorf("health check max staleness cannot be lower than the check interval")
+	}
+
+	return nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
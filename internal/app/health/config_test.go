@@ -0,0 +1,99 @@
+package health
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"HEALTH_CHECK_ENABLED":       os.Getenv("HEALTH_CHECK_ENABLED"),
+		"HEALTH_CHECK_INTERVAL":      os.Getenv("HEALTH_CHECK_INTERVAL"),
+		"HEALTH_CHECK_TIMEOUT":       os.Getenv("HEALTH_CHECK_TIMEOUT"),
+		"HEALTH_CHECK_MAX_STALENESS": os.Getenv("HEALTH_CHECK_MAX_STALENESS"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if !config.Enabled {
+			t.Error("Expected health check to be enabled by default")
+		}
+		if config.CheckInterval != 5*time.Second {
+			t.Errorf("Expected check interval to be 5s, got: %v", config.CheckInterval)
+		}
+		if config.CheckTimeout != 2*time.Second {
+			t.Errorf("Expected check timeout to be 2s, got: %v", config.CheckTimeout)
+		}
+		if config.MaxStaleness != 15*time.Second {
+			t.Errorf("Expected max staleness to be 15s, got: %v", config.MaxStaleness)
+		}
+	})
+
+	t.Run("Invalid interval", func(t *testing.T) {
+		os.Setenv("HEALTH_CHECK_INTERVAL", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid interval value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: true, CheckInterval: 5 * time.Second, CheckTimeout: time.Second, MaxStaleness: 15 * time.Second})
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Interval below processor limit", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: true, CheckInterval: time.Second, CheckTimeout: time.Second, MaxStaleness: 15 * time.Second})
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for interval below 5s")
+		}
+	})
+
+	t.Run("Staleness below interval", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: true, CheckInterval: 10 * time.Second, CheckTimeout: time.Second, MaxStaleness: 5 * time.Second})
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for staleness below interval")
+		}
+	})
+
+	t.Run("Nil config", func(t *testing.T) {
+		cm := NewConfigManager()
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for nil config")
+		}
+	})
+}
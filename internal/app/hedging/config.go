@@ -0,0 +1,135 @@
+package hedging
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the request hedging configuration
+type Config struct {
+	Enabled bool
+	// Percentile of the preferred processor's recent latencies after which a hedge is sent
+	Percentile float64
+	// MinDelay and MaxDelay bound the wait before hedging; MaxDelay is also
+	// used until the processor has MinSamples latencies
+	MinDelay   time.Duration
+	MaxDelay   time.Duration
+	MinSamples int
+	// Window is the number of recent latencies kept per processor
+	Window int
+	// AllowFallback sends the hedge to the next processor instead of the same one
+	AllowFallback bool
+}
+
+// ConfigManager manages request hedging configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new request hedging configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads request hedging configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	enabled, err := strconv.ParseBool(getEnvOrDefault("HEDGING_ENABLED", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid HEDGING_ENABLED value: %w", err)
+	}
+
+	percentile, err := strconv.ParseFloat(getEnvOrDefault("HEDGING_PERCENTILE", "0.95"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid HEDGING_PERCENTILE value: %w", err)
+	}
+
+	minDelay, err := time.ParseDuration(getEnvOrDefault("HEDGING_MIN_DELAY", "20ms"))
+	if err != nil {
+		return fmt.Errorf("invalid HEDGING_MIN_DELAY value: %w", err)
+	}
+
+	maxDelay, err := time.ParseDuration(getEnvOrDefault("HEDGING_MAX_DELAY", "500ms"))
+	if err != nil {
+		return fmt.Errorf("invalid HEDGING_MAX_DELAY value: %w", err)
+	}
+
+	minSamples, err := strconv.Atoi(getEnvOrDefault("HEDGING_MIN_SAMPLES", "20"))
+	if err != nil {
+		return fmt.Errorf("invalid HEDGING_MIN_SAMPLES value: %w", err)
+	}
+
+	window, err := strconv.Atoi(getEnvOrDefault("HEDGING_WINDOW", "200"))
+	if err != nil {
+		return fmt.Errorf("invalid HEDGING_WINDOW value: %w", err)
+	}
+
+	allowFallback, err := strconv.ParseBool(getEnvOrDefault("HEDGING_ALLOW_FALLBACK", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid HEDGING_ALLOW_FALLBACK value: %w", err)
+	}
+
+	cm.config = &Config{
+		Enabled:       enabled,
+		Percentile:    percentile,
+		MinDelay:      minDelay,
+		MaxDelay:      maxDelay,
+		MinSamples:    minSamples,
+		Window:        window,
+		AllowFallback: allowFallback,
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded request hedging configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the request hedging configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("hedging configuration not loaded")
+	}
+
+	if !cm.config.Enabled {
+		return nil
+	}
+
+	if cm.config.Percentile <= 0 || cm.config.Percentile >= 1 {
+		return fmt.Errorf("hedging percentile must be between 0 and 1")
+	}
+
+	if cm.config.MinDelay <= 0 {
+		return fmt.Errorf("hedging min delay must be greater than 0")
+	}
+
+	if cm.config.MaxDelay < cm.config.MinDelay {
+		return fmt.Errorf("hedging max delay cannot be lower than the min delay")
+	}
+
+	if cm.config.MinSamples <= 0 {
+		return fmt.Errorf("hedging min samples must be greater than 0")
+	}
+
+	if cm.config.Window < cm.config.MinSamples {
+		return fmt.Errorf("hedging window cannot be lower than the min samples")
+	}
+
+	return nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,129 @@
+package hedging
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"HEDGING_ENABLED":        os.Getenv("HEDGING_ENABLED"),
+		"HEDGING_PERCENTILE":     os.Getenv("HEDGING_PERCENTILE"),
+		"HEDGING_MIN_DELAY":      os.Getenv("HEDGING_MIN_DELAY"),
+		"HEDGING_MAX_DELAY":      os.Getenv("HEDGING_MAX_DELAY"),
+		"HEDGING_MIN_SAMPLES":    os.Getenv("HEDGING_MIN_SAMPLES"),
+		"HEDGING_WINDOW":         os.Getenv("HEDGING_WINDOW"),
+		"HEDGING_ALLOW_FALLBACK": os.Getenv("HEDGING_ALLOW_FALLBACK"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.Enabled {
+			t.Error("Expected hedging to be disabled by default")
+		}
+		if config.Percentile != 0.95 {
+			t.Errorf("Expected percentile to be 0.95, got: %v", config.Percentile)
+		}
+		if config.MinDelay != 20*time.Millisecond || config.MaxDelay != 500*time.Millisecond {
+			t.Errorf("Expected delays of 20ms and 500ms, got: %v and %v", config.MinDelay, config.MaxDelay)
+		}
+		if config.MinSamples != 20 || config.Window != 200 {
+			t.Errorf("Expected 20 min samples and a window of 200, got: %d and %d", config.MinSamples, config.Window)
+		}
+		if config.AllowFallback {
+			t.Error("Expected hedging to the fallback to be disabled by default")
+		}
+	})
+
+	t.Run("Invalid percentile", func(t *testing.T) {
+		os.Setenv("HEDGING_PERCENTILE", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid percentile value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			Enabled:    true,
+			Percentile: 0.95,
+			MinDelay:   20 * time.Millisecond,
+			MaxDelay:   500 * time.Millisecond,
+			MinSamples: 20,
+			Window:     200,
+		}
+	}
+
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(valid())
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		tests := map[string]func(*Config){
+			"percentile of 1":      func(c *Config) { c.Percentile = 1 },
+			"zero min delay":       func(c *Config) { c.MinDelay = 0 },
+			"max below min delay":  func(c *Config) { c.MaxDelay = time.Millisecond },
+			"zero min samples":     func(c *Config) { c.MinSamples = 0 },
+			"window below samples": func(c *Config) { c.Window = 10 },
+		}
+
+		for name, mutate := range tests {
+			config := valid()
+			mutate(config)
+
+			cm := NewConfigManager()
+			cm.SetConfig(config)
+
+			if err := cm.Validate(); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		}
+	})
+
+	t.Run("Disabled config is not checked", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: false})
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Nil config", func(t *testing.T) {
+		cm := NewConfigManager()
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for nil config")
+		}
+	})
+}
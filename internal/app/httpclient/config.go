@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the outbound HTTP client configuration shared by the processor gateways
+type Config struct {
+	Timeout               time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	KeepAlive             time.Duration
+	DialTimeout           time.Duration
+	ResponseHeaderTimeout time.Duration
+	EnableHTTP2           bool
+}
+
+// ConfigManager manages HTTP client configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new HTTP client configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads HTTP client configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	timeout, err := time.ParseDuration(getEnvOrDefault("HTTP_CLIENT_TIMEOUT", "5s"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_TIMEOUT value: %w", err)
+	}
+
+	maxIdleConns, err := strconv.Atoi(getEnvOrDefault("HTTP_CLIENT_MAX_IDLE_CONNS", "100"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_MAX_IDLE_CONNS value: %w", err)
+	}
+
+	maxIdleConnsPerHost, err := strconv.Atoi(getEnvOrDefault("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", "50"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST value: %w", err)
+	}
+
+	maxConnsPerHost, err := strconv.Atoi(getEnvOrDefault("HTTP_CLIENT_MAX_CONNS_PER_HOST", "0"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_MAX_CONNS_PER_HOST value: %w", err)
+	}
+
+	idleConnTimeout, err := time.ParseDuration(getEnvOrDefault("HTTP_CLIENT_IDLE_CONN_TIMEOUT", "90s"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_IDLE_CONN_TIMEOUT value: %w", err)
+	}
+
+	keepAlive, err := time.ParseDuration(getEnvOrDefault("HTTP_CLIENT_KEEP_ALIVE", "30s"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_KEEP_ALIVE value: %w", err)
+	}
+
+	dialTimeout, err := time.ParseDuration(getEnvOrDefault("HTTP_CLIENT_DIAL_TIMEOUT", "1s"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_DIAL_TIMEOUT value: %w", err)
+	}
+
+	responseHeaderTimeout, err := time.ParseDuration(getEnvOrDefault("HTTP_CLIENT_RESPONSE_HEADER_TIMEOUT", "3s"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_RESPONSE_HEADER_TIMEOUT value: %w", err)
+	}
+
+	enableHTTP2, err := strconv.ParseBool(getEnvOrDefault("HTTP_CLIENT_ENABLE_HTTP2", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_CLIENT_ENABLE_HTTP2 value: %w", err)
+	}
+
+	cm.config = &Config{
+		Timeout:               timeout,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		MaxConnsPerHost:       maxConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		KeepAlive:             keepAlive,
+		DialTimeout:           dialTimeout,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		EnableHTTP2:           enableHTTP2,
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded HTTP client configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the HTTP client configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("http client configuration not loaded")
+	}
+
+	if cm.config.Timeout <= 0 {
+		return fmt.Errorf("http client timeout must be greater than 0")
+	}
+
+	if cm.config.MaxIdleConns < 0 || cm.config.MaxIdleConnsPerHost < 0 || cm.config.MaxConnsPerHost < 0 {
+		return fmt.Errorf("http client connection limits cannot be negative")
+	}
+
+	if cm.config.IdleConnTimeout < 0 || cm.config.KeepAlive < 0 {
+		return fmt.Errorf("http client idle and keep-alive durations cannot be negative")
+	}
+
+	if cm.config.DialTimeout <= 0 {
+		return fmt.Errorf("http client dial timeout must be greater than 0")
+	}
+
+	if cm.config.ResponseHeaderTimeout <= 0 {
+		return fmt.Errorf("http client response header timeout must be greater than 0")
+	}
+
+	return nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"HTTP_CLIENT_TIMEOUT":                 os.Getenv("HTTP_CLIENT_TIMEOUT"),
+		"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST": os.Getenv("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST"),
+		"HTTP_CLIENT_KEEP_ALIVE":              os.Getenv("HTTP_CLIENT_KEEP_ALIVE"),
+		"HTTP_CLIENT_ENABLE_HTTP2":            os.Getenv("HTTP_CLIENT_ENABLE_HTTP2"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.Timeout != 5*time.Second {
+			t.Errorf("Expected timeout to be 5s, got: %v", config.Timeout)
+		}
+		if config.MaxIdleConnsPerHost != 50 {
+			t.Errorf("Expected max idle conns per host to be 50, got: %d", config.MaxIdleConnsPerHost)
+		}
+		if config.KeepAlive != 30*time.Second {
+			t.Errorf("Expected keep-alive to be 30s, got: %v", config.KeepAlive)
+		}
+		if config.EnableHTTP2 {
+			t.Error("Expected HTTP/2 to be disabled by default")
+		}
+	})
+
+	t.Run("Custom values", func(t *testing.T) {
+		os.Setenv("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", "200")
+		os.Setenv("HTTP_CLIENT_ENABLE_HTTP2", "true")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.MaxIdleConnsPerHost != 200 {
+			t.Errorf("Expected max idle conns per host to be 200, got: %d", config.MaxIdleConnsPerHost)
+		}
+		if !config.EnableHTTP2 {
+			t.Error("Expected HTTP/2 to be enabled")
+		}
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		os.Setenv("HTTP_CLIENT_KEEP_ALIVE", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid keep-alive value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			Timeout:               5 * time.Second,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   50,
+			IdleConnTimeout:       90 * time.Second,
+			KeepAlive:             30 * time.Second,
+			DialTimeout:           time.Second,
+			ResponseHeaderTimeout: 3 * time.Second,
+		}
+	}
+
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(valid())
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Negative connection limit", func(t *testing.T) {
+		config := valid()
+		config.MaxIdleConnsPerHost = -1
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for negative connection limit")
+		}
+	})
+
+	t.Run("Invalid dial timeout", func(t *testing.T) {
+		config := valid()
+		config.DialTimeout = 0
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for invalid dial timeout")
+		}
+	})
+
+	t.Run("Nil config", func(t *testing.T) {
+		cm := NewConfigManager()
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for nil config")
+		}
+	})
+}
@@ -3,13 +3,13 @@ package app
 import (
 	"database/sql"
 
-	"github.com/fabianoflorentino/mr-robot/internal/app/queue"
+	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
 )
 
 // ContainerInterface defines the interface for dependency injection container
 type ContainerInterface interface {
 	GetDB() *sql.DB
-	GetPaymentQueue() *queue.PaymentQueue
+	GetPaymentQueue() interfaces.PaymentQueueInterface
 	Shutdown() error
 }
 
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// AuditServiceInterface defines the contract for the payment summary audit
+type AuditServiceInterface interface {
+	Audit(ctx context.Context, from, to time.Time) (*domain.AuditReport, error)
+	Reports() []domain.AuditReport
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+import "github.com/fabianoflorentino/mr-robot/core/domain"
+
+// BulkheadServiceInterface defines the contract for reporting the processors' bulkheads
+type BulkheadServiceInterface interface {
+	Bulkheads() []domain.BulkheadStatus
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import "github.com/fabianoflorentino/mr-robot/core/domain"
+
+// CircuitBreakerServiceInterface defines the contract for inspecting and
+// controlling the processors' circuit breakers
+type CircuitBreakerServiceInterface interface {
+	CircuitBreakers() []domain.CircuitBreakerStatus
+	ForceCircuitBreakerOpen(processorName, reason string) (*domain.CircuitBreakerStatus, error)
+	ForceCircuitBreakerClosed(processorName, reason string) (*domain.CircuitBreakerStatus, error)
+	ReleaseCircuitBreaker(processorName, reason string) (*domain.CircuitBreakerStatus, error)
+	ResetCircuitBreaker(processorName, reason string) (*domain.CircuitBreakerStatus, error)
+	Events() map[string][]domain.CircuitBreakerEvent
+}
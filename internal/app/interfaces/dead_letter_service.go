@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/google/uuid"
+)
+
+// DeadLetterServiceInterface defines the contract for inspecting, replaying
+// and discarding the payments that ran out of queue attempts
+type DeadLetterServiceInterface interface {
+	DeadLetters(ctx context.Context, offset, limit int) ([]domain.DeadLetter, error)
+	DeadLetter(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error)
+	Replay(ctx context.Context, id uuid.UUID) error
+	ReplayAll(ctx context.Context) (*domain.DeadLetterReplay, error)
+	Discard(ctx context.Context, id uuid.UUID) error
+	DiscardAll(ctx context.Context) (int64, error)
+}
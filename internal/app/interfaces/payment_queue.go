@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// PaymentQueueInterface defines the contract for the queue that processes
+// accepted payments in the background
+type PaymentQueueInterface interface {
+	Enqueue(payment *domain.Payment) error
+	Stats(ctx context.Context) (*domain.QueueStats, error)
+	Workers() domain.WorkerPool
+	ResizeWorkers(resize domain.WorkerPool) (*domain.WorkerPool, error)
+	Shutdown()
+}
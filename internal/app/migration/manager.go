@@ -48,6 +48,27 @@ func (m *Manager) RunMigrations() error {
 		log.Println("Payments table already exists, skipping migration")
 	}
 
+	// Tables created before the status column existed are upgraded in place
+	if err := m.ensurePaymentsStatusColumn(); err != nil {
+		return fmt.Errorf("failed to add status column to payments table: %w", err)
+	}
+
+	// Processor state shared between instances, used when SHARED_STATE_ENABLED is set
+	if err := m.ensureSharedStateTables(); err != nil {
+		return fmt.Errorf("failed to create shared state tables: %w", err)
+	}
+
+	// Durable payment queue, used when QUEUE_BACKEND is postgres, and the
+	// dead letters of both queue backends
+	if err := m.ensurePaymentJobsTables(); err != nil {
+		return fmt.Errorf("failed to create payment jobs tables: %w", err)
+	}
+
+	// Jobs the in-memory queue could not finish before shutting down
+	if err := m.ensurePaymentSpoolTable(); err != nil {
+		return fmt.Errorf("failed to create payment queue spool table: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 
 	return nil
@@ -89,6 +110,7 @@ func (m *Manager) createPaymentsTable() error {
 		correlation_id UUID NOT NULL,
 		amount DECIMAL(15,2) NOT NULL,
 		processor VARCHAR(255) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'processed',
 		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 	);
@@ -96,6 +118,97 @@ func (m *Manager) createPaymentsTable() error {
 	CREATE INDEX IF NOT EXISTS idx_payments_correlation_id ON payments(correlation_id);
 	CREATE INDEX IF NOT EXISTS idx_payments_processor ON payments(processor);
 	CREATE INDEX IF NOT EXISTS idx_payments_created_at ON payments(created_at);
+	CREATE INDEX IF NOT EXISTS idx_payments_status ON payments(status);
+	`
+
+	_, err := m.db.Exec(query)
+	return err
+}
+
+func (m *Manager) ensurePaymentsStatusColumn() error {
+	query := `
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'processed';
+	CREATE INDEX IF NOT EXISTS idx_payments_status ON payments(status);
+	`
+
+	_, err := m.db.Exec(query)
+	return err
+}
+
+func (m *Manager) ensureSharedStateTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS processor_shared_state (
+		processor VARCHAR(255) PRIMARY KEY,
+		breaker_state VARCHAR(20),
+		breaker_forced BOOLEAN,
+		breaker_reason TEXT,
+		breaker_instance VARCHAR(255),
+		breaker_changed_at TIMESTAMP WITH TIME ZONE,
+		health_failing BOOLEAN,
+		health_min_response_time INTEGER,
+		health_instance VARCHAR(255),
+		health_checked_at TIMESTAMP WITH TIME ZONE
+	);
+
+	CREATE TABLE IF NOT EXISTS shared_state_leases (
+		name VARCHAR(255) PRIMARY KEY,
+		holder VARCHAR(255) NOT NULL,
+		expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	`
+
+	_, err := m.db.Exec(query)
+	return err
+}
+
+func (m *Manager) ensurePaymentJobsTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS payment_jobs (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		correlation_id UUID NOT NULL UNIQUE,
+		amount DECIMAL(15,2) NOT NULL,
+		requested_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		lease_id UUID,
+		leased_by VARCHAR(255),
+		visible_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		attempt_errors JSONB NOT NULL DEFAULT '[]',
+		retry_delay_ms BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_payment_jobs_visible_at ON payment_jobs(visible_at);
+
+	CREATE TABLE IF NOT EXISTS payment_dead_letters (
+		id UUID PRIMARY KEY,
+		correlation_id UUID NOT NULL,
+		amount DECIMAL(15,2) NOT NULL,
+		requested_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		attempts JSONB NOT NULL,
+		enqueued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		failed_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_payment_dead_letters_failed_at ON payment_dead_letters(failed_at);
+	`
+
+	_, err := m.db.Exec(query)
+	return err
+}
+
+func (m *Manager) ensurePaymentSpoolTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS payment_queue_spool (
+		id UUID PRIMARY KEY,
+		correlation_id UUID NOT NULL,
+		amount DECIMAL(15,2) NOT NULL,
+		requested_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		attempt_errors JSONB NOT NULL DEFAULT '[]',
+		retry_delay_ms BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		spooled_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
 	`
 
 	_, err := m.db.Exec(query)
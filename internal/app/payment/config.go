@@ -4,12 +4,78 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultProcessorName  = "default"
+	fallbackProcessorName = "fallback"
 )
 
 // Config holds payment processor configuration
 type Config struct {
+	// Processors is the processor registry. When empty, the default and
+	// fallback pair below is used instead.
+	Processors           []ProcessorConfig
 	DefaultProcessorURL  string
 	FallbackProcessorURL string
+	DefaultProcessorFee  float64
+	FallbackProcessorFee float64
+	// Requests per second limits of the default and fallback pair
+	DefaultProcessorRequestsPerSecond  float64
+	DefaultProcessorBurst              int
+	FallbackProcessorRequestsPerSecond float64
+	FallbackProcessorBurst             int
+	DefaultRecoveryWait                time.Duration
+	RecoveryFeeRatio                   float64
+}
+
+// ProcessorConfig holds the configuration of a single registered processor.
+// Zero values for MaxFailures, ResetTimeout and RateLimit inherit the circuit
+// breaker configuration; an empty AdminToken inherits the audit admin token.
+// A zero RequestsPerSecond disables the requests per second limit, and Burst
+// is the number of requests allowed at once on top of that rate. Zero
+// bulkhead limits inherit the bulkhead configuration.
+type ProcessorConfig struct {
+	Name                  string
+	URL                   string
+	AdminToken            string
+	Priority              int
+	Fee                   float64
+	MaxFailures           int
+	ResetTimeout          time.Duration
+	RateLimit             int
+	RequestsPerSecond     float64
+	Burst                 int
+	BulkheadMaxConcurrent int
+	BulkheadMaxQueue      int
+}
+
+// ProcessorList returns the registered processors, or the default and fallback
+// pair when no registry is configured
+func (c *Config) ProcessorList() []ProcessorConfig {
+	if len(c.Processors) > 0 {
+		return c.Processors
+	}
+
+	return []ProcessorConfig{
+		{
+			Name:              defaultProcessorName,
+			URL:               c.DefaultProcessorURL,
+			Fee:               c.DefaultProcessorFee,
+			RequestsPerSecond: c.DefaultProcessorRequestsPerSecond,
+			Burst:             c.DefaultProcessorBurst,
+		},
+		{
+			Name:              fallbackProcessorName,
+			URL:               c.FallbackProcessorURL,
+			Fee:               c.FallbackProcessorFee,
+			RequestsPerSecond: c.FallbackProcessorRequestsPerSecond,
+			Burst:             c.FallbackProcessorBurst,
+		},
+	}
 }
 
 // ConfigManager manages payment configuration
@@ -24,6 +90,32 @@ func NewConfigManager() *ConfigManager {
 
 // LoadConfig loads payment configuration from environment variables
 func (cm *ConfigManager) LoadConfig() error {
+	defaultRecoveryWait, err := time.ParseDuration(getEnvOrDefault("PAYMENT_DEFAULT_RECOVERY_WAIT", "200ms"))
+	if err != nil {
+		return fmt.Errorf("invalid PAYMENT_DEFAULT_RECOVERY_WAIT value: %w", err)
+	}
+
+	recoveryFeeRatio, err := strconv.ParseFloat(getEnvOrDefault("PAYMENT_RECOVERY_FEE_RATIO", "2"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid PAYMENT_RECOVERY_FEE_RATIO value: %w", err)
+	}
+
+	// A processor registry takes precedence over the default and fallback pair
+	if names := os.Getenv("PAYMENT_PROCESSORS"); names != "" {
+		processors, err := loadProcessors(names)
+		if err != nil {
+			return err
+		}
+
+		cm.config = &Config{
+			Processors:          processors,
+			DefaultRecoveryWait: defaultRecoveryWait,
+			RecoveryFeeRatio:    recoveryFeeRatio,
+		}
+
+		return nil
+	}
+
 	defaultProcessorURL := os.Getenv("DEFAULT_PROCESSOR_URL")
 	if defaultProcessorURL == "" {
 		return fmt.Errorf("DEFAULT_PROCESSOR_URL environment variable is required")
@@ -34,9 +126,37 @@ func (cm *ConfigManager) LoadConfig() error {
 		return fmt.Errorf("FALLBACK_PROCESSOR_URL environment variable is required")
 	}
 
+	defaultProcessorFee, err := strconv.ParseFloat(getEnvOrDefault("DEFAULT_PROCESSOR_FEE", "0.05"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid DEFAULT_PROCESSOR_FEE value: %w", err)
+	}
+
+	fallbackProcessorFee, err := strconv.ParseFloat(getEnvOrDefault("FALLBACK_PROCESSOR_FEE", "0.15"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid FALLBACK_PROCESSOR_FEE value: %w", err)
+	}
+
+	defaultRequestsPerSecond, defaultBurst, err := loadRequestRate("DEFAULT_PROCESSOR_")
+	if err != nil {
+		return err
+	}
+
+	fallbackRequestsPerSecond, fallbackBurst, err := loadRequestRate("FALLBACK_PROCESSOR_")
+	if err != nil {
+		return err
+	}
+
 	cm.config = &Config{
-		DefaultProcessorURL:  defaultProcessorURL,
-		FallbackProcessorURL: fallbackProcessorURL,
+		DefaultProcessorURL:                defaultProcessorURL,
+		FallbackProcessorURL:               fallbackProcessorURL,
+		DefaultProcessorFee:                defaultProcessorFee,
+		FallbackProcessorFee:               fallbackProcessorFee,
+		DefaultProcessorRequestsPerSecond:  defaultRequestsPerSecond,
+		DefaultProcessorBurst:              defaultBurst,
+		FallbackProcessorRequestsPerSecond: fallbackRequestsPerSecond,
+		FallbackProcessorBurst:             fallbackBurst,
+		DefaultRecoveryWait:                defaultRecoveryWait,
+		RecoveryFeeRatio:                   recoveryFeeRatio,
 	}
 
 	return nil
@@ -58,22 +178,168 @@ func (cm *ConfigManager) Validate() error {
 		return fmt.Errorf("payment configuration not loaded")
 	}
 
-	if cm.config.DefaultProcessorURL == "" {
-		return fmt.Errorf("default processor URL cannot be empty")
+	names := make(map[string]bool)
+	for _, processor := range cm.config.ProcessorList() {
+		if err := validateProcessor(processor); err != nil {
+			return err
+		}
+
+		if names[processor.Name] {
+			return fmt.Errorf("duplicate processor name: %s", processor.Name)
+		}
+		names[processor.Name] = true
 	}
 
-	if cm.config.FallbackProcessorURL == "" {
-		return fmt.Errorf("fallback processor URL cannot be empty")
+	if cm.config.DefaultRecoveryWait < 0 {
+		return fmt.Errorf("default recovery wait cannot be negative")
+	}
+
+	// A zero ratio disables waiting for the default processor
+	if cm.config.RecoveryFeeRatio < 0 {
+		return fmt.Errorf("recovery fee ratio cannot be negative")
+	}
+
+	return nil
+}
+
+// validateProcessor validates a single processor configuration
+func validateProcessor(p ProcessorConfig) error {
+	if p.Name == "" {
+		return fmt.Errorf("processor name cannot be empty")
+	}
+
+	if p.URL == "" {
+		return fmt.Errorf("%s processor URL cannot be empty", p.Name)
 	}
 
 	// Validate URL format
-	if _, err := url.Parse(cm.config.DefaultProcessorURL); err != nil {
-		return fmt.Errorf("invalid default processor URL: %w", err)
+	if _, err := url.Parse(p.URL); err != nil {
+		return fmt.Errorf("invalid %s processor URL: %w", p.Name, err)
+	}
+
+	if p.Fee < 0 {
+		return fmt.Errorf("%s processor fee cannot be negative", p.Name)
 	}
 
-	if _, err := url.Parse(cm.config.FallbackProcessorURL); err != nil {
-		return fmt.Errorf("invalid fallback processor URL: %w", err)
+	if p.MaxFailures < 0 || p.ResetTimeout < 0 || p.RateLimit < 0 || p.RequestsPerSecond < 0 || p.Burst < 0 ||
+		p.BulkheadMaxConcurrent < 0 || p.BulkheadMaxQueue < 0 {
+		return fmt.Errorf("%s processor limits cannot be negative", p.Name)
 	}
 
 	return nil
 }
+
+// loadProcessors loads the processor registry from a comma separated list of
+// names, reading each processor's settings from PROCESSOR_<NAME>_* variables
+func loadProcessors(names string) ([]ProcessorConfig, error) {
+	var processors []ProcessorConfig
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		processor, err := loadProcessor(name)
+		if err != nil {
+			return nil, err
+		}
+
+		processors = append(processors, processor)
+	}
+
+	if len(processors) == 0 {
+		return nil, fmt.Errorf("PAYMENT_PROCESSORS must list at least one processor")
+	}
+
+	return processors, nil
+}
+
+// loadProcessor loads the settings of a single registered processor
+func loadProcessor(name string) (ProcessorConfig, error) {
+	prefix := "PROCESSOR_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+	processorURL := os.Getenv(prefix + "URL")
+	if processorURL == "" {
+		return ProcessorConfig{}, fmt.Errorf("%sURL environment variable is required", prefix)
+	}
+
+	priority, err := strconv.Atoi(getEnvOrDefault(prefix+"PRIORITY", "0"))
+	if err != nil {
+		return ProcessorConfig{}, fmt.Errorf("invalid %sPRIORITY value: %w", prefix, err)
+	}
+
+	fee, err := strconv.ParseFloat(getEnvOrDefault(prefix+"FEE", "0"), 64)
+	if err != nil {
+		return ProcessorConfig{}, fmt.Errorf("invalid %sFEE value: %w", prefix, err)
+	}
+
+	maxFailures, err := strconv.Atoi(getEnvOrDefault(prefix+"MAX_FAILURES", "0"))
+	if err != nil {
+		return ProcessorConfig{}, fmt.Errorf("invalid %sMAX_FAILURES value: %w", prefix, err)
+	}
+
+	resetTimeout, err := time.ParseDuration(getEnvOrDefault(prefix+"RESET_TIMEOUT", "0s"))
+	if err != nil {
+		return ProcessorConfig{}, fmt.Errorf("invalid %sRESET_TIMEOUT value: %w", prefix, err)
+	}
+
+	rateLimit, err := strconv.Atoi(getEnvOrDefault(prefix+"RATE_LIMIT", "0"))
+	if err != nil {
+		return ProcessorConfig{}, fmt.Errorf("invalid %sRATE_LIMIT value: %w", prefix, err)
+	}
+
+	requestsPerSecond, burst, err := loadRequestRate(prefix)
+	if err != nil {
+		return ProcessorConfig{}, err
+	}
+
+	bulkheadMaxConcurrent, err := strconv.Atoi(getEnvOrDefault(prefix+"BULKHEAD_MAX_CONCURRENT", "0"))
+	if err != nil {
+		return ProcessorConfig{}, fmt.Errorf("invalid %sBULKHEAD_MAX_CONCURRENT value: %w", prefix, err)
+	}
+
+	bulkheadMaxQueue, err := strconv.Atoi(getEnvOrDefault(prefix+"BULKHEAD_MAX_QUEUE", "0"))
+	if err != nil {
+		return ProcessorConfig{}, fmt.Errorf("invalid %sBULKHEAD_MAX_QUEUE value: %w", prefix, err)
+	}
+
+	return ProcessorConfig{
+		Name:                  name,
+		URL:                   processorURL,
+		AdminToken:            os.Getenv(prefix + "ADMIN_TOKEN"),
+		Priority:              priority,
+		Fee:                   fee,
+		MaxFailures:           maxFailures,
+		ResetTimeout:          resetTimeout,
+		RateLimit:             rateLimit,
+		RequestsPerSecond:     requestsPerSecond,
+		Burst:                 burst,
+		BulkheadMaxConcurrent: bulkheadMaxConcurrent,
+		BulkheadMaxQueue:      bulkheadMaxQueue,
+	}, nil
+}
+
+// loadRequestRate loads a processor's requests per second limit from the
+// <prefix>REQUESTS_PER_SECOND and <prefix>BURST variables
+func loadRequestRate(prefix string) (float64, int, error) {
+	requestsPerSecond, err := strconv.ParseFloat(getEnvOrDefault(prefix+"REQUESTS_PER_SECOND", "0"), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %sREQUESTS_PER_SECOND value: %w", prefix, err)
+	}
+
+	burst, err := strconv.Atoi(getEnvOrDefault(prefix+"BURST", "1"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %sBURST value: %w", prefix, err)
+	}
+
+	return requestsPerSecond, burst, nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -3,6 +3,7 @@ package payment
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestConfigManager_LoadConfig(t *testing.T) {
@@ -41,6 +42,31 @@ func TestConfigManager_LoadConfig(t *testing.T) {
 		if config.FallbackProcessorURL != "http://fallback.example.com" {
 			t.Errorf("Expected fallback URL to be 'http://fallback.example.com', got: %s", config.FallbackProcessorURL)
 		}
+		if config.DefaultProcessorFee != 0.05 {
+			t.Errorf("Expected default fee to be 0.05, got: %v", config.DefaultProcessorFee)
+		}
+		if config.FallbackProcessorFee != 0.15 {
+			t.Errorf("Expected fallback fee to be 0.15, got: %v", config.FallbackProcessorFee)
+		}
+		if config.DefaultRecoveryWait != 200*time.Millisecond {
+			t.Errorf("Expected default recovery wait to be 200ms, got: %v", config.DefaultRecoveryWait)
+		}
+		if config.RecoveryFeeRatio != 2 {
+			t.Errorf("Expected recovery fee ratio to be 2, got: %v", config.RecoveryFeeRatio)
+		}
+	})
+
+	t.Run("Invalid fee", func(t *testing.T) {
+		os.Setenv("DEFAULT_PROCESSOR_URL", "http://default.example.com")
+		os.Setenv("FALLBACK_PROCESSOR_URL", "http://fallback.example.com")
+		os.Setenv("DEFAULT_PROCESSOR_FEE", "invalid")
+		defer os.Unsetenv("DEFAULT_PROCESSOR_FEE")
+
+		cm := NewConfigManager()
+		err := cm.LoadConfig()
+		if err == nil {
+			t.Fatal("Expected error for invalid default processor fee")
+		}
 	})
 
 	t.Run("Missing default URL", func(t *testing.T) {
@@ -132,6 +158,20 @@ func TestConfigManager_Validate(t *testing.T) {
 		}
 	})
 
+	t.Run("Negative fee", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{
+			DefaultProcessorURL:  "http://default.example.com",
+			FallbackProcessorURL: "http://fallback.example.com",
+			DefaultProcessorFee:  -0.05,
+		})
+
+		err := cm.Validate()
+		if err == nil {
+			t.Fatal("Expected error for negative fee")
+		}
+	})
+
 	t.Run("Nil config", func(t *testing.T) {
 		cm := NewConfigManager()
 
@@ -141,3 +181,116 @@ func TestConfigManager_Validate(t *testing.T) {
 		}
 	})
 }
+
+func TestConfigManager_LoadProcessorRegistry(t *testing.T) {
+	envVars := []string{
+		"PAYMENT_PROCESSORS",
+		"PROCESSOR_PRIMARY_URL", "PROCESSOR_PRIMARY_PRIORITY", "PROCESSOR_PRIMARY_FEE",
+		"PROCESSOR_BACKUP_ONE_URL", "PROCESSOR_BACKUP_ONE_MAX_FAILURES", "PROCESSOR_BACKUP_ONE_RESET_TIMEOUT",
+		"PROCESSOR_BACKUP_ONE_REQUESTS_PER_SECOND", "PROCESSOR_BACKUP_ONE_BURST",
+	}
+
+	originalValues := make(map[string]string)
+	for _, env := range envVars {
+		originalValues[env] = os.Getenv(env)
+	}
+
+	// Cleanup function
+	defer func() {
+		for _, env := range envVars {
+			if original := originalValues[env]; original == "" {
+				os.Unsetenv(env)
+			} else {
+				os.Setenv(env, original)
+			}
+		}
+	}()
+
+	t.Run("Registry with multiple processors", func(t *testing.T) {
+		os.Setenv("PAYMENT_PROCESSORS", "primary, backup-one")
+		os.Setenv("PROCESSOR_PRIMARY_URL", "http://primary.example.com")
+		os.Setenv("PROCESSOR_PRIMARY_PRIORITY", "1")
+		os.Setenv("PROCESSOR_PRIMARY_FEE", "0.03")
+		os.Setenv("PROCESSOR_BACKUP_ONE_URL", "http://backup.example.com")
+		os.Setenv("PROCESSOR_BACKUP_ONE_MAX_FAILURES", "3")
+		os.Setenv("PROCESSOR_BACKUP_ONE_RESET_TIMEOUT", "30s")
+		os.Setenv("PROCESSOR_BACKUP_ONE_REQUESTS_PER_SECOND", "2.5")
+		os.Setenv("PROCESSOR_BACKUP_ONE_BURST", "5")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected valid configuration, got: %v", err)
+		}
+
+		processors := cm.GetConfig().ProcessorList()
+		if len(processors) != 2 {
+			t.Fatalf("Expected 2 processors, got: %d", len(processors))
+		}
+
+		primary := processors[0]
+		if primary.Name != "primary" || primary.URL != "http://primary.example.com" || primary.Priority != 1 || primary.Fee != 0.03 {
+			t.Errorf("Unexpected primary processor config: %+v", primary)
+		}
+		if primary.RequestsPerSecond != 0 || primary.Burst != 1 {
+			t.Errorf("Expected no requests per second limit for primary, got: %+v", primary)
+		}
+
+		backup := processors[1]
+		if backup.Name != "backup-one" || backup.MaxFailures != 3 || backup.ResetTimeout != 30*time.Second {
+			t.Errorf("Unexpected backup processor config: %+v", backup)
+		}
+		if backup.RequestsPerSecond != 2.5 || backup.Burst != 5 {
+			t.Errorf("Expected 2.5 requests per second with a burst of 5 for backup, got: %+v", backup)
+		}
+	})
+
+	t.Run("Missing processor URL", func(t *testing.T) {
+		os.Setenv("PAYMENT_PROCESSORS", "primary,unknown")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for processor without URL")
+		}
+	})
+
+	t.Run("Legacy default and fallback pair", func(t *testing.T) {
+		cfg := &Config{
+			DefaultProcessorURL:  "http://default.example.com",
+			FallbackProcessorURL: "http://fallback.example.com",
+			DefaultProcessorFee:  0.05,
+			FallbackProcessorFee: 0.15,
+		}
+
+		processors := cfg.ProcessorList()
+		if len(processors) != 2 || processors[0].Name != "default" || processors[1].Name != "fallback" {
+			t.Fatalf("Expected default and fallback processors, got: %+v", processors)
+		}
+	})
+
+	t.Run("Negative requests per second", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Processors: []ProcessorConfig{
+			{Name: "primary", URL: "http://primary.example.com", RequestsPerSecond: -1},
+		}})
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for negative requests per second")
+		}
+	})
+
+	t.Run("Duplicate processor names", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Processors: []ProcessorConfig{
+			{Name: "primary", URL: "http://primary.example.com"},
+			{Name: "primary", URL: "http://other.example.com"},
+		}})
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for duplicate processor names")
+		}
+	})
+}
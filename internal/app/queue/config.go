@@ -4,14 +4,63 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
+)
+
+// Queue backends
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// Retry backoff strategies
+const (
+	BackoffExponential        = "exponential"
+	BackoffDecorrelatedJitter = "decorrelated_jitter"
 )
 
 // Config holds queue-specific configuration
 type Config struct {
+	// Workers is the initial size of the worker pool, which is scaled between
+	// MinWorkers and MaxWorkers; both default to Workers, a fixed pool
 	Workers               int
+	MinWorkers            int
+	MaxWorkers            int
 	BufferSize            int
 	MaxEnqueueRetries     int
 	MaxSimultaneousWrites int
+	// Backend is memory (a channel, lost on restart), the default, or postgres (durable)
+	Backend string
+	// VisibilityTimeout is how long a claimed postgres job stays hidden from
+	// the other workers before it can be claimed again
+	VisibilityTimeout time.Duration
+	// PollInterval is how often idle workers look for postgres jobs
+	PollInterval time.Duration
+	// RetryBackoff is exponential or decorrelated_jitter; delays start at
+	// RetryBaseDelay and never exceed RetryMaxDelay
+	RetryBackoff   string
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// ScaleInterval is how often the worker pool is resized, aiming to start
+	// every queued job within ScaleTargetWait at the current job latency
+	ScaleInterval   time.Duration
+	ScaleTargetWait time.Duration
+	// DrainTimeout is how long the queue keeps processing on shutdown before
+	// the running jobs are aborted and the rest is spooled or handed back
+	DrainTimeout time.Duration
+}
+
+// workerBounds returns the bounds of the worker pool, defaulting to Workers
+func (c *Config) workerBounds() (int, int) {
+	minWorkers, maxWorkers := c.MinWorkers, c.MaxWorkers
+	if minWorkers == 0 {
+		minWorkers = c.Workers
+	}
+	if maxWorkers == 0 {
+		maxWorkers = c.Workers
+	}
+
+	return minWorkers, maxWorkers
 }
 
 // ConfigManager manages queue configuration
@@ -31,6 +80,16 @@ func (cm *ConfigManager) LoadConfig() error {
 		return fmt.Errorf("invalid QUEUE_WORKERS value: %w", err)
 	}
 
+	minWorkers, err := strconv.Atoi(getEnvOrDefault("QUEUE_MIN_WORKERS", strconv.Itoa(workers)))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_MIN_WORKERS value: %w", err)
+	}
+
+	maxWorkers, err := strconv.Atoi(getEnvOrDefault("QUEUE_MAX_WORKERS", strconv.Itoa(workers)))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_MAX_WORKERS value: %w", err)
+	}
+
 	bufferSize, err := strconv.Atoi(getEnvOrDefault("QUEUE_BUFFER_SIZE", "10000"))
 	if err != nil {
 		return fmt.Errorf("invalid QUEUE_BUFFER_SIZE value: %w", err)
@@ -46,11 +105,57 @@ func (cm *ConfigManager) LoadConfig() error {
 		return fmt.Errorf("invalid QUEUE_MAX_SIMULTANEOUS_WRITES value: %w", err)
 	}
 
+	visibilityTimeout, err := time.ParseDuration(getEnvOrDefault("QUEUE_VISIBILITY_TIMEOUT", "30s"))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_VISIBILITY_TIMEOUT value: %w", err)
+	}
+
+	pollInterval, err := time.ParseDuration(getEnvOrDefault("QUEUE_POLL_INTERVAL", "100ms"))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_POLL_INTERVAL value: %w", err)
+	}
+
+	retryBaseDelay, err := time.ParseDuration(getEnvOrDefault("QUEUE_RETRY_BASE_DELAY", "1s"))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_RETRY_BASE_DELAY value: %w", err)
+	}
+
+	retryMaxDelay, err := time.ParseDuration(getEnvOrDefault("QUEUE_RETRY_MAX_DELAY", "30s"))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_RETRY_MAX_DELAY value: %w", err)
+	}
+
+	scaleInterval, err := time.ParseDuration(getEnvOrDefault("QUEUE_SCALE_INTERVAL", "1s"))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_SCALE_INTERVAL value: %w", err)
+	}
+
+	scaleTargetWait, err := time.ParseDuration(getEnvOrDefault("QUEUE_SCALE_TARGET_WAIT", "500ms"))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_SCALE_TARGET_WAIT value: %w", err)
+	}
+
+	drainTimeout, err := time.ParseDuration(getEnvOrDefault("QUEUE_DRAIN_TIMEOUT", "5s"))
+	if err != nil {
+		return fmt.Errorf("invalid QUEUE_DRAIN_TIMEOUT value: %w", err)
+	}
+
 	cm.config = &Config{
 		Workers:               workers,
+		MinWorkers:            minWorkers,
+		MaxWorkers:            maxWorkers,
 		BufferSize:            bufferSize,
 		MaxEnqueueRetries:     maxEnqueueRetries,
 		MaxSimultaneousWrites: maxSimultaneousWrites,
+		Backend:               getEnvOrDefault("QUEUE_BACKEND", BackendMemory),
+		VisibilityTimeout:     visibilityTimeout,
+		PollInterval:          pollInterval,
+		RetryBackoff:          getEnvOrDefault("QUEUE_RETRY_BACKOFF", BackoffExponential),
+		RetryBaseDelay:        retryBaseDelay,
+		RetryMaxDelay:         retryMaxDelay,
+		ScaleInterval:         scaleInterval,
+		ScaleTargetWait:       scaleTargetWait,
+		DrainTimeout:          drainTimeout,
 	}
 
 	return nil
@@ -76,6 +181,10 @@ func (cm *ConfigManager) Validate() error {
 		return fmt.Errorf("queue workers must be greater than 0")
 	}
 
+	if err := cm.validateWorkers(); err != nil {
+		return err
+	}
+
 	if cm.config.BufferSize <= 0 {
 		return fmt.Errorf("queue buffer size must be greater than 0")
 	}
@@ -88,6 +197,81 @@ func (cm *ConfigManager) Validate() error {
 		return fmt.Errorf("max simultaneous writes must be greater than 0")
 	}
 
+	if err := cm.validateRetry(); err != nil {
+		return err
+	}
+
+	if cm.config.DrainTimeout < 0 {
+		return fmt.Errorf("queue drain timeout cannot be negative")
+	}
+
+	switch cm.config.Backend {
+	case "", BackendMemory:
+		return nil
+	case BackendPostgres:
+	default:
+		return fmt.Errorf("queue backend must be %s or %s, got %q", BackendMemory, BackendPostgres, cm.config.Backend)
+	}
+
+	// A job still being processed must not become visible to another worker
+	if cm.config.VisibilityTimeout <= jobTimeout {
+		return fmt.Errorf("queue visibility timeout must be longer than the job timeout of %v", jobTimeout)
+	}
+
+	if cm.config.PollInterval <= 0 {
+		return fmt.Errorf("queue poll interval must be greater than 0")
+	}
+
+	return nil
+}
+
+// validateWorkers validates the worker pool bounds, and the scaling settings
+// when the pool can be resized
+func (cm *ConfigManager) validateWorkers() error {
+	minWorkers, maxWorkers := cm.config.workerBounds()
+
+	if minWorkers <= 0 || minWorkers > cm.config.Workers {
+		return fmt.Errorf("queue min workers must be between 1 and the queue workers")
+	}
+
+	if maxWorkers < cm.config.Workers {
+		return fmt.Errorf("queue max workers must be greater than or equal to the queue workers")
+	}
+
+	if minWorkers == maxWorkers {
+		return nil
+	}
+
+	if cm.config.ScaleInterval <= 0 {
+		return fmt.Errorf("queue scale interval must be greater than 0")
+	}
+
+	if cm.config.ScaleTargetWait <= 0 {
+		return fmt.Errorf("queue scale target wait must be greater than 0")
+	}
+
+	return nil
+}
+
+// validateRetry validates the retry policy. Configurations without a backoff
+// strategy keep the default retry policy.
+func (cm *ConfigManager) validateRetry() error {
+	switch cm.config.RetryBackoff {
+	case "":
+		return nil
+	case BackoffExponential, BackoffDecorrelatedJitter:
+	default:
+		return fmt.Errorf("queue retry backoff must be %s or %s, got %q", BackoffExponential, BackoffDecorrelatedJitter, cm.config.RetryBackoff)
+	}
+
+	if cm.config.RetryBaseDelay <= 0 {
+		return fmt.Errorf("queue retry base delay must be greater than 0")
+	}
+
+	if cm.config.RetryMaxDelay < cm.config.RetryBaseDelay {
+		return fmt.Errorf("queue retry max delay must be greater than or equal to the base delay")
+	}
+
 	return nil
 }
 
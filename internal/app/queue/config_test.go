@@ -3,6 +3,7 @@ package queue
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestConfigManager_LoadConfig(t *testing.T) {
@@ -12,6 +13,17 @@ func TestConfigManager_LoadConfig(t *testing.T) {
 		"QUEUE_BUFFER_SIZE":             os.Getenv("QUEUE_BUFFER_SIZE"),
 		"QUEUE_MAX_ENQUEUE_RETRIES":     os.Getenv("QUEUE_MAX_ENQUEUE_RETRIES"),
 		"QUEUE_MAX_SIMULTANEOUS_WRITES": os.Getenv("QUEUE_MAX_SIMULTANEOUS_WRITES"),
+		"QUEUE_BACKEND":                 os.Getenv("QUEUE_BACKEND"),
+		"QUEUE_VISIBILITY_TIMEOUT":      os.Getenv("QUEUE_VISIBILITY_TIMEOUT"),
+		"QUEUE_POLL_INTERVAL":           os.Getenv("QUEUE_POLL_INTERVAL"),
+		"QUEUE_RETRY_BACKOFF":           os.Getenv("QUEUE_RETRY_BACKOFF"),
+		"QUEUE_RETRY_BASE_DELAY":        os.Getenv("QUEUE_RETRY_BASE_DELAY"),
+		"QUEUE_RETRY_MAX_DELAY":         os.Getenv("QUEUE_RETRY_MAX_DELAY"),
+		"QUEUE_MIN_WORKERS":             os.Getenv("QUEUE_MIN_WORKERS"),
+		"QUEUE_MAX_WORKERS":             os.Getenv("QUEUE_MAX_WORKERS"),
+		"QUEUE_SCALE_INTERVAL":          os.Getenv("QUEUE_SCALE_INTERVAL"),
+		"QUEUE_SCALE_TARGET_WAIT":       os.Getenv("QUEUE_SCALE_TARGET_WAIT"),
+		"QUEUE_DRAIN_TIMEOUT":           os.Getenv("QUEUE_DRAIN_TIMEOUT"),
 	}
 
 	// Cleanup function
@@ -50,6 +62,36 @@ func TestConfigManager_LoadConfig(t *testing.T) {
 		if config.MaxSimultaneousWrites != 50 {
 			t.Errorf("Expected max simultaneous writes to be 50, got: %d", config.MaxSimultaneousWrites)
 		}
+		if config.Backend != BackendMemory {
+			t.Errorf("Expected backend to be memory, got: %s", config.Backend)
+		}
+		if config.VisibilityTimeout != 30*time.Second {
+			t.Errorf("Expected visibility timeout to be 30s, got: %v", config.VisibilityTimeout)
+		}
+		if config.PollInterval != 100*time.Millisecond {
+			t.Errorf("Expected poll interval to be 100ms, got: %v", config.PollInterval)
+		}
+		if config.RetryBackoff != BackoffExponential {
+			t.Errorf("Expected retry backoff to be exponential, got: %s", config.RetryBackoff)
+		}
+		if config.RetryBaseDelay != time.Second {
+			t.Errorf("Expected retry base delay to be 1s, got: %v", config.RetryBaseDelay)
+		}
+		if config.RetryMaxDelay != 30*time.Second {
+			t.Errorf("Expected retry max delay to be 30s, got: %v", config.RetryMaxDelay)
+		}
+		if config.MinWorkers != 10 || config.MaxWorkers != 10 {
+			t.Errorf("Expected a fixed pool of 10 workers, got: %d to %d", config.MinWorkers, config.MaxWorkers)
+		}
+		if config.ScaleInterval != time.Second {
+			t.Errorf("Expected scale interval to be 1s, got: %v", config.ScaleInterval)
+		}
+		if config.ScaleTargetWait != 500*time.Millisecond {
+			t.Errorf("Expected scale target wait to be 500ms, got: %v", config.ScaleTargetWait)
+		}
+		if config.DrainTimeout != 5*time.Second {
+			t.Errorf("Expected drain timeout to be 5s, got: %v", config.DrainTimeout)
+		}
 	})
 
 	t.Run("Custom values", func(t *testing.T) {
@@ -57,6 +99,17 @@ func TestConfigManager_LoadConfig(t *testing.T) {
 		os.Setenv("QUEUE_BUFFER_SIZE", "20000")
 		os.Setenv("QUEUE_MAX_ENQUEUE_RETRIES", "8")
 		os.Setenv("QUEUE_MAX_SIMULTANEOUS_WRITES", "100")
+		os.Setenv("QUEUE_BACKEND", "postgres")
+		os.Setenv("QUEUE_VISIBILITY_TIMEOUT", "1m")
+		os.Setenv("QUEUE_POLL_INTERVAL", "250ms")
+		os.Setenv("QUEUE_RETRY_BACKOFF", "decorrelated_jitter")
+		os.Setenv("QUEUE_RETRY_BASE_DELAY", "200ms")
+		os.Setenv("QUEUE_RETRY_MAX_DELAY", "1m")
+		os.Setenv("QUEUE_MIN_WORKERS", "5")
+		os.Setenv("QUEUE_MAX_WORKERS", "40")
+		os.Setenv("QUEUE_SCALE_INTERVAL", "2s")
+		os.Setenv("QUEUE_SCALE_TARGET_WAIT", "250ms")
+		os.Setenv("QUEUE_DRAIN_TIMEOUT", "8s")
 
 		cm := NewConfigManager()
 		err := cm.LoadConfig()
@@ -77,6 +130,36 @@ func TestConfigManager_LoadConfig(t *testing.T) {
 		if config.MaxSimultaneousWrites != 100 {
 			t.Errorf("Expected max simultaneous writes to be 100, got: %d", config.MaxSimultaneousWrites)
 		}
+		if config.Backend != BackendPostgres {
+			t.Errorf("Expected backend to be postgres, got: %s", config.Backend)
+		}
+		if config.VisibilityTimeout != time.Minute {
+			t.Errorf("Expected visibility timeout to be 1m, got: %v", config.VisibilityTimeout)
+		}
+		if config.PollInterval != 250*time.Millisecond {
+			t.Errorf("Expected poll interval to be 250ms, got: %v", config.PollInterval)
+		}
+		if config.RetryBackoff != BackoffDecorrelatedJitter {
+			t.Errorf("Expected retry backoff to be decorrelated_jitter, got: %s", config.RetryBackoff)
+		}
+		if config.RetryBaseDelay != 200*time.Millisecond {
+			t.Errorf("Expected retry base delay to be 200ms, got: %v", config.RetryBaseDelay)
+		}
+		if config.RetryMaxDelay != time.Minute {
+			t.Errorf("Expected retry max delay to be 1m, got: %v", config.RetryMaxDelay)
+		}
+		if config.MinWorkers != 5 || config.MaxWorkers != 40 {
+			t.Errorf("Expected a pool of 5 to 40 workers, got: %d to %d", config.MinWorkers, config.MaxWorkers)
+		}
+		if config.ScaleInterval != 2*time.Second {
+			t.Errorf("Expected scale interval to be 2s, got: %v", config.ScaleInterval)
+		}
+		if config.ScaleTargetWait != 250*time.Millisecond {
+			t.Errorf("Expected scale target wait to be 250ms, got: %v", config.ScaleTargetWait)
+		}
+		if config.DrainTimeout != 8*time.Second {
+			t.Errorf("Expected drain timeout to be 8s, got: %v", config.DrainTimeout)
+		}
 	})
 
 	t.Run("Invalid values", func(t *testing.T) {
@@ -151,6 +234,156 @@ func TestConfigManager_Validate(t *testing.T) {
 		}
 	})
 
+	t.Run("Invalid backend", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{
+			Workers:               10,
+			BufferSize:            1000,
+			MaxEnqueueRetries:     3,
+			MaxSimultaneousWrites: 50,
+			Backend:               "redis",
+		})
+
+		err := cm.Validate()
+		if err == nil {
+			t.Fatal("Expected error for invalid backend")
+		}
+	})
+
+	t.Run("Retry policy", func(t *testing.T) {
+		valid := func() *Config {
+			return &Config{
+				Workers:               10,
+				BufferSize:            1000,
+				MaxEnqueueRetries:     3,
+				MaxSimultaneousWrites: 50,
+				RetryBackoff:          BackoffExponential,
+				RetryBaseDelay:        time.Second,
+				RetryMaxDelay:         30 * time.Second,
+			}
+		}
+
+		cm := NewConfigManager()
+		cm.SetConfig(valid())
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		invalid := map[string]func(*Config){
+			"unknown backoff":                func(c *Config) { c.RetryBackoff = "linear" },
+			"zero base delay":                func(c *Config) { c.RetryBaseDelay = 0 },
+			"max delay below the base delay": func(c *Config) { c.RetryMaxDelay = 500 * time.Millisecond },
+		}
+
+		for name, mutate := range invalid {
+			config := valid()
+			mutate(config)
+			cm.SetConfig(config)
+
+			if err := cm.Validate(); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		}
+	})
+
+	t.Run("Worker pool bounds", func(t *testing.T) {
+		valid := func() *Config {
+			return &Config{
+				Workers:               10,
+				MinWorkers:            2,
+				MaxWorkers:            20,
+				BufferSize:            1000,
+				MaxEnqueueRetries:     3,
+				MaxSimultaneousWrites: 50,
+				ScaleInterval:         time.Second,
+				ScaleTargetWait:       500 * time.Millisecond,
+			}
+		}
+
+		cm := NewConfigManager()
+		cm.SetConfig(valid())
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		invalid := map[string]func(*Config){
+			"min workers above workers": func(c *Config) { c.MinWorkers = 11 },
+			"negative min workers":      func(c *Config) { c.MinWorkers = -1 },
+			"max workers below workers": func(c *Config) { c.MaxWorkers = 9 },
+			"zero scale interval":       func(c *Config) { c.ScaleInterval = 0 },
+			"zero scale target wait":    func(c *Config) { c.ScaleTargetWait = 0 },
+		}
+
+		for name, mutate := range invalid {
+			config := valid()
+			mutate(config)
+			cm.SetConfig(config)
+
+			if err := cm.Validate(); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		}
+
+		// A fixed pool does not scale, so it needs no scaling settings
+		fixed := valid()
+		fixed.MinWorkers, fixed.MaxWorkers = 10, 10
+		fixed.ScaleInterval, fixed.ScaleTargetWait = 0, 0
+		cm.SetConfig(fixed)
+		if err := cm.Validate(); err != nil {
+			t.Errorf("Expected no error for a fixed pool, got: %v", err)
+		}
+	})
+
+	t.Run("Negative drain timeout", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{
+			Workers:               10,
+			BufferSize:            1000,
+			MaxEnqueueRetries:     3,
+			MaxSimultaneousWrites: 50,
+			DrainTimeout:          -time.Second,
+		})
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for a negative drain timeout")
+		}
+	})
+
+	t.Run("Postgres backend", func(t *testing.T) {
+		valid := func() *Config {
+			return &Config{
+				Workers:               10,
+				BufferSize:            1000,
+				MaxEnqueueRetries:     3,
+				MaxSimultaneousWrites: 50,
+				Backend:               BackendPostgres,
+				VisibilityTimeout:     30 * time.Second,
+				PollInterval:          100 * time.Millisecond,
+			}
+		}
+
+		cm := NewConfigManager()
+		cm.SetConfig(valid())
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		invalid := map[string]func(*Config){
+			"visibility timeout not longer than the job timeout": func(c *Config) { c.VisibilityTimeout = jobTimeout },
+			"zero poll interval": func(c *Config) { c.PollInterval = 0 },
+		}
+
+		for name, mutate := range invalid {
+			config := valid()
+			mutate(config)
+			cm.SetConfig(config)
+
+			if err := cm.Validate(); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		}
+	})
+
 	t.Run("Nil config", func(t *testing.T) {
 		cm := NewConfigManager()
 
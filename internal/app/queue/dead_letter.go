@@ -0,0 +1,30 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/google/uuid"
+)
+
+// newJobAttempt describes a failed attempt at processing a job
+func newJobAttempt(attempt int, err error) domain.JobAttempt {
+	return domain.JobAttempt{Attempt: attempt, Error: err.Error(), At: time.Now().UTC()}
+}
+
+// addDeadLetter stores a job that ran out of attempts. The job ID is the dead
+// letter ID, so storing the same job twice keeps one dead letter.
+func addDeadLetter(deadLetters repository.DeadLetterRepository, id uuid.UUID, payment domain.Payment, attempts []domain.JobAttempt, enqueuedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	return deadLetters.Add(ctx, domain.DeadLetter{
+		ID:         id,
+		Payment:    payment,
+		Attempts:   attempts,
+		EnqueuedAt: enqueuedAt,
+		FailedAt:   time.Now().UTC(),
+	})
+}
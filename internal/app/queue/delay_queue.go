@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// delayQueue holds jobs waiting for their retry and hands each one to ready
+// when it is due. A single goroutine serves every pending retry.
+type delayQueue struct {
+	pending delayedJobs
+	ready   chan<- PaymentJob
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	mutex   sync.Mutex
+}
+
+// delayedJob is a job and the time it is due
+type delayedJob struct {
+	job PaymentJob
+	due time.Time
+}
+
+// delayedJobs is a min-heap of delayed jobs, earliest due first
+type delayedJobs []delayedJob
+
+func (d delayedJobs) Len() int           { return len(d) }
+func (d delayedJobs) Less(i, j int) bool { return d[i].due.Before(d[j].due) }
+func (d delayedJobs) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d *delayedJobs) Push(x any)        { *d = append(*d, x.(delayedJob)) }
+func (d *delayedJobs) Pop() any {
+	old := *d
+	last := old[len(old)-1]
+	*d = old[:len(old)-1]
+	return last
+}
+
+func newDelayQueue(ready chan<- PaymentJob) *delayQueue {
+	q := &delayQueue{
+		ready: ready,
+		wake:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	go q.run()
+
+	return q
+}
+
+// Add schedules a job to be handed out after delay
+func (q *delayQueue) Add(job PaymentJob, delay time.Duration) {
+	q.mutex.Lock()
+	heap.Push(&q.pending, delayedJob{job: job, due: time.Now().Add(delay)})
+	q.mutex.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of jobs waiting for their retry
+func (q *delayQueue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.pending.Len()
+}
+
+// run hands out the due jobs, sleeping until the earliest one is due
+func (q *delayQueue) run() {
+	defer close(q.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		job, wait, due := q.popDue()
+
+		if due {
+			select {
+			case q.ready <- job:
+			case <-q.stop:
+				// Keep the job so Stop returns it
+				q.mutex.Lock()
+				heap.Push(&q.pending, delayedJob{job: job, due: time.Now()})
+				q.mutex.Unlock()
+				return
+			}
+			continue
+		}
+
+		timer.Reset(wait)
+
+		select {
+		case <-timer.C:
+		case <-q.wake:
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// popDue removes and returns the earliest job if it is due, or returns how
+// long until it is
+func (q *delayQueue) popDue() (PaymentJob, time.Duration, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.pending.Len() == 0 {
+		return PaymentJob{}, time.Hour, false
+	}
+
+	if wait := time.Until(q.pending[0].due); wait > 0 {
+		return PaymentJob{}, wait, false
+	}
+
+	return heap.Pop(&q.pending).(delayedJob).job, 0, true
+}
+
+// Stop stops handing out jobs and returns the ones still waiting
+func (q *delayQueue) Stop() []PaymentJob {
+	close(q.stop)
+	<-q.done
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	jobs := make([]PaymentJob, 0, q.pending.Len())
+	for _, d := range q.pending {
+		jobs = append(jobs, d.job)
+	}
+	q.pending = nil
+
+	return jobs
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayQueue(t *testing.T) {
+	t.Run("Hands out jobs when they are due, earliest first", func(t *testing.T) {
+		ready := make(chan PaymentJob, 3)
+		q := newDelayQueue(ready)
+		defer q.Stop()
+
+		q.Add(PaymentJob{Retries: 3}, 60*time.Millisecond)
+		q.Add(PaymentJob{Retries: 1}, 20*time.Millisecond)
+		q.Add(PaymentJob{Retries: 2}, 40*time.Millisecond)
+
+		if q.Len() != 3 {
+			t.Errorf("Expected 3 pending jobs, got: %d", q.Len())
+		}
+
+		start := time.Now()
+		for want := 1; want <= 3; want++ {
+			select {
+			case job := <-ready:
+				if job.Retries != want {
+					t.Errorf("Expected job %d, got: %d", want, job.Retries)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Expected the job to be handed out")
+			}
+		}
+
+		if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+			t.Errorf("Expected the jobs to wait for their delay, took: %v", elapsed)
+		}
+		if q.Len() != 0 {
+			t.Errorf("Expected no pending jobs, got: %d", q.Len())
+		}
+	})
+
+	t.Run("Returns the pending jobs when stopped", func(t *testing.T) {
+		q := newDelayQueue(make(chan PaymentJob))
+
+		q.Add(PaymentJob{}, time.Hour)
+		q.Add(PaymentJob{}, time.Hour)
+
+		if pending := q.Stop(); len(pending) != 2 {
+			t.Errorf("Expected 2 pending jobs, got: %d", len(pending))
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// drainWithin waits for wait to return, cancelling the running jobs through
+// cancel once timeout elapses. It reports whether the queue drained in time.
+func drainWithin(timeout time.Duration, wait func(), cancel context.CancelFunc) bool {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		cancel()
+		<-done
+		return false
+	}
+}
@@ -0,0 +1,322 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
+	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
+	"github.com/google/uuid"
+)
+
+// DurableQueue processes payments stored in a PaymentJobRepository, so
+// accepted payments survive crashes, restarts and deploys. A fetcher claims
+// jobs for the idle workers; a job whose worker dies is claimed again once
+// its visibility timeout expires.
+type DurableQueue struct {
+	repo        repository.PaymentJobRepository
+	deadLetters repository.DeadLetterRepository
+	service     interfaces.PaymentServiceInterface
+	retryPolicy *RetryPolicy
+	counters    queueCounters
+	config      *Config
+	owner       string
+	workers     *workerPool
+	jobs        chan domain.PaymentJob
+	idle        chan struct{}
+	wake        chan struct{}
+	semaphore   chan struct{}
+	ctx         context.Context
+	cancel      context.CancelFunc
+	fetchCtx    context.Context
+	stopFetch   context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewDurableQueue creates the durable queue. Jobs that run out of attempts
+// are moved to deadLetters, or dropped when it is nil.
+func NewDurableQueue(queueConfig *Config, repo repository.PaymentJobRepository, deadLetters repository.DeadLetterRepository, service interfaces.PaymentServiceInterface) *DurableQueue {
+	// Cancelled when the drain times out so in-flight processor calls are
+	// aborted; claiming jobs stops as soon as the shutdown starts
+	ctx, cancel := context.WithCancel(context.Background())
+	fetchCtx, stopFetch := context.WithCancel(ctx)
+
+	hostname, _ := os.Hostname()
+
+	q := &DurableQueue{
+		repo:        repo,
+		deadLetters: deadLetters,
+		service:     service,
+		retryPolicy: NewRetryPolicy(queueConfig),
+		config:      queueConfig,
+		owner:       fmt.Sprintf("%s/%s", hostname, uuid.NewString()),
+		jobs:        make(chan domain.PaymentJob),
+		idle:        make(chan struct{}, max(queueConfig.Workers, queueConfig.MaxWorkers)),
+		wake:        make(chan struct{}, 1),
+		semaphore:   make(chan struct{}, queueConfig.MaxSimultaneousWrites),
+		ctx:         ctx,
+		cancel:      cancel,
+		fetchCtx:    fetchCtx,
+		stopFetch:   stopFetch,
+	}
+
+	q.workers = newWorkerPool(queueConfig, q.worker)
+
+	q.wg.Add(2)
+	go q.fetch()
+	go func() {
+		defer q.wg.Done()
+		autoscale(fetchCtx, queueConfig, q.workers, &q.counters, q.depth)
+	}()
+
+	return q
+}
+
+// Enqueue stores the payment; it is accepted once the job is written
+func (q *DurableQueue) Enqueue(payment *domain.Payment) error {
+	if payment.RequestedAt.IsZero() {
+		payment.RequestedAt = domain.NewRequestedAt()
+	}
+
+	ctx, cancel := context.WithTimeout(q.ctx, jobTimeout)
+	defer cancel()
+
+	if err := q.repo.Enqueue(ctx, payment); err != nil {
+		return err
+	}
+
+	// Let the fetcher pick the job up without waiting for the next poll
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Stats returns the jobs waiting in the repository, shared by every instance,
+// along with this instance's workers and the outcome of its attempts since
+// startup
+func (q *DurableQueue) Stats(ctx context.Context) (*domain.QueueStats, error) {
+	jobStats, err := q.repo.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := q.counters.snapshot(BackendPostgres, q.workers.Status())
+	stats.Depth = jobStats.Ready
+	stats.PendingRetries = jobStats.Delayed
+	stats.OldestJobAgeMs = jobAge(jobStats.OldestCreatedAt)
+
+	return &stats, nil
+}
+
+// Workers returns the size and bounds of this instance's worker pool
+func (q *DurableQueue) Workers() domain.WorkerPool {
+	return q.workers.Status()
+}
+
+// ResizeWorkers changes the size or the bounds of this instance's worker
+// pool. Retired workers finish their running job first.
+func (q *DurableQueue) ResizeWorkers(resize domain.WorkerPool) (*domain.WorkerPool, error) {
+	return q.workers.Resize(resize)
+}
+
+// depth returns the number of jobs ready to be claimed
+func (q *DurableQueue) depth(ctx context.Context) (int, error) {
+	stats, err := q.repo.Stats(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return stats.Ready, nil
+}
+
+// fetch claims as many jobs as there are idle workers and hands them out,
+// polling while the queue is empty
+func (q *DurableQueue) fetch() {
+	defer q.wg.Done()
+
+	free := 0
+	for {
+		if free == 0 {
+			select {
+			case <-q.idle:
+				free++
+			case <-q.fetchCtx.Done():
+				return
+			}
+		}
+
+	collect:
+		for {
+			select {
+			case <-q.idle:
+				free++
+			default:
+				break collect
+			}
+		}
+
+		jobs, err := q.repo.Claim(q.fetchCtx, q.owner, free, q.config.VisibilityTimeout)
+		if err != nil && q.fetchCtx.Err() == nil {
+			log.Printf("Failed to claim payment jobs: %v", err)
+		}
+
+		for i, job := range jobs {
+			select {
+			case q.jobs <- job:
+				free--
+			case <-q.fetchCtx.Done():
+				// Shutting down, let another worker take the rest right away
+				for _, unsent := range jobs[i:] {
+					q.release(unsent)
+				}
+				return
+			}
+		}
+
+		if len(jobs) == 0 {
+			select {
+			case <-q.wake:
+			case <-time.After(q.config.PollInterval):
+			case <-q.fetchCtx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (q *DurableQueue) worker(workerID int, quit <-chan struct{}) {
+	for {
+		select {
+		case q.idle <- struct{}{}:
+		case <-quit:
+			return
+		case <-q.fetchCtx.Done():
+			return
+		}
+
+		select {
+		case job := <-q.jobs:
+			q.processJob(job, workerID)
+		case <-quit:
+			q.retire(workerID)
+			return
+		case <-q.fetchCtx.Done():
+			return
+		}
+	}
+}
+
+// retire takes back the idle token of a worker leaving the pool. When the
+// fetcher already counted it, a job may be claimed for this worker, so the
+// worker waits for it before leaving.
+func (q *DurableQueue) retire(workerID int) {
+	select {
+	case <-q.idle:
+		return
+	default:
+	}
+
+	select {
+	case job := <-q.jobs:
+		q.processJob(job, workerID)
+	case <-q.fetchCtx.Done():
+	}
+}
+
+func (q *DurableQueue) processJob(job domain.PaymentJob, workerID int) {
+	q.counters.inFlight.Add(1)
+	defer q.counters.inFlight.Add(-1)
+
+	q.semaphore <- struct{}{}
+	defer func() { <-q.semaphore }()
+
+	jobCtx, cancel := context.WithTimeout(q.ctx, jobTimeout)
+	defer cancel()
+
+	log.Printf("[Worker %d] Processing job %s (attempt %d) - timestamp: %v", workerID, job.ID, job.Attempts, time.Now().UnixNano())
+
+	start := time.Now()
+	err := q.service.Process(jobCtx, &job.Payment)
+	q.counters.recordLatency(time.Since(start))
+
+	// The repository is updated even when the queue is shutting down
+	ctx, cancelUpdate := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancelUpdate()
+
+	switch {
+	case err == nil:
+		log.Printf("[Worker %d] Successfully processed job %s in %v - timestamp: %v", workerID, job.ID, time.Since(job.CreatedAt), time.Now().UnixNano())
+		q.counters.succeeded.Add(1)
+		err = q.repo.Complete(ctx, job)
+	case q.ctx.Err() != nil:
+		// Interrupted by the shutdown, the attempt does not count
+		q.release(job)
+		return
+	default:
+		q.counters.failed.Add(1)
+		failure := newJobAttempt(job.Attempts, err)
+
+		delay, retry := q.retryPolicy.Next(job.Attempts, job.RetryDelay, err)
+		if !retry {
+			err = q.deadLetter(ctx, job, failure, workerID)
+			break
+		}
+
+		log.Printf("[Worker %d] Failed to process payment for job %s: %v, retrying in %v", workerID, job.ID, err, delay)
+		err = q.repo.Retry(ctx, job, delay, failure)
+	}
+
+	if err != nil {
+		log.Printf("[Worker %d] Failed to update job %s: %v", workerID, job.ID, err)
+	}
+}
+
+// deadLetter moves a job that ran out of attempts to the dead letters. If the
+// dead letter cannot be stored, the job stays leased and is dead-lettered
+// again once its lease expires.
+func (q *DurableQueue) deadLetter(ctx context.Context, job domain.PaymentJob, failure domain.JobAttempt, workerID int) error {
+	if q.deadLetters == nil {
+		log.Printf("[Worker %d] Job %s failed after %d attempts, dropping: %s", workerID, job.ID, job.Attempts, failure.Error)
+		return q.repo.Complete(ctx, job)
+	}
+
+	if err := addDeadLetter(q.deadLetters, job.ID, job.Payment, append(job.Errors, failure), job.CreatedAt); err != nil {
+		return err
+	}
+
+	q.counters.deadLettered.Add(1)
+	log.Printf("[Worker %d] Job %s failed after %d attempts, dead-lettered: %s", workerID, job.ID, job.Attempts, failure.Error)
+	return q.repo.Complete(ctx, job)
+}
+
+// release hands a claimed job back without counting the attempt
+func (q *DurableQueue) release(job domain.PaymentJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	if err := q.repo.Release(ctx, job); err != nil {
+		log.Printf("Failed to release job %s: %v", job.ID, err)
+	}
+}
+
+// Shutdown stops claiming jobs and lets the jobs in progress finish for up
+// to the drain timeout, then aborts the ones still running and hands them
+// back to the queue
+func (q *DurableQueue) Shutdown() {
+	q.stopFetch()
+
+	if !drainWithin(q.config.DrainTimeout, q.workers.Close, q.cancel) {
+		log.Printf("Queue drain timed out after %v, released the running jobs", q.config.DrainTimeout)
+	}
+
+	q.cancel()
+	q.wg.Wait()
+}
@@ -0,0 +1,514 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/google/uuid"
+)
+
+// memoryJobRepository is an in-memory PaymentJobRepository with the leasing
+// rules of the Postgres one
+type memoryJobRepository struct {
+	jobs  map[uuid.UUID]*memoryJob
+	mutex sync.Mutex
+}
+
+type memoryJob struct {
+	job       domain.PaymentJob
+	visibleAt time.Time
+}
+
+func newMemoryJobRepository() *memoryJobRepository {
+	return &memoryJobRepository{jobs: make(map[uuid.UUID]*memoryJob)}
+}
+
+func (r *memoryJobRepository) Enqueue(ctx context.Context, payment *domain.Payment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, j := range r.jobs {
+		if j.job.Payment.CorrelationID == payment.CorrelationID {
+			return nil
+		}
+	}
+
+	id := uuid.New()
+	r.jobs[id] = &memoryJob{job: domain.PaymentJob{ID: id, Payment: *payment, CreatedAt: time.Now()}, visibleAt: time.Now()}
+	return nil
+}
+
+func (r *memoryJobRepository) Claim(ctx context.Context, owner string, limit int, visibilityTimeout time.Duration) ([]domain.PaymentJob, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var claimed []domain.PaymentJob
+	for _, j := range r.jobs {
+		if len(claimed) == limit {
+			break
+		}
+		if j.visibleAt.After(time.Now()) {
+			continue
+		}
+
+		j.job.Attempts++
+		j.job.LeaseID = uuid.New()
+		j.visibleAt = time.Now().Add(visibilityTimeout)
+		claimed = append(claimed, j.job)
+	}
+
+	return claimed, nil
+}
+
+// leased returns the stored job if the lease of job is still held
+func (r *memoryJobRepository) leased(job domain.PaymentJob) (*memoryJob, error) {
+	j, ok := r.jobs[job.ID]
+	if !ok || j.job.LeaseID != job.LeaseID {
+		return nil, core.ErrJobLeaseExpired
+	}
+	return j, nil
+}
+
+func (r *memoryJobRepository) Complete(ctx context.Context, job domain.PaymentJob) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, err := r.leased(job); err != nil {
+		return err
+	}
+
+	delete(r.jobs, job.ID)
+	return nil
+}
+
+func (r *memoryJobRepository) Retry(ctx context.Context, job domain.PaymentJob, delay time.Duration, failure domain.JobAttempt) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	j, err := r.leased(job)
+	if err != nil {
+		return err
+	}
+
+	j.job.LeaseID = uuid.Nil
+	j.job.Errors = append(j.job.Errors, failure)
+	j.job.RetryDelay = delay
+	j.visibleAt = time.Now().Add(delay)
+	return nil
+}
+
+func (r *memoryJobRepository) Release(ctx context.Context, job domain.PaymentJob) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	j, err := r.leased(job)
+	if err != nil {
+		return err
+	}
+
+	j.job.Attempts--
+	j.job.LeaseID = uuid.Nil
+	j.visibleAt = time.Now()
+	return nil
+}
+
+func (r *memoryJobRepository) Stats(ctx context.Context) (*domain.PaymentJobStats, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var stats domain.PaymentJobStats
+	for _, j := range r.jobs {
+		switch {
+		case !j.visibleAt.After(time.Now()):
+			stats.Ready++
+		case j.job.LeaseID == uuid.Nil:
+			stats.Delayed++
+		}
+
+		if stats.OldestCreatedAt.IsZero() || j.job.CreatedAt.Before(stats.OldestCreatedAt) {
+			stats.OldestCreatedAt = j.job.CreatedAt
+		}
+	}
+
+	return &stats, nil
+}
+
+// snapshot returns a copy of the stored jobs
+func (r *memoryJobRepository) snapshot() []memoryJob {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var jobs []memoryJob
+	for _, j := range r.jobs {
+		jobs = append(jobs, *j)
+	}
+	return jobs
+}
+
+// memoryDeadLetterRepository is an in-memory DeadLetterRepository
+type memoryDeadLetterRepository struct {
+	letters []domain.DeadLetter
+	mutex   sync.Mutex
+}
+
+func (r *memoryDeadLetterRepository) Add(ctx context.Context, letter domain.DeadLetter) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.letters = append(r.letters, letter)
+	return nil
+}
+
+func (r *memoryDeadLetterRepository) List(ctx context.Context, offset, limit int) ([]domain.DeadLetter, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return slices.Clone(r.letters[min(offset, len(r.letters)):min(offset+limit, len(r.letters))]), nil
+}
+
+func (r *memoryDeadLetterRepository) Get(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error) {
+	return nil, core.ErrDeadLetterNotFound
+}
+
+func (r *memoryDeadLetterRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return core.ErrDeadLetterNotFound
+}
+
+func (r *memoryDeadLetterRepository) Purge(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// fakePaymentService processes payments with a test function
+type fakePaymentService struct {
+	process func(ctx context.Context, payment *domain.Payment) error
+}
+
+func (s *fakePaymentService) Process(ctx context.Context, payment *domain.Payment) error {
+	return s.process(ctx, payment)
+}
+
+func (s *fakePaymentService) Summary(ctx context.Context, from, to *time.Time) (*domain.PaymentSummary, error) {
+	return &domain.PaymentSummary{}, nil
+}
+
+func (s *fakePaymentService) Purge(ctx context.Context) error {
+	return nil
+}
+
+func newTestDurableQueueConfig(maxRetries int) *Config {
+	return &Config{
+		Workers:               2,
+		BufferSize:            10,
+		MaxEnqueueRetries:     maxRetries,
+		MaxSimultaneousWrites: 2,
+		Backend:               BackendPostgres,
+		VisibilityTimeout:     30 * time.Second,
+		PollInterval:          10 * time.Millisecond,
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newTestPayment() *domain.Payment {
+	return &domain.Payment{CorrelationID: uuid.New(), Amount: 19.9}
+}
+
+func TestDurableQueue(t *testing.T) {
+	t.Run("Processes and completes enqueued payments", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		processed := make(chan uuid.UUID, 3)
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			processed <- payment.CorrelationID
+			return nil
+		}}
+
+		q := NewDurableQueue(newTestDurableQueueConfig(1), repo, nil, service)
+		defer q.Shutdown()
+
+		for range 3 {
+			if err := q.Enqueue(newTestPayment()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		for range 3 {
+			select {
+			case <-processed:
+			case <-time.After(time.Second):
+				t.Fatal("Expected the payments to be processed")
+			}
+		}
+
+		waitFor(t, func() bool { return len(repo.snapshot()) == 0 })
+	})
+
+	t.Run("Retries a failed payment later", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			return core.ErrProcessorUnavailable
+		}}
+
+		q := NewDurableQueue(newTestDurableQueueConfig(1), repo, nil, service)
+		defer q.Shutdown()
+
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		waitFor(t, func() bool {
+			jobs := repo.snapshot()
+			return len(jobs) == 1 && len(jobs[0].job.Errors) == 1
+		})
+
+		job := repo.snapshot()[0]
+		if job.job.Attempts != 1 {
+			t.Errorf("Expected 1 attempt, got: %d", job.job.Attempts)
+		}
+		if !job.visibleAt.After(time.Now()) {
+			t.Error("Expected the job to stay hidden until its retry")
+		}
+	})
+
+	t.Run("Drops a payment after its last attempt", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			return core.ErrProcessorUnavailable
+		}}
+
+		q := NewDurableQueue(newTestDurableQueueConfig(0), repo, nil, service)
+		defer q.Shutdown()
+
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		waitFor(t, func() bool { return len(repo.snapshot()) == 0 })
+	})
+
+	t.Run("Dead-letters a payment with the error of each attempt", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		deadLetters := &memoryDeadLetterRepository{}
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			return core.ErrProcessorUnavailable
+		}}
+
+		// The first attempt failed before, this one is the last
+		p := newTestPayment()
+		if err := repo.Enqueue(context.Background(), p); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		for _, j := range repo.jobs {
+			j.job.Attempts = 1
+			j.job.Errors = []domain.JobAttempt{{Attempt: 1, Error: "processor timeout", At: time.Now()}}
+		}
+
+		q := NewDurableQueue(newTestDurableQueueConfig(1), repo, deadLetters, service)
+		defer q.Shutdown()
+
+		waitFor(t, func() bool { return len(repo.snapshot()) == 0 })
+
+		letters, _ := deadLetters.List(context.Background(), 0, 10)
+		if len(letters) != 1 {
+			t.Fatalf("Expected 1 dead letter, got: %d", len(letters))
+		}
+		if letters[0].Payment.CorrelationID != p.CorrelationID {
+			t.Errorf("Expected the dead letter of the payment, got: %v", letters[0].Payment.CorrelationID)
+		}
+		if len(letters[0].Attempts) != 2 || letters[0].Attempts[1].Attempt != 2 || letters[0].Attempts[1].Error != core.ErrProcessorUnavailable.Error() {
+			t.Errorf("Expected the errors of both attempts, got: %+v", letters[0].Attempts)
+		}
+	})
+
+	t.Run("Hands in-flight jobs back on shutdown", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		started := make(chan struct{})
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+
+		q := NewDurableQueue(newTestDurableQueueConfig(1), repo, nil, service)
+
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the payment to be processed")
+		}
+
+		q.Shutdown()
+
+		jobs := repo.snapshot()
+		if len(jobs) != 1 {
+			t.Fatalf("Expected the job to remain queued, got: %d jobs", len(jobs))
+		}
+		if jobs[0].job.Attempts != 0 || jobs[0].job.LeaseID != uuid.Nil {
+			t.Errorf("Expected the job released without counting the attempt, got: %+v", jobs[0].job)
+		}
+		if jobs[0].visibleAt.After(time.Now()) {
+			t.Error("Expected the job to be visible right away")
+		}
+	})
+
+	t.Run("Reports the jobs waiting in the repository", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		started := make(chan struct{}, 1)
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+
+		// Keep one job waiting for a retry
+		if err := repo.Enqueue(context.Background(), newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		for _, j := range repo.jobs {
+			j.job.CreatedAt = time.Now().Add(-time.Minute)
+			j.visibleAt = time.Now().Add(time.Hour)
+		}
+
+		q := NewDurableQueue(newTestDurableQueueConfig(1), repo, nil, service)
+		defer q.Shutdown()
+
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the payment to be processed")
+		}
+
+		stats, err := q.Stats(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if stats.Backend != BackendPostgres || stats.Workers != 2 {
+			t.Errorf("Expected the postgres backend with 2 workers, got: %+v", stats)
+		}
+		if stats.InFlight != 1 || stats.Utilization != 0.5 {
+			t.Errorf("Expected 1 job in flight and half the workers busy, got: %+v", stats)
+		}
+		if stats.Depth != 0 || stats.PendingRetries != 1 {
+			t.Errorf("Expected no job ready and 1 pending retry, got: %+v", stats)
+		}
+		if stats.OldestJobAgeMs < time.Minute.Milliseconds() {
+			t.Errorf("Expected the oldest job to be at least a minute old, got: %dms", stats.OldestJobAgeMs)
+		}
+	})
+
+	t.Run("Finishes running jobs when the pool shrinks", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			started <- struct{}{}
+			<-release
+			return ctx.Err()
+		}}
+
+		config := newTestDurableQueueConfig(1)
+		config.MinWorkers = 1
+		q := NewDurableQueue(config, repo, nil, service)
+		defer q.Shutdown()
+
+		for range 2 {
+			if err := q.Enqueue(newTestPayment()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		for range 2 {
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				t.Fatal("Expected the payments to be processed")
+			}
+		}
+
+		if _, err := q.ResizeWorkers(domain.WorkerPool{Workers: 1}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		close(release)
+
+		// Both jobs succeed: the retired worker was not cancelled
+		waitFor(t, func() bool { return len(repo.snapshot()) == 0 })
+
+		stats, _ := q.Stats(context.Background())
+		if stats.Workers != 1 || stats.Succeeded != 2 {
+			t.Errorf("Expected 1 worker and 2 successes, got: %+v", stats)
+		}
+	})
+
+	t.Run("Lets running jobs finish within the drain timeout", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		started := make(chan struct{})
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			return ctx.Err()
+		}}
+
+		config := newTestDurableQueueConfig(1)
+		config.DrainTimeout = time.Second
+		q := NewDurableQueue(config, repo, nil, service)
+
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the payment to be processed")
+		}
+
+		q.Shutdown()
+
+		if jobs := repo.snapshot(); len(jobs) != 0 {
+			t.Errorf("Expected the job to be completed, got: %d jobs", len(jobs))
+		}
+	})
+
+	t.Run("Does not complete a job whose lease expired", func(t *testing.T) {
+		repo := newMemoryJobRepository()
+		if err := repo.Enqueue(context.Background(), newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		first, _ := repo.Claim(context.Background(), "a", 1, 0)
+		second, _ := repo.Claim(context.Background(), "b", 1, time.Minute)
+		if len(first) != 1 || len(second) != 1 {
+			t.Fatalf("Expected the expired job to be claimed again, got: %d and %d", len(first), len(second))
+		}
+
+		if err := repo.Complete(context.Background(), first[0]); !errors.Is(err, core.ErrJobLeaseExpired) {
+			t.Errorf("Expected lease expired error, got: %v", err)
+		}
+	})
+}
@@ -8,71 +8,140 @@ import (
 
 	"github.com/fabianoflorentino/mr-robot/core"
 	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/fabianoflorentino/mr-robot/core/repository"
 	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
 	"github.com/google/uuid"
 )
 
+// jobTimeout bounds the processing of a single job
+const jobTimeout = 5 * time.Second
+
+// PaymentJob is a payment in the in-memory queue. Retries counts the retries
+// made so far and Delay is the backoff before the last one.
 type PaymentJob struct {
 	ID      uuid.UUID
 	Payment *domain.Payment
 	Retries int
+	Delay   time.Duration
+	Errors  []domain.JobAttempt
 	Created time.Time
 }
 
 type PaymentQueue struct {
-	jobs       chan PaymentJob
-	workers    int
-	service    interfaces.PaymentServiceInterface
-	stop       chan struct{}
-	wg         sync.WaitGroup
-	maxRetries int
-	semaphore  chan struct{}
-	config     *Config
+	jobs        chan PaymentJob
+	workers     *workerPool
+	service     interfaces.PaymentServiceInterface
+	deadLetters repository.DeadLetterRepository
+	retryPolicy *RetryPolicy
+	retries     *delayQueue
+	counters    queueCounters
+	outstanding *outstandingJobs
+	spool       repository.PaymentSpoolRepository
+	unfinished  []PaymentJob
+	mutex       sync.Mutex
+	stop        chan struct{}
+	wg          sync.WaitGroup
+	semaphore   chan struct{}
+	config      *Config
+	cancel      context.CancelFunc
+	stopScaling context.CancelFunc
 }
 
-func NewPaymentQueue(queueConfig *Config, service interfaces.PaymentServiceInterface) *PaymentQueue {
+// NewPaymentQueue creates the in-memory queue. Jobs that run out of retries
+// are stored in deadLetters, or dropped when it is nil. Jobs left over at
+// shutdown are written to spool, or dropped when it is nil.
+func NewPaymentQueue(queueConfig *Config, service interfaces.PaymentServiceInterface, deadLetters repository.DeadLetterRepository, spool repository.PaymentSpoolRepository) *PaymentQueue {
+	// Cancelled when the drain times out so in-flight processor calls are aborted
+	ctx, cancel := context.WithCancel(context.Background())
+	scaleCtx, stopScaling := context.WithCancel(ctx)
+
 	q := &PaymentQueue{
-		jobs:       make(chan PaymentJob, queueConfig.BufferSize),
-		workers:    queueConfig.Workers,
-		service:    service,
-		stop:       make(chan struct{}),
-		maxRetries: queueConfig.MaxEnqueueRetries,
-		semaphore:  make(chan struct{}, queueConfig.MaxSimultaneousWrites),
-		config:     queueConfig,
+		jobs:        make(chan PaymentJob, queueConfig.BufferSize),
+		service:     service,
+		deadLetters: deadLetters,
+		retryPolicy: NewRetryPolicy(queueConfig),
+		outstanding: newOutstandingJobs(),
+		spool:       spool,
+		stop:        make(chan struct{}),
+		semaphore:   make(chan struct{}, queueConfig.MaxSimultaneousWrites),
+		config:      queueConfig,
+		cancel:      cancel,
+		stopScaling: stopScaling,
 	}
 
-	for j := 0; j < queueConfig.Workers; j++ {
-		q.wg.Add(1)
-		go q.worker(context.Background(), j)
-	}
+	q.retries = newDelayQueue(q.jobs)
+	q.workers = newWorkerPool(queueConfig, func(workerID int, quit <-chan struct{}) {
+		q.worker(ctx, workerID, quit)
+	})
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		autoscale(scaleCtx, queueConfig, q.workers, &q.counters, q.depth)
+	}()
 
 	return q
 }
 
 func (q *PaymentQueue) Enqueue(payment *domain.Payment) error {
+	if payment.RequestedAt.IsZero() {
+		payment.RequestedAt = domain.NewRequestedAt()
+	}
+
 	job := PaymentJob{
 		ID:      uuid.New(),
 		Payment: payment,
-		Retries: q.config.MaxEnqueueRetries,
 		Created: time.Now(),
 	}
 
+	q.outstanding.add(job.ID, job.Created)
+
 	select {
 	case q.jobs <- job:
 		return nil
 	default:
+		q.outstanding.remove(job.ID)
 		return core.ErrQueueFull
 	}
 }
 
-func (q *PaymentQueue) worker(ctx context.Context, workerID int) {
-	defer q.wg.Done()
+// Stats returns the depth of the buffer, the jobs in flight and waiting for a
+// retry, and the outcome of the attempts since startup
+func (q *PaymentQueue) Stats(ctx context.Context) (*domain.QueueStats, error) {
+	stats := q.counters.snapshot(BackendMemory, q.workers.Status())
+	stats.Depth = len(q.jobs)
+	stats.Capacity = cap(q.jobs)
+	stats.PendingRetries = q.retries.Len()
+	stats.OldestJobAgeMs = jobAge(q.outstanding.oldest())
+
+	return &stats, nil
+}
+
+// Workers returns the size and bounds of the worker pool
+func (q *PaymentQueue) Workers() domain.WorkerPool {
+	return q.workers.Status()
+}
+
+// ResizeWorkers changes the size or the bounds of the worker pool. Retired
+// workers finish their running job first.
+func (q *PaymentQueue) ResizeWorkers(resize domain.WorkerPool) (*domain.WorkerPool, error) {
+	return q.workers.Resize(resize)
+}
+
+// depth returns the number of jobs waiting in the buffer
+func (q *PaymentQueue) depth(ctx context.Context) (int, error) {
+	return len(q.jobs), nil
+}
 
+func (q *PaymentQueue) worker(ctx context.Context, workerID int, quit <-chan struct{}) {
 	for {
 		select {
 		case job := <-q.jobs:
 			q.processJob(ctx, job, workerID)
+		case <-quit:
+			return
 		case <-q.stop:
+			q.drain(ctx, workerID)
 			return
 		case <-ctx.Done():
 			return
@@ -80,45 +149,118 @@ func (q *PaymentQueue) worker(ctx context.Context, workerID int) {
 	}
 }
 
+// drain processes the jobs left in the buffer on shutdown, until it is empty
+// or the drain timeout aborts the queue
+func (q *PaymentQueue) drain(ctx context.Context, workerID int) {
+	for ctx.Err() == nil {
+		select {
+		case job := <-q.jobs:
+			q.processJob(ctx, job, workerID)
+		default:
+			return
+		}
+	}
+}
+
 func (q *PaymentQueue) processJob(ctx context.Context, job PaymentJob, workerID int) {
+	if ctx.Err() != nil {
+		// Picked up after the drain timed out, keep it for the spool
+		q.keepUnfinished(job)
+		return
+	}
+
+	q.counters.inFlight.Add(1)
+	defer q.counters.inFlight.Add(-1)
+
 	q.semaphore <- struct{}{}
 	defer func() { <-q.semaphore }()
 
-	jobCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
 	defer cancel()
 
-	log.Printf("[Worker %d] Processing job %s (attempt %d) - timestamp: %v", workerID, job.ID, job.Retries, time.Now().UnixNano())
+	attempt := job.Retries + 1
+	log.Printf("[Worker %d] Processing job %s (attempt %d) - timestamp: %v", workerID, job.ID, attempt, time.Now().UnixNano())
 
+	start := time.Now()
 	err := q.service.Process(jobCtx, job.Payment)
+	q.counters.recordLatency(time.Since(start))
+
+	if err != nil && ctx.Err() != nil {
+		// Aborted by the shutdown, the attempt does not count
+		log.Printf("[Worker %d] Job %s interrupted by the shutdown", workerID, job.ID)
+		q.keepUnfinished(job)
+		return
+	}
+
 	if err != nil {
 		log.Printf("[Worker %d] Failed to process payment for job %s: %v", workerID, job.ID, err)
+		q.counters.failed.Add(1)
+		job.Errors = append(job.Errors, newJobAttempt(attempt, err))
 
-		// Retry logic com backoff exponencial
-		if job.Retries < q.maxRetries {
-			job.Retries++
-
-			// Backoff exponencial: 1s, 2s, 4s
-			backoff := time.Duration(1<<job.Retries) * time.Second
-			log.Printf("[Worker %d] Retrying job %s in %v", workerID, job.ID, backoff)
-
-			go func() {
-				time.Sleep(backoff)
-				select {
-				case q.jobs <- job:
-				case <-q.stop:
-				}
-			}()
-		} else {
-			log.Printf("[Worker %d] Job %s failed after %d attempts, dropping", workerID, job.ID, q.maxRetries)
+		delay, retry := q.retryPolicy.Next(attempt, job.Delay, err)
+		if !retry {
+			q.outstanding.remove(job.ID)
+			q.deadLetter(job, workerID)
+			return
 		}
+
+		job.Retries++
+		job.Delay = delay
+		log.Printf("[Worker %d] Retrying job %s in %v", workerID, job.ID, delay)
+		q.retries.Add(job, delay)
 		return
 	}
 
+	q.counters.succeeded.Add(1)
+	q.outstanding.remove(job.ID)
+
 	duration := time.Since(job.Created)
 	log.Printf("[Worker %d] Successfully processed job %s in %v - timestamp: %v", workerID, job.ID, duration, time.Now().UnixNano())
 }
 
+// keepUnfinished holds a job aborted by the shutdown until it is spooled
+func (q *PaymentQueue) keepUnfinished(job PaymentJob) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.unfinished = append(q.unfinished, job)
+}
+
+// deadLetter stores a job that ran out of retries
+func (q *PaymentQueue) deadLetter(job PaymentJob, workerID int) {
+	if q.deadLetters == nil {
+		log.Printf("[Worker %d] Job %s failed after %d attempts, dropping", workerID, job.ID, len(job.Errors))
+		return
+	}
+
+	if err := addDeadLetter(q.deadLetters, job.ID, *job.Payment, job.Errors, job.Created); err != nil {
+		log.Printf("[Worker %d] Job %s failed after %d attempts, dropping: %v", workerID, job.ID, len(job.Errors), err)
+		return
+	}
+
+	q.counters.deadLettered.Add(1)
+	log.Printf("[Worker %d] Job %s failed after %d attempts, dead-lettered", workerID, job.ID, len(job.Errors))
+}
+
+// Shutdown lets the workers drain the buffer for up to the drain timeout,
+// then aborts the jobs still running. The jobs left over, including the
+// retries still waiting for their backoff, are spooled for the next start.
 func (q *PaymentQueue) Shutdown() {
+	q.stopScaling()
 	close(q.stop)
+
+	if !drainWithin(q.config.DrainTimeout, q.workers.Close, q.cancel) {
+		log.Printf("Queue drain timed out after %v, aborted the running jobs", q.config.DrainTimeout)
+	}
+
+	q.cancel()
 	q.wg.Wait()
+
+	// Retries are collected once the workers stopped adding them
+	leftover := append(q.retries.Stop(), q.unfinished...)
+	for len(q.jobs) > 0 {
+		leftover = append(leftover, <-q.jobs)
+	}
+
+	q.spoolJobs(leftover)
 }
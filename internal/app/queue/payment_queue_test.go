@@ -0,0 +1,291 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// memorySpoolRepository is an in-memory PaymentSpoolRepository
+type memorySpoolRepository struct {
+	jobs  []domain.PaymentJob
+	mutex sync.Mutex
+}
+
+func (r *memorySpoolRepository) Spool(ctx context.Context, jobs []domain.PaymentJob) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.jobs = append(r.jobs, jobs...)
+	return nil
+}
+
+func (r *memorySpoolRepository) Restore(ctx context.Context, restore func(jobs []domain.PaymentJob) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.jobs) == 0 {
+		return nil
+	}
+
+	if err := restore(r.jobs); err != nil {
+		return err
+	}
+	r.jobs = nil
+	return nil
+}
+
+func newTestPaymentQueueConfig(maxRetries int) *Config {
+	return &Config{
+		Workers:               2,
+		BufferSize:            10,
+		MaxEnqueueRetries:     maxRetries,
+		MaxSimultaneousWrites: 2,
+		RetryBackoff:          BackoffExponential,
+		RetryBaseDelay:        10 * time.Millisecond,
+		RetryMaxDelay:         50 * time.Millisecond,
+	}
+}
+
+func TestPaymentQueue(t *testing.T) {
+	t.Run("Retries a payment while the processors are out", func(t *testing.T) {
+		var attempts atomic.Int32
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			if attempts.Add(1) < 3 {
+				return core.ErrProcessorUnavailable
+			}
+			return nil
+		}}
+
+		deadLetters := &memoryDeadLetterRepository{}
+		q := NewPaymentQueue(newTestPaymentQueueConfig(4), service, deadLetters, nil)
+		defer q.Shutdown()
+
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		waitFor(t, func() bool { return attempts.Load() == 3 })
+
+		time.Sleep(50 * time.Millisecond)
+		if got := attempts.Load(); got != 3 {
+			t.Errorf("Expected 3 attempts, got: %d", got)
+		}
+		if letters, _ := deadLetters.List(context.Background(), 0, 10); len(letters) != 0 {
+			t.Errorf("Expected no dead letter, got: %d", len(letters))
+		}
+	})
+
+	t.Run("Dead-letters a payment after its last retry", func(t *testing.T) {
+		var attempts atomic.Int32
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			attempts.Add(1)
+			return core.ErrProcessorUnavailable
+		}}
+
+		deadLetters := &memoryDeadLetterRepository{}
+		q := NewPaymentQueue(newTestPaymentQueueConfig(2), service, deadLetters, nil)
+		defer q.Shutdown()
+
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		waitFor(t, func() bool {
+			letters, _ := deadLetters.List(context.Background(), 0, 10)
+			return len(letters) == 1
+		})
+
+		letters, _ := deadLetters.List(context.Background(), 0, 10)
+		if attempts.Load() != 3 || len(letters[0].Attempts) != 3 {
+			t.Errorf("Expected 3 attempts recorded, got: %d calls and %d errors", attempts.Load(), len(letters[0].Attempts))
+		}
+	})
+
+	t.Run("Reports the buffer, the workers and the outcomes", func(t *testing.T) {
+		release := make(chan struct{})
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			<-release
+			if payment.Amount < 0 {
+				return core.ErrInvalidPayment
+			}
+			return nil
+		}}
+
+		config := newTestPaymentQueueConfig(0)
+		config.Workers = 1
+		q := NewPaymentQueue(config, service, nil, nil)
+		defer q.Shutdown()
+
+		failing := newTestPayment()
+		failing.Amount = -1
+
+		for _, p := range []*domain.Payment{newTestPayment(), failing} {
+			if err := q.Enqueue(p); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		var stats *domain.QueueStats
+		waitFor(t, func() bool {
+			stats, _ = q.Stats(context.Background())
+			return stats.InFlight == 1
+		})
+
+		if stats.Backend != BackendMemory || stats.Depth != 1 || stats.Capacity != 10 {
+			t.Errorf("Expected 1 job in a buffer of 10, got: %+v", stats)
+		}
+		if stats.Utilization != 1 {
+			t.Errorf("Expected the worker to be busy, got: %v", stats.Utilization)
+		}
+
+		close(release)
+
+		waitFor(t, func() bool {
+			stats, _ = q.Stats(context.Background())
+			return stats.Succeeded == 1 && stats.Failed == 1 && stats.InFlight == 0
+		})
+
+		if stats.Depth != 0 || stats.OldestJobAgeMs != 0 {
+			t.Errorf("Expected an empty queue, got: %+v", stats)
+		}
+	})
+
+	t.Run("Does not retry an invalid payment", func(t *testing.T) {
+		var attempts atomic.Int32
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			attempts.Add(1)
+			return fmt.Errorf("%w: amount must be positive", core.ErrInvalidPayment)
+		}}
+
+		deadLetters := &memoryDeadLetterRepository{}
+		q := NewPaymentQueue(newTestPaymentQueueConfig(4), service, deadLetters, nil)
+		defer q.Shutdown()
+
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		waitFor(t, func() bool {
+			letters, _ := deadLetters.List(context.Background(), 0, 10)
+			return len(letters) == 1
+		})
+
+		if got := attempts.Load(); got != 1 {
+			t.Errorf("Expected a single attempt, got: %d", got)
+		}
+	})
+}
+
+func TestPaymentQueue_Shutdown(t *testing.T) {
+	t.Run("Drains the buffer before stopping", func(t *testing.T) {
+		var processed atomic.Int32
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			time.Sleep(10 * time.Millisecond)
+			processed.Add(1)
+			return nil
+		}}
+
+		config := newTestPaymentQueueConfig(0)
+		config.Workers = 1
+		config.DrainTimeout = time.Second
+		spool := &memorySpoolRepository{}
+		q := NewPaymentQueue(config, service, nil, spool)
+
+		for range 5 {
+			if err := q.Enqueue(newTestPayment()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		q.Shutdown()
+
+		if got := processed.Load(); got != 5 {
+			t.Errorf("Expected the 5 payments to be processed, got: %d", got)
+		}
+		if len(spool.jobs) != 0 {
+			t.Errorf("Expected nothing spooled, got: %d jobs", len(spool.jobs))
+		}
+	})
+
+	t.Run("Spools what is left after the drain timeout and restores it", func(t *testing.T) {
+		started := make(chan struct{}, 1)
+		service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			if payment.Amount == 1 {
+				return core.ErrProcessorUnavailable
+			}
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+
+		config := newTestPaymentQueueConfig(4)
+		config.Workers = 1
+		config.RetryBaseDelay, config.RetryMaxDelay = time.Hour, time.Hour
+		config.DrainTimeout = 20 * time.Millisecond
+		spool := &memorySpoolRepository{}
+		q := NewPaymentQueue(config, service, nil, spool)
+
+		// The first payment waits for its retry when the others arrive
+		retried := newTestPayment()
+		retried.Amount = 1
+		if err := q.Enqueue(retried); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		waitFor(t, func() bool { return q.retries.Len() == 1 })
+
+		for range 3 {
+			if err := q.Enqueue(newTestPayment()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("Expected a payment to be processed")
+		}
+
+		q.Shutdown()
+
+		// The interrupted job, the 2 buffered ones and the pending retry
+		if len(spool.jobs) != 4 {
+			t.Fatalf("Expected 4 jobs spooled, got: %d", len(spool.jobs))
+		}
+		for _, job := range spool.jobs {
+			wantAttempts := 0
+			if job.Payment.CorrelationID == retried.CorrelationID {
+				wantAttempts = 1
+			}
+			if job.Attempts != wantAttempts || len(job.Errors) != wantAttempts {
+				t.Errorf("Expected job %s to keep %d attempts, got: %+v", job.ID, wantAttempts, job)
+			}
+		}
+
+		var restored atomic.Int32
+		restarted := NewPaymentQueue(newTestPaymentQueueConfig(4), &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+			restored.Add(1)
+			return nil
+		}}, nil, spool)
+		defer restarted.Shutdown()
+
+		if err := restarted.Restore(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		waitFor(t, func() bool { return restored.Load() == 4 })
+		if len(spool.jobs) != 0 {
+			t.Errorf("Expected the spool to be emptied, got: %d jobs", len(spool.jobs))
+		}
+	})
+
+}
@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+)
+
+// Retry policy defaults, used when the configuration leaves them empty
+const (
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy decides whether a failed job is retried and after how long.
+// Payments that can never succeed are not retried; processor outages are,
+// with a delay growing exponentially or with decorrelated jitter up to the
+// max delay, until the job has made MaxAttempts attempts.
+type RetryPolicy struct {
+	backoff     string
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	maxAttempts int
+}
+
+// NewRetryPolicy creates the retry policy of the queue configuration. A job
+// makes up to MaxEnqueueRetries retries after its first attempt.
+func NewRetryPolicy(cfg *Config) *RetryPolicy {
+	p := &RetryPolicy{
+		backoff:     cfg.RetryBackoff,
+		baseDelay:   cfg.RetryBaseDelay,
+		maxDelay:    cfg.RetryMaxDelay,
+		maxAttempts: cfg.MaxEnqueueRetries + 1,
+	}
+
+	if p.backoff == "" {
+		p.backoff = BackoffExponential
+	}
+	if p.baseDelay <= 0 {
+		p.baseDelay = defaultRetryBaseDelay
+	}
+	if p.maxDelay < p.baseDelay {
+		p.maxDelay = max(defaultRetryMaxDelay, p.baseDelay)
+	}
+
+	return p
+}
+
+// Next returns the delay before retrying a job that failed with err after
+// making attempts attempts, the previous one delayed by previous, and whether
+// to retry it at all
+func (p *RetryPolicy) Next(attempts int, previous time.Duration, err error) (time.Duration, bool) {
+	if attempts >= p.maxAttempts || !Retryable(err) {
+		return 0, false
+	}
+
+	delay := p.delay(attempts, previous)
+
+	// A processor asking us to back off is not called again sooner
+	if retryAfter, ok := core.RetryAfterFrom(err); ok {
+		delay = max(delay, retryAfter)
+	}
+
+	return delay, true
+}
+
+// delay returns the backoff after the given number of attempts
+func (p *RetryPolicy) delay(attempts int, previous time.Duration) time.Duration {
+	if p.backoff == BackoffDecorrelatedJitter {
+		// A random delay between the base delay and three times the previous one
+		upper := max(previous*3, p.baseDelay)
+		if upper >= p.maxDelay {
+			upper = p.maxDelay
+		}
+		return p.baseDelay + rand.N(upper-p.baseDelay+1)
+	}
+
+	delay := p.baseDelay
+	for i := 1; i < attempts && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.maxDelay)
+}
+
+// Retryable reports whether a payment that failed with err may succeed later.
+// Invalid payments and payments rejected by a processor fail the same way on
+// every attempt; outages, timeouts, throttling and other errors are retried.
+func Retryable(err error) bool {
+	return !errors.Is(err, core.ErrInvalidPayment) && !errors.Is(err, core.ErrProcessorClientError)
+}
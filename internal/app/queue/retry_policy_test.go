@@ -0,0 +1,110 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+)
+
+func newTestRetryPolicy(backoff string, maxRetries int) *RetryPolicy {
+	return NewRetryPolicy(&Config{
+		MaxEnqueueRetries: maxRetries,
+		RetryBackoff:      backoff,
+		RetryBaseDelay:    100 * time.Millisecond,
+		RetryMaxDelay:     time.Second,
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+	outage := &core.ProcessorError{Kind: core.ErrProcessorUnavailable, Processor: "default"}
+
+	t.Run("Backs off exponentially up to the max delay", func(t *testing.T) {
+		p := newTestRetryPolicy(BackoffExponential, 10)
+
+		expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+		for i, want := range expected {
+			delay, retry := p.Next(i+1, 0, outage)
+			if !retry {
+				t.Fatalf("Expected attempt %d to be retried", i+1)
+			}
+			if delay != want*time.Millisecond {
+				t.Errorf("Expected delay %v after attempt %d, got: %v", want*time.Millisecond, i+1, delay)
+			}
+		}
+	})
+
+	t.Run("Decorrelated jitter stays between the base delay and the max delay", func(t *testing.T) {
+		p := newTestRetryPolicy(BackoffDecorrelatedJitter, 100)
+
+		previous := time.Duration(0)
+		for attempt := 1; attempt <= 50; attempt++ {
+			delay, retry := p.Next(attempt, previous, outage)
+			if !retry {
+				t.Fatalf("Expected attempt %d to be retried", attempt)
+			}
+			if delay < 100*time.Millisecond || delay > max(previous*3, 100*time.Millisecond) || delay > time.Second {
+				t.Fatalf("Expected a delay between 100ms and min(3x %v, 1s), got: %v", previous, delay)
+			}
+			previous = delay
+		}
+	})
+
+	t.Run("Stops after the max attempts", func(t *testing.T) {
+		p := newTestRetryPolicy(BackoffExponential, 2)
+
+		if _, retry := p.Next(2, 0, outage); !retry {
+			t.Error("Expected the second attempt to be retried")
+		}
+		if _, retry := p.Next(3, 0, outage); retry {
+			t.Error("Expected no retry after the third attempt")
+		}
+	})
+
+	t.Run("Never retries payments that cannot succeed", func(t *testing.T) {
+		p := newTestRetryPolicy(BackoffExponential, 4)
+
+		permanent := []error{
+			fmt.Errorf("%w: amount must be positive", core.ErrInvalidPayment),
+			fmt.Errorf("payment rejected by default: %w", &core.ProcessorError{Kind: core.ErrProcessorClientError, StatusCode: 422}),
+		}
+		for _, err := range permanent {
+			if _, retry := p.Next(1, 0, err); retry {
+				t.Errorf("Expected no retry for: %v", err)
+			}
+		}
+
+		transient := []error{
+			outage,
+			&core.ProcessorError{Kind: core.ErrProcessorTimeout},
+			&core.ProcessorError{Kind: core.ErrProcessorServerError, StatusCode: 500},
+			core.ErrCircuitBreakerOpen,
+			core.ErrBulkheadFull,
+			errors.New("database unavailable"),
+		}
+		for _, err := range transient {
+			if _, retry := p.Next(1, 0, err); !retry {
+				t.Errorf("Expected a retry for: %v", err)
+			}
+		}
+	})
+
+	t.Run("Waits at least as long as the processor asked", func(t *testing.T) {
+		p := newTestRetryPolicy(BackoffExponential, 4)
+
+		rateLimited := &core.ProcessorError{Kind: core.ErrProcessorRateLimited, StatusCode: 429, RetryAfter: 5 * time.Second}
+		if delay, retry := p.Next(1, 0, rateLimited); !retry || delay != 5*time.Second {
+			t.Errorf("Expected a retry after 5s, got: %v, %v", delay, retry)
+		}
+	})
+
+	t.Run("Applies defaults to an empty configuration", func(t *testing.T) {
+		p := NewRetryPolicy(&Config{MaxEnqueueRetries: 4})
+
+		if delay, retry := p.Next(1, 0, outage); !retry || delay != defaultRetryBaseDelay {
+			t.Errorf("Expected a retry after %v, got: %v, %v", defaultRetryBaseDelay, delay, retry)
+		}
+	})
+}
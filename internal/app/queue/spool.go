@@ -0,0 +1,83 @@
+package queue
+
+import (
+	"context"
+	"log"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// toSpooled converts an in-memory job for the spool
+func toSpooled(job PaymentJob) domain.PaymentJob {
+	return domain.PaymentJob{
+		ID:         job.ID,
+		Payment:    *job.Payment,
+		Attempts:   job.Retries,
+		Errors:     job.Errors,
+		RetryDelay: job.Delay,
+		CreatedAt:  job.Created,
+	}
+}
+
+// fromSpooled converts a spooled job back into an in-memory job
+func fromSpooled(job domain.PaymentJob) PaymentJob {
+	payment := job.Payment
+
+	return PaymentJob{
+		ID:      job.ID,
+		Payment: &payment,
+		Retries: job.Attempts,
+		Delay:   job.RetryDelay,
+		Errors:  job.Errors,
+		Created: job.CreatedAt,
+	}
+}
+
+// spoolJobs writes the jobs left over at shutdown to the spool, or drops
+// them when there is no spool
+func (q *PaymentQueue) spoolJobs(jobs []PaymentJob) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	if q.spool == nil {
+		log.Printf("Dropping %d unfinished payment jobs: no spool configured", len(jobs))
+		return
+	}
+
+	spooled := make([]domain.PaymentJob, 0, len(jobs))
+	for _, job := range jobs {
+		spooled = append(spooled, toSpooled(job))
+	}
+
+	// The queue context is already cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	if err := q.spool.Spool(ctx, spooled); err != nil {
+		log.Printf("Failed to spool %d unfinished payment jobs: %v", len(jobs), err)
+		return
+	}
+
+	log.Printf("Spooled %d unfinished payment jobs", len(jobs))
+}
+
+// Restore reloads the jobs spooled by the last shutdown. They are handed to
+// the workers as the buffer frees up, keeping their attempts. The spool only
+// lets go of the jobs once they are all queued.
+func (q *PaymentQueue) Restore(ctx context.Context) error {
+	if q.spool == nil {
+		return nil
+	}
+
+	return q.spool.Restore(ctx, func(jobs []domain.PaymentJob) error {
+		for _, spooled := range jobs {
+			job := fromSpooled(spooled)
+			q.outstanding.add(job.ID, job.Created)
+			q.retries.Add(job, 0)
+		}
+
+		log.Printf("Restored %d spooled payment jobs", len(jobs))
+		return nil
+	})
+}
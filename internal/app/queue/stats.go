@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+	"github.com/google/uuid"
+)
+
+// latencySmoothing is the weight of the latest job in the job latency average
+const latencySmoothing = 0.2
+
+// queueCounters tracks the jobs a queue is processing, the outcome of its
+// attempts since startup and the moving average of the job latency
+type queueCounters struct {
+	inFlight     atomic.Int64
+	succeeded    atomic.Int64
+	failed       atomic.Int64
+	deadLettered atomic.Int64
+	jobLatency   time.Duration
+	latencyMutex sync.Mutex
+}
+
+// recordLatency adds the time a job took to the moving average
+func (c *queueCounters) recordLatency(latency time.Duration) {
+	c.latencyMutex.Lock()
+	defer c.latencyMutex.Unlock()
+
+	if c.jobLatency == 0 {
+		c.jobLatency = latency
+		return
+	}
+
+	c.jobLatency += time.Duration(latencySmoothing * float64(latency-c.jobLatency))
+}
+
+// latency returns the moving average of the job latency
+func (c *queueCounters) latency() time.Duration {
+	c.latencyMutex.Lock()
+	defer c.latencyMutex.Unlock()
+
+	return c.jobLatency
+}
+
+// snapshot fills the worker and counter figures of the stats
+func (c *queueCounters) snapshot(backend string, pool domain.WorkerPool) domain.QueueStats {
+	// Each worker runs one job at a time, so the jobs in flight are the busy workers
+	busy := int(c.inFlight.Load())
+
+	stats := domain.QueueStats{
+		Backend:      backend,
+		Workers:      pool.Workers,
+		MinWorkers:   pool.MinWorkers,
+		MaxWorkers:   pool.MaxWorkers,
+		InFlight:     busy,
+		JobLatencyMs: float64(c.latency()) / float64(time.Millisecond),
+		Succeeded:    c.succeeded.Load(),
+		Failed:       c.failed.Load(),
+		DeadLettered: c.deadLettered.Load(),
+	}
+
+	// Workers that were retired while running a job are still busy
+	if pool.Workers > 0 {
+		stats.Utilization = min(1, float64(busy)/float64(pool.Workers))
+	}
+
+	return stats
+}
+
+// outstandingJobs keeps the creation time of the jobs a queue has not
+// finished with, to report the age of the oldest one
+type outstandingJobs struct {
+	created map[uuid.UUID]time.Time
+	mutex   sync.Mutex
+}
+
+func newOutstandingJobs() *outstandingJobs {
+	return &outstandingJobs{created: make(map[uuid.UUID]time.Time)}
+}
+
+func (o *outstandingJobs) add(id uuid.UUID, created time.Time) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.created[id] = created
+}
+
+func (o *outstandingJobs) remove(id uuid.UUID) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	delete(o.created, id)
+}
+
+// oldest returns the creation time of the oldest job, or the zero time
+func (o *outstandingJobs) oldest() time.Time {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var oldest time.Time
+	for _, created := range o.created {
+		if oldest.IsZero() || created.Before(oldest) {
+			oldest = created
+		}
+	}
+
+	return oldest
+}
+
+// jobAge returns the age of a job created at created in milliseconds, zero
+// when there is no job
+func jobAge(created time.Time) int64 {
+	if created.IsZero() {
+		return 0
+	}
+
+	return time.Since(created).Milliseconds()
+}
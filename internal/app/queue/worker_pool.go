@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// Worker pool scaling defaults, used when the configuration leaves them empty
+const (
+	defaultScaleInterval   = time.Second
+	defaultScaleTargetWait = 500 * time.Millisecond
+)
+
+// workerPool runs a resizable set of workers. Every worker has its own quit
+// channel and checks it only between jobs, so shrinking the pool closes the
+// newest workers' channels without interrupting a running job.
+type workerPool struct {
+	work       func(workerID int, quit <-chan struct{})
+	quits      []chan struct{}
+	nextID     int
+	minWorkers int
+	maxWorkers int
+	closed     bool
+	mutex      sync.Mutex
+	wg         sync.WaitGroup
+}
+
+// newWorkerPool starts the configured number of workers running work
+func newWorkerPool(cfg *Config, work func(workerID int, quit <-chan struct{})) *workerPool {
+	p := &workerPool{work: work}
+	p.minWorkers, p.maxWorkers = cfg.workerBounds()
+
+	p.mutex.Lock()
+	p.resize(cfg.Workers)
+	p.mutex.Unlock()
+
+	return p
+}
+
+// Status returns the number of workers and the bounds of the pool
+func (p *workerPool) Status() domain.WorkerPool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return domain.WorkerPool{Workers: len(p.quits), MinWorkers: p.minWorkers, MaxWorkers: p.maxWorkers}
+}
+
+// Resize changes the bounds of the pool and its size, leaving zero fields
+// unchanged. Without a new size, the pool is brought within the new bounds.
+func (p *workerPool) Resize(resize domain.WorkerPool) (*domain.WorkerPool, error) {
+	if resize.Workers < 0 || resize.MinWorkers < 0 || resize.MaxWorkers < 0 {
+		return nil, fmt.Errorf("%w: sizes cannot be negative", core.ErrInvalidWorkerPool)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	minWorkers := cmp.Or(resize.MinWorkers, p.minWorkers)
+	maxWorkers := cmp.Or(resize.MaxWorkers, p.maxWorkers)
+	if minWorkers > maxWorkers {
+		return nil, fmt.Errorf("%w: min workers %d is greater than max workers %d", core.ErrInvalidWorkerPool, minWorkers, maxWorkers)
+	}
+
+	size := min(max(len(p.quits), minWorkers), maxWorkers)
+	if resize.Workers != 0 {
+		if resize.Workers < minWorkers || resize.Workers > maxWorkers {
+			return nil, fmt.Errorf("%w: workers must be between %d and %d", core.ErrInvalidWorkerPool, minWorkers, maxWorkers)
+		}
+		size = resize.Workers
+	}
+
+	p.minWorkers, p.maxWorkers = minWorkers, maxWorkers
+	p.resize(size)
+
+	return &domain.WorkerPool{Workers: len(p.quits), MinWorkers: p.minWorkers, MaxWorkers: p.maxWorkers}, nil
+}
+
+// scale moves the pool towards the desired number of workers within its
+// bounds. It grows at once but sheds at most a quarter of the workers at a
+// time, so a short lull does not drain the pool.
+func (p *workerPool) scale(desired int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	size := len(p.quits)
+	if desired < size {
+		desired = max(desired, size-max(1, size/4))
+	}
+
+	desired = min(max(desired, p.minWorkers), p.maxWorkers)
+	if desired != size {
+		log.Printf("Resizing the queue worker pool from %d to %d workers", size, desired)
+		p.resize(desired)
+	}
+}
+
+// scalable reports whether the bounds let the pool be resized
+func (p *workerPool) scalable() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.minWorkers < p.maxWorkers
+}
+
+// resize starts or stops workers to reach size; the caller holds the mutex
+func (p *workerPool) resize(size int) {
+	if p.closed {
+		return
+	}
+
+	for len(p.quits) < size {
+		quit := make(chan struct{})
+		p.quits = append(p.quits, quit)
+
+		p.wg.Add(1)
+		go func(workerID int) {
+			defer p.wg.Done()
+			p.work(workerID, quit)
+		}(p.nextID)
+		p.nextID++
+	}
+
+	for len(p.quits) > size {
+		last := len(p.quits) - 1
+		close(p.quits[last])
+		p.quits = p.quits[:last]
+	}
+}
+
+// Close stops resizing the pool and waits for the workers, which the queue
+// must already have told to stop
+func (p *workerPool) Close() {
+	p.mutex.Lock()
+	p.closed = true
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+}
+
+// autoscale resizes the pool every scale interval from the jobs waiting,
+// given by depth, the jobs in flight and the job latency, until ctx is done
+func autoscale(ctx context.Context, cfg *Config, pool *workerPool, counters *queueCounters, depth func(ctx context.Context) (int, error)) {
+	interval := cmp.Or(cfg.ScaleInterval, defaultScaleInterval)
+	targetWait := cmp.Or(cfg.ScaleTargetWait, defaultScaleTargetWait)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		// The bounds may be changed at runtime, so keep checking
+		if !pool.scalable() {
+			continue
+		}
+
+		waiting, err := depth(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to get the queue depth to scale the workers: %v", err)
+			}
+			continue
+		}
+
+		pool.scale(desiredWorkers(waiting, int(counters.inFlight.Load()), counters.latency(), targetWait))
+	}
+}
+
+// desiredWorkers returns the workers needed to finish the queued and running
+// jobs within targetWait at the current job latency
+func desiredWorkers(depth, inFlight int, latency, targetWait time.Duration) int {
+	jobsPerWorker := 1
+	if latency > 0 {
+		jobsPerWorker = max(1, int(targetWait/latency))
+	}
+
+	return (depth + inFlight + jobsPerWorker - 1) / jobsPerWorker
+}
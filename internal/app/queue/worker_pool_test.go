@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/core"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
+)
+
+// newTestWorkerPool starts a pool whose workers count themselves while
+// running, stopped when the test ends
+func newTestWorkerPool(t *testing.T, workers, minWorkers, maxWorkers int) (*workerPool, *atomic.Int32) {
+	var running atomic.Int32
+	stop := make(chan struct{})
+
+	pool := newWorkerPool(&Config{Workers: workers, MinWorkers: minWorkers, MaxWorkers: maxWorkers}, func(workerID int, quit <-chan struct{}) {
+		running.Add(1)
+		defer running.Add(-1)
+
+		select {
+		case <-quit:
+		case <-stop:
+		}
+	})
+
+	t.Cleanup(func() {
+		close(stop)
+		pool.Close()
+	})
+
+	return pool, &running
+}
+
+func TestWorkerPool(t *testing.T) {
+	t.Run("Grows and shrinks", func(t *testing.T) {
+		pool, running := newTestWorkerPool(t, 2, 1, 5)
+
+		waitFor(t, func() bool { return running.Load() == 2 })
+
+		status, err := pool.Resize(domain.WorkerPool{Workers: 5})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if status.Workers != 5 {
+			t.Errorf("Expected 5 workers, got: %d", status.Workers)
+		}
+		waitFor(t, func() bool { return running.Load() == 5 })
+
+		if _, err := pool.Resize(domain.WorkerPool{Workers: 1}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		waitFor(t, func() bool { return running.Load() == 1 })
+	})
+
+	t.Run("Brings the pool within new bounds", func(t *testing.T) {
+		pool, running := newTestWorkerPool(t, 2, 1, 5)
+
+		status, err := pool.Resize(domain.WorkerPool{MinWorkers: 4})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if *status != (domain.WorkerPool{Workers: 4, MinWorkers: 4, MaxWorkers: 5}) {
+			t.Errorf("Expected 4 workers between 4 and 5, got: %+v", status)
+		}
+		waitFor(t, func() bool { return running.Load() == 4 })
+	})
+
+	t.Run("Rejects invalid sizes", func(t *testing.T) {
+		pool, _ := newTestWorkerPool(t, 2, 1, 5)
+
+		invalid := []domain.WorkerPool{
+			{Workers: 6},
+			{Workers: -1},
+			{MinWorkers: 6},
+			{MinWorkers: 3, MaxWorkers: 2},
+		}
+
+		for _, resize := range invalid {
+			if _, err := pool.Resize(resize); !errors.Is(err, core.ErrInvalidWorkerPool) {
+				t.Errorf("Expected invalid worker pool error for %+v, got: %v", resize, err)
+			}
+		}
+
+		if status := pool.Status(); status != (domain.WorkerPool{Workers: 2, MinWorkers: 1, MaxWorkers: 5}) {
+			t.Errorf("Expected the pool unchanged, got: %+v", status)
+		}
+	})
+
+	t.Run("Scales up at once and down gradually", func(t *testing.T) {
+		pool, _ := newTestWorkerPool(t, 4, 2, 10)
+
+		pool.scale(20)
+		if got := pool.Status().Workers; got != 10 {
+			t.Errorf("Expected the pool to grow to its max of 10, got: %d", got)
+		}
+
+		pool.scale(0)
+		if got := pool.Status().Workers; got != 8 {
+			t.Errorf("Expected the pool to shed a quarter of its workers, got: %d", got)
+		}
+
+		for range 10 {
+			pool.scale(0)
+		}
+		if got := pool.Status().Workers; got != 2 {
+			t.Errorf("Expected the pool to shrink to its min of 2, got: %d", got)
+		}
+	})
+}
+
+func TestDesiredWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		depth    int
+		inFlight int
+		latency  time.Duration
+		want     int
+	}{
+		{"Idle queue", 0, 0, 10 * time.Millisecond, 0},
+		{"Fast jobs share workers", 95, 5, 10 * time.Millisecond, 2},
+		{"Slow jobs need a worker each", 30, 10, time.Second, 40},
+		{"No latency measured yet", 3, 1, 0, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := desiredWorkers(tt.depth, tt.inFlight, tt.latency, 500*time.Millisecond); got != tt.want {
+				t.Errorf("Expected %d workers, got: %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPaymentQueue_Autoscale(t *testing.T) {
+	release := make(chan struct{})
+	var finished atomic.Int32
+	service := &fakePaymentService{process: func(ctx context.Context, payment *domain.Payment) error {
+		<-release
+		finished.Add(1)
+		return nil
+	}}
+
+	config := newTestPaymentQueueConfig(0)
+	config.Workers, config.MinWorkers, config.MaxWorkers = 1, 1, 4
+	config.MaxSimultaneousWrites = 4
+	config.ScaleInterval = 10 * time.Millisecond
+	config.ScaleTargetWait = 10 * time.Millisecond
+
+	q := NewPaymentQueue(config, service, nil, nil)
+	defer q.Shutdown()
+
+	for range 6 {
+		if err := q.Enqueue(newTestPayment()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	// The busy workers are not interrupted when the pool grows or shrinks
+	waitFor(t, func() bool {
+		stats, _ := q.Stats(context.Background())
+		return stats.Workers == 4 && stats.InFlight == 4
+	})
+
+	close(release)
+
+	waitFor(t, func() bool { return finished.Load() == 6 })
+	waitFor(t, func() bool { return q.Workers().Workers == 1 })
+}
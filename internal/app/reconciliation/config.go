@@ -0,0 +1,97 @@
+package reconciliation
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds in-doubt payment reconciliation configuration
+type Config struct {
+	Enabled       bool
+	Interval      time.Duration
+	LookupTimeout time.Duration
+	BatchSize     int
+}
+
+// ConfigManager manages reconciliation configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new reconciliation configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads reconciliation configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	enabled, err := strconv.ParseBool(getEnvOrDefault("RECONCILIATION_ENABLED", "true"))
+	if err != nil {
+		return fmt.Errorf("invalid RECONCILIATION_ENABLED value: %w", err)
+	}
+
+	interval, err := time.ParseDuration(getEnvOrDefault("RECONCILIATION_INTERVAL", "10s"))
+	if err != nil {
+		return fmt.Errorf("invalid RECONCILIATION_INTERVAL value: %w", err)
+	}
+
+	lookupTimeout, err := time.ParseDuration(getEnvOrDefault("RECONCILIATION_LOOKUP_TIMEOUT", "1s"))
+	if err != nil {
+		return fmt.Errorf("invalid RECONCILIATION_LOOKUP_TIMEOUT value: %w", err)
+	}
+
+	batchSize, err := strconv.Atoi(getEnvOrDefault("RECONCILIATION_BATCH_SIZE", "100"))
+	if err != nil {
+		return fmt.Errorf("invalid RECONCILIATION_BATCH_SIZE value: %w", err)
+	}
+
+	cm.config = &Config{
+		Enabled:       enabled,
+		Interval:      interval,
+		LookupTimeout: lookupTimeout,
+		BatchSize:     batchSize,
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded reconciliation configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the reconciliation configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("reconciliation configuration not loaded")
+	}
+
+	if cm.config.Interval <= 0 {
+		return fmt.Errorf("reconciliation interval must be greater than 0")
+	}
+
+	if cm.config.LookupTimeout <= 0 {
+		return fmt.Errorf("reconciliation lookup timeout must be greater than 0")
+	}
+
+	if cm.config.BatchSize <= 0 {
+		return fmt.Errorf("reconciliation batch size must be greater than 0")
+	}
+
+	return nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,90 @@
+package reconciliation
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"RECONCILIATION_ENABLED":        os.Getenv("RECONCILIATION_ENABLED"),
+		"RECONCILIATION_INTERVAL":       os.Getenv("RECONCILIATION_INTERVAL"),
+		"RECONCILIATION_LOOKUP_TIMEOUT": os.Getenv("RECONCILIATION_LOOKUP_TIMEOUT"),
+		"RECONCILIATION_BATCH_SIZE":     os.Getenv("RECONCILIATION_BATCH_SIZE"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if !config.Enabled {
+			t.Error("Expected reconciliation to be enabled by default")
+		}
+		if config.Interval != 10*time.Second {
+			t.Errorf("Expected interval to be 10s, got: %v", config.Interval)
+		}
+		if config.LookupTimeout != time.Second {
+			t.Errorf("Expected lookup timeout to be 1s, got: %v", config.LookupTimeout)
+		}
+		if config.BatchSize != 100 {
+			t.Errorf("Expected batch size to be 100, got: %d", config.BatchSize)
+		}
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		os.Setenv("RECONCILIATION_BATCH_SIZE", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid batch size value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: true, Interval: 10 * time.Second, LookupTimeout: time.Second, BatchSize: 100})
+
+		if err := cm.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid batch size", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{Enabled: true, Interval: 10 * time.Second, LookupTimeout: time.Second, BatchSize: 0})
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for invalid batch size")
+		}
+	})
+
+	t.Run("Nil config", func(t *testing.T) {
+		cm := NewConfigManager()
+
+		if err := cm.Validate(); err == nil {
+			t.Fatal("Expected error for nil config")
+		}
+	})
+}
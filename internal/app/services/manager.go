@@ -1,35 +1,74 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/fabianoflorentino/mr-robot/adapters/outbound/gateway"
 	"github.com/fabianoflorentino/mr-robot/adapters/outbound/persistence/data"
+	"github.com/fabianoflorentino/mr-robot/core/domain"
 	"github.com/fabianoflorentino/mr-robot/core/services"
+	"github.com/fabianoflorentino/mr-robot/internal/app/audit"
+	"github.com/fabianoflorentino/mr-robot/internal/app/bulkhead"
 	"github.com/fabianoflorentino/mr-robot/internal/app/circuitbreaker"
+	"github.com/fabianoflorentino/mr-robot/internal/app/concurrency"
+	"github.com/fabianoflorentino/mr-robot/internal/app/health"
+	"github.com/fabianoflorentino/mr-robot/internal/app/hedging"
+	"github.com/fabianoflorentino/mr-robot/internal/app/httpclient"
 	"github.com/fabianoflorentino/mr-robot/internal/app/interfaces"
 	"github.com/fabianoflorentino/mr-robot/internal/app/payment"
 	"github.com/fabianoflorentino/mr-robot/internal/app/queue"
+	"github.com/fabianoflorentino/mr-robot/internal/app/reconciliation"
+	"github.com/fabianoflorentino/mr-robot/internal/app/sharedstate"
 )
 
+// restoreTimeout bounds the reload of the spooled payments at startup
+const restoreTimeout = 30 * time.Second
+
+// circuitBreakerService combines the payment service breakers with their event log
+type circuitBreakerService struct {
+	*services.PaymentService
+	*services.CircuitBreakerEventLog
+}
+
+// ManagerConfig holds the configuration of the services the manager creates
+type ManagerConfig struct {
+	Payment        *payment.Config
+	Queue          *queue.Config
+	CircuitBreaker *circuitbreaker.Config
+	Health         *health.Config
+	HTTPClient     *httpclient.Config
+	Reconciliation *reconciliation.Config
+	Audit          *audit.Config
+	SharedState    *sharedstate.Config
+	Concurrency    *concurrency.Config
+	Bulkhead       *bulkhead.Config
+	Hedging        *hedging.Config
+}
+
 // Manager handles service initialization and management
 type Manager struct {
-	db                   *sql.DB
-	paymentConfig        *payment.Config
-	queueConfig          *queue.Config
-	circuitBreakerConfig *circuitbreaker.Config
-	paymentService       interfaces.PaymentServiceInterface
-	paymentQueue         *queue.PaymentQueue
+	db              *sql.DB
+	config          ManagerConfig
+	paymentService  interfaces.PaymentServiceInterface
+	healthMonitor   *services.HealthMonitor
+	sharedState     *services.SharedStateSync
+	reconciler      *services.PaymentReconciler
+	auditor         *services.SummaryAuditor
+	circuitBreakers *circuitBreakerService
+	bulkheads       interfaces.BulkheadServiceInterface
+	deadLetters     *services.DeadLetterQueue
+	paymentQueue    interfaces.PaymentQueueInterface
+	memoryQueue     *queue.PaymentQueue
 }
 
 // NewManager creates a new service manager
-func NewManager(db *sql.DB, paymentConfig *payment.Config, queueConfig *queue.Config, circuitBreakerConfig *circuitbreaker.Config) *Manager {
+func NewManager(db *sql.DB, cfg ManagerConfig) *Manager {
 	return &Manager{
-		db:                   db,
-		paymentConfig:        paymentConfig,
-		queueConfig:          queueConfig,
-		circuitBreakerConfig: circuitBreakerConfig,
+		db:     db,
+		config: cfg,
 	}
 }
 
@@ -48,43 +87,202 @@ func (s *Manager) InitializeServices() error {
 	return nil
 }
 
-// initializePaymentService creates and configures the payment service with fallback
+// initializePaymentService creates and configures the payment service with the registered processors
 func (s *Manager) initializePaymentService() error {
 	paymentRepo := data.NewDataPaymentRepository(s.db)
 
-	// Create default processor
-	defaultProcessor := &gateway.ProcessGateway{
-		URL:  s.paymentConfig.DefaultProcessorURL,
-		Name: "default",
+	var registrations []services.ProcessorRegistration
+	var healthCheckers []domain.ProcessorHealthChecker
+	var lookups []domain.PaymentLookup
+	var summaryProviders []domain.AdminSummaryProvider
+
+	processors := s.newProcessors()
+	for i, p := range s.config.Payment.ProcessorList() {
+		processor := processors[i]
+
+		registrations = append(registrations, services.ProcessorRegistration{
+			Processor:             processor,
+			Priority:              p.Priority,
+			Fee:                   p.Fee,
+			MaxFailures:           p.MaxFailures,
+			ResetTimeout:          p.ResetTimeout,
+			RateLimit:             p.RateLimit,
+			RequestsPerSecond:     p.RequestsPerSecond,
+			Burst:                 p.Burst,
+			BulkheadMaxConcurrent: p.BulkheadMaxConcurrent,
+			BulkheadMaxQueue:      p.BulkheadMaxQueue,
+		})
+		healthCheckers = append(healthCheckers, processor)
+		lookups = append(lookups, processor)
+		summaryProviders = append(summaryProviders, processor)
 	}
 
-	// Create fallback processor
-	fallbackProcessor := &gateway.ProcessGateway{
-		URL:  s.paymentConfig.FallbackProcessorURL,
-		Name: "fallback",
+	// Hedging to the next processor also needs the reconciler, set below, to
+	// check the losing attempt
+	paymentService := services.NewPaymentService(paymentRepo, registrations, s.config.Payment, s.config.CircuitBreaker, services.PaymentServiceOptions{
+		Concurrency: s.config.Concurrency,
+		Bulkhead:    s.config.Bulkhead,
+		Hedging:     s.config.Hedging,
+	})
+
+	// Log, count and keep the circuit breaker state changes
+	circuitBreakerEvents := services.NewCircuitBreakerEventLog(s.config.CircuitBreaker.EventHistorySize)
+	paymentService.OnCircuitBreakerStateChange(circuitBreakerEvents.Record)
+	s.circuitBreakers = &circuitBreakerService{PaymentService: paymentService, CircuitBreakerEventLog: circuitBreakerEvents}
+	s.bulkheads = paymentService
+
+	// Poll the processors' health endpoints so known failures skip the processor
+	if s.config.Health != nil && s.config.Health.Enabled {
+		s.healthMonitor = services.NewHealthMonitor(s.config.Health, healthCheckers...)
+		paymentService.SetHealthMonitor(s.healthMonitor)
+	}
+
+	// Share breaker transitions and health with the other instances; only the
+	// elected instance polls the health endpoints
+	if s.config.SharedState != nil && s.config.SharedState.Enabled {
+		sharedStateRepo := data.NewDataSharedStateRepository(s.db, s.config.SharedState.Channel)
+		s.sharedState = services.NewSharedStateSync(sharedStateRepo, s.config.SharedState, paymentService, s.healthMonitor)
+		s.sharedState.Start()
 	}
 
-	// Convert circuit breaker config to legacy format
-	// Use the new service with fallback support
-	s.paymentService = services.NewPaymentService(paymentRepo, defaultProcessor, fallbackProcessor, s.circuitBreakerConfig)
+	if s.healthMonitor != nil {
+		s.healthMonitor.Start()
+	}
+
+	// Settle timed-out processor calls before failing over, and in the background
+	if s.config.Reconciliation != nil && s.config.Reconciliation.Enabled {
+		s.reconciler = services.NewPaymentReconciler(paymentRepo, s.config.Reconciliation, paymentService.Process, lookups...)
+		s.reconciler.Start()
+		paymentService.SetReconciler(s.reconciler)
+	}
+
+	// Compare our books with the processors' books in the background
+	if s.config.Audit != nil && s.config.Audit.Enabled {
+		s.auditor = services.NewSummaryAuditor(paymentRepo, s.config.Audit, summaryProviders, lookups)
+		s.auditor.Start()
+	}
+
+	s.paymentService = paymentService
 
 	return nil
 }
 
-// initializePaymentQueue creates and configures the payment queue
+// newProcessors creates one gateway per registered processor, in registry
+// order, all sharing the same pooled HTTP client
+func (s *Manager) newProcessors() []*gateway.ProcessGateway {
+	factory := gateway.NewProcessorFactory()
+	processorConfig := s.newProcessorConfig()
+
+	var processors []*gateway.ProcessGateway
+	for _, p := range s.config.Payment.ProcessorList() {
+		processorConfig.URL = p.URL
+		processorConfig.AdminToken = s.adminToken(p.AdminToken)
+		processors = append(processors, factory.CreateProcessor(gateway.ProcessorType(p.Name), processorConfig))
+	}
+
+	return processors
+}
+
+// adminToken returns the processor admin token, falling back to the audit admin token
+func (s *Manager) adminToken(processorToken string) string {
+	if processorToken != "" || s.config.Audit == nil {
+		return processorToken
+	}
+
+	return s.config.Audit.AdminToken
+}
+
+// newProcessorConfig returns the gateway configuration shared by all processors,
+// with a single pooled HTTP client when the HTTP client configuration is loaded
+func (s *Manager) newProcessorConfig() gateway.ProcessorConfig {
+	if s.config.HTTPClient == nil {
+		return gateway.ProcessorConfig{}
+	}
+
+	client := gateway.NewHTTPClient(gateway.TransportConfig{
+		Timeout:               s.config.HTTPClient.Timeout,
+		MaxIdleConns:          s.config.HTTPClient.MaxIdleConns,
+		MaxIdleConnsPerHost:   s.config.HTTPClient.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       s.config.HTTPClient.MaxConnsPerHost,
+		IdleConnTimeout:       s.config.HTTPClient.IdleConnTimeout,
+		KeepAlive:             s.config.HTTPClient.KeepAlive,
+		DialTimeout:           s.config.HTTPClient.DialTimeout,
+		ResponseHeaderTimeout: s.config.HTTPClient.ResponseHeaderTimeout,
+		EnableHTTP2:           s.config.HTTPClient.EnableHTTP2,
+	})
+
+	return gateway.ProcessorConfig{
+		Timeout:    s.config.HTTPClient.Timeout,
+		HTTPClient: client,
+	}
+}
+
+// initializePaymentQueue creates and configures the payment queue, kept in
+// Postgres when the postgres backend is configured
 func (s *Manager) initializePaymentQueue() error {
-	s.paymentQueue = queue.NewPaymentQueue(s.queueConfig, s.paymentService)
+	deadLetterRepo := data.NewDataDeadLetterRepository(s.db)
+
+	switch s.config.Queue.Backend {
+	case queue.BackendPostgres:
+		jobRepo := data.NewDataPaymentJobRepository(s.db)
+		s.paymentQueue = queue.NewDurableQueue(s.config.Queue, jobRepo, deadLetterRepo, s.paymentService)
+	default:
+		// Jobs left over at shutdown are spooled and restored on the next start
+		spoolRepo := data.NewDataPaymentSpoolRepository(s.db)
+		s.memoryQueue = queue.NewPaymentQueue(s.config.Queue, s.paymentService, deadLetterRepo, spoolRepo)
+		s.paymentQueue = s.memoryQueue
+	}
+
+	// Dead letters are replayed through the normal processing path
+	s.deadLetters = services.NewDeadLetterQueue(deadLetterRepo, s.paymentService.Process)
 
 	return nil
 }
 
+// RestorePaymentQueue reloads the payments the in-memory queue spooled at the
+// last shutdown. It runs once the migrations created the spool table.
+func (s *Manager) RestorePaymentQueue() error {
+	if s.memoryQueue == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+	defer cancel()
+
+	return s.memoryQueue.Restore(ctx)
+}
+
 // GetPaymentService returns the payment service instance
 func (s *Manager) GetPaymentService() interfaces.PaymentServiceInterface {
 	return s.paymentService
 }
 
+// GetAuditService returns the payment summary auditor, or nil when the audit is disabled
+func (s *Manager) GetAuditService() interfaces.AuditServiceInterface {
+	if s.auditor == nil {
+		return nil
+	}
+
+	return s.auditor
+}
+
+// GetCircuitBreakerService returns the circuit breaker admin service
+func (s *Manager) GetCircuitBreakerService() interfaces.CircuitBreakerServiceInterface {
+	return s.circuitBreakers
+}
+
+// GetBulkheadService returns the service reporting the processors' bulkheads
+func (s *Manager) GetBulkheadService() interfaces.BulkheadServiceInterface {
+	return s.bulkheads
+}
+
+// GetDeadLetterService returns the service inspecting and replaying dead letters
+func (s *Manager) GetDeadLetterService() interfaces.DeadLetterServiceInterface {
+	return s.deadLetters
+}
+
 // GetPaymentQueue returns the payment queue instance
-func (s *Manager) GetPaymentQueue() *queue.PaymentQueue {
+func (s *Manager) GetPaymentQueue() interfaces.PaymentQueueInterface {
 	return s.paymentQueue
 }
 
@@ -93,4 +291,20 @@ func (s *Manager) Shutdown() {
 	if s.paymentQueue != nil {
 		s.paymentQueue.Shutdown()
 	}
+
+	if s.auditor != nil {
+		s.auditor.Stop()
+	}
+
+	if s.reconciler != nil {
+		s.reconciler.Stop()
+	}
+
+	if s.healthMonitor != nil {
+		s.healthMonitor.Stop()
+	}
+
+	if s.sharedState != nil {
+		s.sharedState.Stop()
+	}
 }
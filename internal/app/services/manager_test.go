@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/mr-robot/internal/app/httpclient"
+	"github.com/fabianoflorentino/mr-robot/internal/app/payment"
+	"github.com/google/uuid"
+)
+
+func newTestHTTPClientConfig() *httpclient.Config {
+	return &httpclient.Config{
+		Timeout:               2 * time.Second,
+		MaxIdleConns:          20,
+		MaxIdleConnsPerHost:   10,
+		MaxConnsPerHost:       15,
+		IdleConnTimeout:       time.Minute,
+		KeepAlive:             30 * time.Second,
+		DialTimeout:           time.Second,
+		ResponseHeaderTimeout: 500 * time.Millisecond,
+	}
+}
+
+func TestManager_ProcessorTransport(t *testing.T) {
+	t.Run("Configures the pooled transport", func(t *testing.T) {
+		s := &Manager{config: ManagerConfig{HTTPClient: newTestHTTPClientConfig()}}
+
+		processorConfig := s.newProcessorConfig()
+		transport, ok := processorConfig.HTTPClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("Expected an *http.Transport, got: %T", processorConfig.HTTPClient.Transport)
+		}
+
+		if transport.MaxIdleConns != 20 || transport.MaxIdleConnsPerHost != 10 || transport.MaxConnsPerHost != 15 {
+			t.Errorf("Expected the configured connection limits, got: %d, %d, %d", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+		}
+		if transport.IdleConnTimeout != time.Minute || transport.ResponseHeaderTimeout != 500*time.Millisecond {
+			t.Errorf("Expected the configured timeouts, got: %v, %v", transport.IdleConnTimeout, transport.ResponseHeaderTimeout)
+		}
+		if processorConfig.HTTPClient.Timeout != 2*time.Second {
+			t.Errorf("Expected a client timeout of 2s, got: %v", processorConfig.HTTPClient.Timeout)
+		}
+	})
+
+	t.Run("Shares the connection pool between processors", func(t *testing.T) {
+		var connections atomic.Int32
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				connections.Add(1)
+			}
+		}
+		server.Start()
+		defer server.Close()
+
+		s := &Manager{config: ManagerConfig{
+			HTTPClient: newTestHTTPClientConfig(),
+			Payment: &payment.Config{Processors: []payment.ProcessorConfig{
+				{Name: "default", URL: server.URL + "/payments"},
+				{Name: "fallback", URL: server.URL + "/payments"},
+			}},
+		}}
+
+		processors := s.newProcessors()
+		if len(processors) != 2 {
+			t.Fatalf("Expected 2 processors, got: %d", len(processors))
+		}
+
+		// One after the other, the second call reuses the first one's connection
+		for _, processor := range processors {
+			if _, err := processor.LookupPayment(context.Background(), uuid.New()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		if got := connections.Load(); got != 1 {
+			t.Errorf("Expected the processors to share a single connection, got: %d", got)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package sharedstate
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// channelPattern matches the channel names accepted by LISTEN without quoting
+var channelPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Config holds configuration for sharing breaker and health state between instances
+type Config struct {
+	Enabled bool
+	// InstanceID identifies this instance in the shared state, defaults to the host name
+	InstanceID string
+	// Channel is the Postgres LISTEN/NOTIFY channel
+	Channel string
+	// LeaseTTL is how long the elected health poller keeps its lease without renewing it
+	LeaseTTL time.Duration
+	// ReconnectDelay is the pause before listening again after a lost connection
+	ReconnectDelay time.Duration
+	// PublishTimeout bounds each write of a state change
+	PublishTimeout time.Duration
+}
+
+// ConfigManager manages shared state configuration
+type ConfigManager struct {
+	config *Config
+}
+
+// NewConfigManager creates a new shared state configuration manager
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{}
+}
+
+// LoadConfig loads shared state configuration from environment variables
+func (cm *ConfigManager) LoadConfig() error {
+	enabled, err := strconv.ParseBool(getEnvOrDefault("SHARED_STATE_ENABLED", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid SHARED_STATE_ENABLED value: %w", err)
+	}
+
+	leaseTTL, err := time.ParseDuration(getEnvOrDefault("SHARED_STATE_LEASE_TTL", "15s"))
+	if err != nil {
+		return fmt.Errorf("invalid SHARED_STATE_LEASE_TTL value: %w", err)
+	}
+
+	reconnectDelay, err := time.ParseDuration(getEnvOrDefault("SHARED_STATE_RECONNECT_DELAY", "1s"))
+	if err != nil {
+		return fmt.Errorf("invalid SHARED_STATE_RECONNECT_DELAY value: %w", err)
+	}
+
+	publishTimeout, err := time.ParseDuration(getEnvOrDefault("SHARED_STATE_PUBLISH_TIMEOUT", "1s"))
+	if err != nil {
+		return fmt.Errorf("invalid SHARED_STATE_PUBLISH_TIMEOUT value: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+
+	cm.config = &Config{
+		Enabled:        enabled,
+		InstanceID:     getEnvOrDefault("SHARED_STATE_INSTANCE_ID", hostname),
+		Channel:        getEnvOrDefault("SHARED_STATE_CHANNEL", "mr_robot_shared_state"),
+		LeaseTTL:       leaseTTL,
+		ReconnectDelay: reconnectDelay,
+		PublishTimeout: publishTimeout,
+	}
+
+	return nil
+}
+
+// GetConfig returns the loaded shared state configuration
+func (cm *ConfigManager) GetConfig() *Config {
+	return cm.config
+}
+
+// SetConfig sets the configuration (useful for testing)
+func (cm *ConfigManager) SetConfig(config *Config) {
+	cm.config = config
+}
+
+// Validate validates the shared state configuration
+func (cm *ConfigManager) Validate() error {
+	if cm.config == nil {
+		return fmt.Errorf("shared state configuration not loaded")
+	}
+
+	if !cm.config.Enabled {
+		return nil
+	}
+
+	if cm.config.InstanceID == "" {
+		return fmt.Errorf("shared state instance id cannot be empty")
+	}
+
+	if !channelPattern.MatchString(cm.config.Channel) {
+		return fmt.Errorf("shared state channel must be lowercase letters, digits and underscores")
+	}
+
+	if cm.config.LeaseTTL < time.Second {
+		return fmt.Errorf("shared state lease TTL must be at least 1s")
+	}
+
+	if cm.config.ReconnectDelay <= 0 {
+		return fmt.Errorf("shared state reconnect delay must be greater than 0")
+	}
+
+	if cm.config.PublishTimeout <= 0 {
+		return fmt.Errorf("shared state publish timeout must be greater than 0")
+	}
+
+	return nil
+}
+
+// getEnvOrDefault retrieves the value of an environment variable or returns a default value if not set
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,135 @@
+package sharedstate
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigManager_LoadConfig(t *testing.T) {
+	// Save original env vars
+	originalVars := map[string]string{
+		"SHARED_STATE_ENABLED":         os.Getenv("SHARED_STATE_ENABLED"),
+		"SHARED_STATE_INSTANCE_ID":     os.Getenv("SHARED_STATE_INSTANCE_ID"),
+		"SHARED_STATE_CHANNEL":         os.Getenv("SHARED_STATE_CHANNEL"),
+		"SHARED_STATE_LEASE_TTL":       os.Getenv("SHARED_STATE_LEASE_TTL"),
+		"SHARED_STATE_RECONNECT_DELAY": os.Getenv("SHARED_STATE_RECONNECT_DELAY"),
+		"SHARED_STATE_PUBLISH_TIMEOUT": os.Getenv("SHARED_STATE_PUBLISH_TIMEOUT"),
+	}
+
+	// Cleanup function
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Default values", func(t *testing.T) {
+		for key := range originalVars {
+			os.Unsetenv(key)
+		}
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if config.Enabled {
+			t.Error("Expected shared state to be disabled by default")
+		}
+		if hostname, _ := os.Hostname(); config.InstanceID != hostname {
+			t.Errorf("Expected instance id to be the host name %q, got: %q", hostname, config.InstanceID)
+		}
+		if config.Channel != "mr_robot_shared_state" {
+			t.Errorf("Expected default channel, got: %q", config.Channel)
+		}
+		if config.LeaseTTL != 15*time.Second {
+			t.Errorf("Expected lease TTL to be 15s, got: %v", config.LeaseTTL)
+		}
+	})
+
+	t.Run("Custom values", func(t *testing.T) {
+		os.Setenv("SHARED_STATE_ENABLED", "true")
+		os.Setenv("SHARED_STATE_INSTANCE_ID", "api01")
+		os.Setenv("SHARED_STATE_LEASE_TTL", "30s")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		config := cm.GetConfig()
+		if !config.Enabled || config.InstanceID != "api01" || config.LeaseTTL != 30*time.Second {
+			t.Errorf("Expected custom values, got: %+v", config)
+		}
+	})
+
+	t.Run("Invalid lease TTL", func(t *testing.T) {
+		os.Setenv("SHARED_STATE_LEASE_TTL", "invalid")
+
+		cm := NewConfigManager()
+		if err := cm.LoadConfig(); err == nil {
+			t.Fatal("Expected error for invalid lease TTL value")
+		}
+	})
+}
+
+func TestConfigManager_Validate(t *testing.T) {
+	validConfig := func() *Config {
+		return &Config{
+			Enabled:        true,
+			InstanceID:     "api01",
+			Channel:        "mr_robot_shared_state",
+			LeaseTTL:       15 * time.Second,
+			ReconnectDelay: time.Second,
+			PublishTimeout: time.Second,
+		}
+	}
+
+	t.Run("Valid config", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(validConfig())
+
+		if err := cm.Validate(); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid channel", func(t *testing.T) {
+		config := validConfig()
+		config.Channel = "shared-state; DROP TABLE payments"
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for invalid channel")
+		}
+	})
+
+	t.Run("Lease TTL too short", func(t *testing.T) {
+		config := validConfig()
+		config.LeaseTTL = 100 * time.Millisecond
+
+		cm := NewConfigManager()
+		cm.SetConfig(config)
+
+		if err := cm.Validate(); err == nil {
+			t.Error("Expected error for short lease TTL")
+		}
+	})
+
+	t.Run("Disabled skips validation", func(t *testing.T) {
+		cm := NewConfigManager()
+		cm.SetConfig(&Config{})
+
+		if err := cm.Validate(); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package fakeprocessor
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// outcome is the decision taken for a payment request
+type outcome int
+
+const (
+	outcomeAccept outcome = iota
+	outcomeDuplicate
+	outcomeRateLimited
+	outcomeUnavailable
+	outcomeError
+)
+
+func (p *Processor) handlePayment(w http.ResponseWriter, r *http.Request) {
+	var payment Payment
+	if err := json.NewDecoder(r.Body).Decode(&payment); err != nil || payment.CorrelationID == uuid.Nil || payment.Amount <= 0 {
+		writeMessage(w, http.StatusUnprocessableEntity, "invalid payment")
+		return
+	}
+
+	result, latency, retryAfter := p.decide(payment)
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch result {
+	case outcomeDuplicate:
+		writeMessage(w, http.StatusUnprocessableEntity, "payment already exists")
+	case outcomeRateLimited:
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		writeMessage(w, http.StatusTooManyRequests, "too many requests")
+	case outcomeUnavailable:
+		writeMessage(w, http.StatusServiceUnavailable, "service unavailable")
+	case outcomeError:
+		writeMessage(w, http.StatusInternalServerError, "internal server error")
+	default:
+		writeMessage(w, http.StatusOK, "payment processed successfully")
+	}
+}
+
+// decide picks the outcome of a payment request and records accepted payments
+func (p *Processor) decide(payment Payment) (outcome, time.Duration, time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.calls++
+	b := p.behavior
+
+	switch {
+	case b.RateLimitEvery > 0 && p.calls%b.RateLimitEvery == 0:
+		return outcomeRateLimited, b.Latency, b.RetryAfter
+	case p.isDown():
+		return outcomeUnavailable, b.Latency, 0
+	case b.Failing, b.ErrorRate > 0 && p.random.Float64() < b.ErrorRate:
+		return outcomeError, b.Latency, 0
+	}
+
+	if _, exists := p.payments[payment.CorrelationID]; exists {
+		return outcomeDuplicate, b.Latency, 0
+	}
+
+	if payment.RequestedAt.IsZero() {
+		payment.RequestedAt = p.options.Now().UTC()
+	}
+
+	p.payments[payment.CorrelationID] = payment
+	p.order = append(p.order, payment.CorrelationID)
+
+	if b.DuplicateRate > 0 && p.random.Float64() < b.DuplicateRate {
+		return outcomeDuplicate, b.Latency, 0
+	}
+
+	return outcomeAccept, b.Latency, 0
+}
+
+func (p *Processor) handleHealth(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	now := p.options.Now()
+
+	if !p.lastHealth.IsZero() && now.Sub(p.lastHealth) < p.options.HealthInterval {
+		p.mutex.Unlock()
+		writeMessage(w, http.StatusTooManyRequests, "too many requests")
+		return
+	}
+
+	p.lastHealth = now
+	health := map[string]any{
+		"failing":         p.behavior.Failing || p.isDown(),
+		"minResponseTime": p.behavior.MinResponseTime,
+	}
+	p.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, health)
+}
+
+func (p *Processor) handleLookup(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMessage(w, http.StatusNotFound, "payment not found")
+		return
+	}
+
+	p.mutex.Lock()
+	payment, ok := p.payments[id]
+	p.mutex.Unlock()
+
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "payment not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, payment)
+}
+
+func (p *Processor) handleSummary(w http.ResponseWriter, r *http.Request) {
+	if !p.authorized(r) {
+		writeMessage(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	from, to, ok := parseWindow(r)
+	if !ok {
+		writeMessage(w, http.StatusBadRequest, "invalid from or to")
+		return
+	}
+
+	p.mutex.Lock()
+	var totalRequests int64
+	var totalAmount float64
+	for _, payment := range p.payments {
+		if (from.IsZero() || !payment.RequestedAt.Before(from)) && (to.IsZero() || !payment.RequestedAt.After(to)) {
+			totalRequests++
+			totalAmount += payment.Amount
+		}
+	}
+	p.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"totalRequests":     totalRequests,
+		"totalAmount":       totalAmount,
+		"totalFee":          totalAmount * p.options.Fee,
+		"feePerTransaction": p.options.Fee,
+	})
+}
+
+func (p *Processor) handlePurge(w http.ResponseWriter, r *http.Request) {
+	if !p.authorized(r) {
+		writeMessage(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	p.Purge()
+	writeMessage(w, http.StatusOK, "all payments purged")
+}
+
+func (p *Processor) handleFailureConfig(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Failure bool `json:"failure"`
+	}
+
+	if !p.authorized(r) {
+		writeMessage(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeMessage(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	p.mutex.Lock()
+	p.behavior.Failing = body.Failure
+	p.mutex.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *Processor) handleDelayConfig(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Delay int `json:"delay"`
+	}
+
+	if !p.authorized(r) {
+		writeMessage(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Delay < 0 {
+		writeMessage(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	p.mutex.Lock()
+	p.behavior.Latency = time.Duration(body.Delay) * time.Millisecond
+	p.behavior.MinResponseTime = body.Delay
+	p.mutex.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseWindow parses the optional from and to query parameters
+func parseWindow(r *http.Request) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	var err error
+
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return from, to, false
+		}
+	}
+
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return from, to, false
+		}
+	}
+
+	return from, to, true
+}
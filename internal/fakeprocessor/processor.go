@@ -0,0 +1,196 @@
+// Package fakeprocessor imitates the payment processor API so the gateway and
+// the payment service can be tested offline and deterministically with httptest.
+package fakeprocessor
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	adminTokenHeader      = "X-Rinha-Token"
+	defaultHealthInterval = 5 * time.Second
+)
+
+// Options configures a fake processor
+type Options struct {
+	// Fee is the transaction fee reported by the admin summary
+	Fee float64
+	// AdminToken is required by the admin endpoints when set
+	AdminToken string
+	// HealthInterval is the minimum interval between service-health calls
+	HealthInterval time.Duration
+	// Seed makes the random failure modes reproducible
+	Seed uint64
+	// Now overrides the clock used for outage windows and timestamps
+	Now func() time.Time
+}
+
+// Window is a period, relative to the processor start, during which it is down
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Behavior describes the failure modes of the fake processor
+type Behavior struct {
+	// Failing answers every payment with 500 and reports failing on service-health
+	Failing bool
+	// ErrorRate is the fraction of payments answered with 500
+	ErrorRate float64
+	// Latency delays every payment response
+	Latency time.Duration
+	// MinResponseTime is reported by service-health
+	MinResponseTime int
+	// Outages are windows during which payments are answered with 503
+	Outages []Window
+	// RateLimitEvery answers every Nth payment with 429
+	RateLimitEvery int
+	// RetryAfter is sent with 429 answers
+	RetryAfter time.Duration
+	// DuplicateRate is the fraction of new payments recorded but answered as duplicates
+	DuplicateRate float64
+}
+
+// Payment is a payment recorded by the fake processor
+type Payment struct {
+	CorrelationID uuid.UUID `json:"correlationId"`
+	Amount        float64   `json:"amount"`
+	RequestedAt   time.Time `json:"requestedAt"`
+}
+
+// Processor is an in-memory payment processor
+type Processor struct {
+	options    Options
+	behavior   Behavior
+	payments   map[uuid.UUID]Payment
+	order      []uuid.UUID
+	calls      int
+	lastHealth time.Time
+	started    time.Time
+	random     *rand.Rand
+	mutex      sync.Mutex
+	mux        *http.ServeMux
+}
+
+// New creates a fake processor
+func New(opts Options) *Processor {
+	if opts.HealthInterval == 0 {
+		opts.HealthInterval = defaultHealthInterval
+	}
+
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	p := &Processor{
+		options:  opts,
+		payments: make(map[uuid.UUID]Payment),
+		started:  opts.Now(),
+		random:   rand.New(rand.NewPCG(opts.Seed, opts.Seed)),
+		mux:      http.NewServeMux(),
+	}
+
+	p.mux.HandleFunc("POST /payments", p.handlePayment)
+	p.mux.HandleFunc("GET /payments/service-health", p.handleHealth)
+	p.mux.HandleFunc("GET /payments/{id}", p.handleLookup)
+	p.mux.HandleFunc("GET /admin/payments-summary", p.handleSummary)
+	p.mux.HandleFunc("POST /admin/purge-payments", p.handlePurge)
+	p.mux.HandleFunc("PUT /admin/configurations/failure", p.handleFailureConfig)
+	p.mux.HandleFunc("PUT /admin/configurations/delay", p.handleDelayConfig)
+
+	return p
+}
+
+// ServeHTTP implements http.Handler
+func (p *Processor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+// SetBehavior replaces the failure modes of the processor
+func (p *Processor) SetBehavior(b Behavior) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.behavior = b
+}
+
+// Behavior returns the current failure modes of the processor
+func (p *Processor) Behavior() Behavior {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.behavior
+}
+
+// Payments returns the recorded payments in arrival order
+func (p *Processor) Payments() []Payment {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	payments := make([]Payment, 0, len(p.order))
+	for _, id := range p.order {
+		payments = append(payments, p.payments[id])
+	}
+
+	return payments
+}
+
+// Calls returns how many payment requests were received
+func (p *Processor) Calls() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.calls
+}
+
+// Has reports whether the processor recorded the payment
+func (p *Processor) Has(correlationID uuid.UUID) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	_, ok := p.payments[correlationID]
+	return ok
+}
+
+// Purge removes every recorded payment
+func (p *Processor) Purge() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.payments = make(map[uuid.UUID]Payment)
+	p.order = nil
+}
+
+// isDown reports whether the processor is inside an outage window. The
+// caller must hold the mutex.
+func (p *Processor) isDown() bool {
+	elapsed := p.options.Now().Sub(p.started)
+	for _, window := range p.behavior.Outages {
+		if elapsed >= window.Start && elapsed < window.End {
+			return true
+		}
+	}
+	return false
+}
+
+// authorized checks the admin token when one is configured
+func (p *Processor) authorized(r *http.Request) bool {
+	return p.options.AdminToken == "" || r.Header.Get(adminTokenHeader) == p.options.AdminToken
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if data != nil {
+		_ = json.NewEncoder(w).Encode(data)
+	}
+}
+
+// writeMessage writes a JSON response with a single message
+func writeMessage(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]string{"message": message})
+}
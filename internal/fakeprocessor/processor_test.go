@@ -0,0 +1,135 @@
+package fakeprocessor
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func postPayment(t *testing.T, server *httptest.Server, id uuid.UUID) *http.Response {
+	t.Helper()
+
+	body, _ := json.Marshal(Payment{CorrelationID: id, Amount: 19.9, RequestedAt: time.Now().UTC()})
+	resp, err := http.Post(server.URL+"/payments", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+
+	return resp
+}
+
+func TestProcessor_Payments(t *testing.T) {
+	t.Run("Accepts and rejects duplicates", func(t *testing.T) {
+		processor := New(Options{})
+		server := httptest.NewServer(processor)
+		defer server.Close()
+
+		id := uuid.New()
+		if resp := postPayment(t, server, id); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected 200, got: %d", resp.StatusCode)
+		}
+		if resp := postPayment(t, server, id); resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected 422 for duplicate, got: %d", resp.StatusCode)
+		}
+		if !processor.Has(id) || len(processor.Payments()) != 1 {
+			t.Error("Expected the payment to be recorded once")
+		}
+	})
+
+	t.Run("Rate limits every Nth payment", func(t *testing.T) {
+		processor := New(Options{})
+		processor.SetBehavior(Behavior{RateLimitEvery: 2, RetryAfter: 3 * time.Second})
+		server := httptest.NewServer(processor)
+		defer server.Close()
+
+		postPayment(t, server, uuid.New())
+		resp := postPayment(t, server, uuid.New())
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("Expected 429, got: %d", resp.StatusCode)
+		}
+		if resp.Header.Get("Retry-After") != "3" {
+			t.Errorf("Expected Retry-After 3, got: %q", resp.Header.Get("Retry-After"))
+		}
+	})
+
+	t.Run("Outage window", func(t *testing.T) {
+		now := time.Now()
+		processor := New(Options{Now: func() time.Time { return now }})
+		processor.SetBehavior(Behavior{Outages: []Window{{Start: time.Second, End: 2 * time.Second}}})
+		server := httptest.NewServer(processor)
+		defer server.Close()
+
+		if resp := postPayment(t, server, uuid.New()); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected 200 before the outage, got: %d", resp.StatusCode)
+		}
+
+		now = now.Add(1500 * time.Millisecond)
+		if resp := postPayment(t, server, uuid.New()); resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("Expected 503 during the outage, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Error rate is reproducible", func(t *testing.T) {
+		failures := func() int {
+			processor := New(Options{Seed: 42})
+			processor.SetBehavior(Behavior{ErrorRate: 0.5})
+			server := httptest.NewServer(processor)
+			defer server.Close()
+
+			count := 0
+			for range 20 {
+				if postPayment(t, server, uuid.New()).StatusCode == http.StatusInternalServerError {
+					count++
+				}
+			}
+			return count
+		}
+
+		first := failures()
+		if first == 0 || first == 20 {
+			t.Fatalf("Expected some but not all payments to fail, got: %d", first)
+		}
+		if second := failures(); second != first {
+			t.Errorf("Expected %d failures with the same seed, got: %d", first, second)
+		}
+	})
+}
+
+func TestProcessor_HealthAndAdmin(t *testing.T) {
+	processor := New(Options{Fee: 0.05, AdminToken: "123"})
+	processor.SetBehavior(Behavior{Failing: true, MinResponseTime: 40})
+	server := httptest.NewServer(processor)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/payments/service-health")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var health struct {
+		Failing         bool `json:"failing"`
+		MinResponseTime int  `json:"minResponseTime"`
+	}
+	json.NewDecoder(resp.Body).Decode(&health)
+	resp.Body.Close()
+	if !health.Failing || health.MinResponseTime != 40 {
+		t.Errorf("Expected failing with 40ms, got: %+v", health)
+	}
+
+	resp, _ = http.Get(server.URL + "/payments/service-health")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 for a second health call, got: %d", resp.StatusCode)
+	}
+
+	resp, _ = http.Get(server.URL + "/admin/payments-summary")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got: %d", resp.StatusCode)
+	}
+}
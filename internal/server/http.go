@@ -2,13 +2,14 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
+	"expvar"
 	"log"
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
 	"path/filepath"
-	"syscall"
+	"strings"
 	"time"
 
 	"github.com/fabianoflorentino/mr-robot/adapters/inbound/http/controllers"
@@ -21,12 +22,19 @@ var (
 	USE_UNIX_SOCKET        = os.Getenv("USE_UNIX_SOCKET") == "true"
 )
 
-func InitHTTPServer(container container.Container) {
+// InitHTTPServer serves the API until ctx is done, then stops accepting
+// connections and waits for the requests in progress before returning
+func InitHTTPServer(ctx context.Context, container container.Container) {
 	mux := http.NewServeMux()
 
 	// Register routes
 	registerPaymentRoutes(mux, container)
-	registerHealthCheckRoutes(mux)
+	registerHealthCheckRoutes(mux, container)
+	registerAuditRoutes(mux, container)
+	registerCircuitBreakerRoutes(mux, container)
+	registerDeadLetterRoutes(mux, container)
+	registerQueueRoutes(mux, container)
+	registerMetricsRoutes(mux, container)
 
 	// Add middleware
 	handler := loggingMiddleware(mux)
@@ -86,19 +94,17 @@ func InitHTTPServer(container container.Container) {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	<-quit
+	// Wait for the caller's shutdown signal
+	<-ctx.Done()
 
 	log.Println("Shutting down server...")
 
 	// Create a deadline to wait for.
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Attempt graceful shutdown
-	if err := server.Shutdown(ctx); err != nil {
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
@@ -120,12 +126,81 @@ func registerPaymentRoutes(mux *http.ServeMux, c container.Container) {
 	mux.HandleFunc("DELETE /payments-purge", paymentController.PurgePayments)
 }
 
-func registerHealthCheckRoutes(mux *http.ServeMux) {
-	healthCheckController := controllers.NewHealthCheckController()
+func registerAuditRoutes(mux *http.ServeMux, c container.Container) {
+	auditController := controllers.NewAuditController(c.GetAuditService())
+	token := c.GetAdminConfig().Token
+
+	mux.HandleFunc("GET /admin/payments-audit", adminAuthMiddleware(token, auditController.PaymentsAudit))
+}
+
+func registerCircuitBreakerRoutes(mux *http.ServeMux, c container.Container) {
+	circuitBreakerController := controllers.NewCircuitBreakerController(c.GetCircuitBreakerService())
+	token := c.GetAdminConfig().Token
+
+	mux.HandleFunc("GET /admin/circuit-breakers", adminAuthMiddleware(token, circuitBreakerController.CircuitBreakers))
+	mux.HandleFunc("GET /admin/circuit-breakers/events", adminAuthMiddleware(token, circuitBreakerController.Events))
+	mux.HandleFunc("POST /admin/circuit-breakers/{processor}/{action}", adminAuthMiddleware(token, circuitBreakerController.Control))
+}
+
+func registerDeadLetterRoutes(mux *http.ServeMux, c container.Container) {
+	deadLetterController := controllers.NewDeadLetterController(c.GetDeadLetterService())
+	token := c.GetAdminConfig().Token
+
+	mux.HandleFunc("GET /admin/dead-letters", adminAuthMiddleware(token, deadLetterController.DeadLetters))
+	mux.HandleFunc("GET /admin/dead-letters/{id}", adminAuthMiddleware(token, deadLetterController.DeadLetter))
+	mux.HandleFunc("POST /admin/dead-letters/replay", adminAuthMiddleware(token, deadLetterController.ReplayAll))
+	mux.HandleFunc("POST /admin/dead-letters/{id}/replay", adminAuthMiddleware(token, deadLetterController.Replay))
+	mux.HandleFunc("DELETE /admin/dead-letters", adminAuthMiddleware(token, deadLetterController.DiscardAll))
+	mux.HandleFunc("DELETE /admin/dead-letters/{id}", adminAuthMiddleware(token, deadLetterController.Discard))
+}
+
+// registerQueueRoutes exposes the queue stats without the admin token, like
+// the health check, for load balancers and autoscalers. Resizing the worker
+// pool requires the token.
+func registerQueueRoutes(mux *http.ServeMux, c container.Container) {
+	queueController := controllers.NewQueueController(c.GetPaymentQueue())
+	token := c.GetAdminConfig().Token
+
+	mux.HandleFunc("GET /queue/stats", queueController.Stats)
+	mux.HandleFunc("GET /admin/queue/workers", adminAuthMiddleware(token, queueController.Workers))
+	mux.HandleFunc("PUT /admin/queue/workers", adminAuthMiddleware(token, queueController.ResizeWorkers))
+}
+
+// registerMetricsRoutes exposes the expvar metrics, including circuit breaker
+// transitions. They require the admin token, as expvar also publishes the
+// command line and memory stats.
+func registerMetricsRoutes(mux *http.ServeMux, c container.Container) {
+	token := c.GetAdminConfig().Token
+
+	mux.HandleFunc("GET /debug/vars", adminAuthMiddleware(token, expvar.Handler().ServeHTTP))
+}
+
+func registerHealthCheckRoutes(mux *http.ServeMux, c container.Container) {
+	healthCheckController := controllers.NewHealthCheckController(c.GetBulkheadService())
 
 	mux.HandleFunc("GET /health", healthCheckController.HealthCheck)
 }
 
+// adminAuthMiddleware requires the admin API token as a bearer token. Without
+// a configured token the admin API is disabled.
+func adminAuthMiddleware(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.Error(w, "admin API is disabled", http.StatusServiceUnavailable)
+			return
+		}
+
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()